@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/kodra-pay/checkout-service/internal/dto"
@@ -14,6 +15,7 @@ import (
 // TransactionClient defines the interface for interacting with the Transaction Service
 type TransactionClient interface {
 	CreateTransaction(ctx context.Context, req dto.TransactionCreateRequest) (*dto.TransactionResponse, error)
+	UpdateTransactionStatus(ctx context.Context, reference string, req dto.TransactionStatusUpdateRequest) (*dto.TransactionResponse, error)
 }
 
 // HTTPTransactionClient implements TransactionClient using HTTP
@@ -62,6 +64,36 @@ func (c *HTTPTransactionClient) CreateTransaction(ctx context.Context, req dto.T
 	return &resp, nil
 }
 
+func (c *HTTPTransactionClient) UpdateTransactionStatus(ctx context.Context, reference string, req dto.TransactionStatusUpdateRequest) (*dto.TransactionResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal transaction status update request: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/transactions/%s/status", c.baseURL, url.PathEscape(reference))
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPatch, endpoint, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create http request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	httpResp, err := c.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send http request to transaction service: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("transaction service returned non-200 status for status update: %d", httpResp.StatusCode)
+	}
+
+	var resp dto.TransactionResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to decode transaction response: %w", err)
+	}
+	return &resp, nil
+}
+
 // WalletLedgerClient defines the interface for interacting with the Wallet-Ledger Service
 type WalletLedgerClient interface {
 	GetWalletByUserIDAndCurrency(ctx context.Context, userID int, currency string) (*dto.WalletResponse, error)
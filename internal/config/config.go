@@ -2,7 +2,9 @@ package config
 
 import (
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type Config struct {
@@ -13,8 +15,42 @@ type Config struct {
 	TransactionServiceURL  string
 	WalletLedgerServiceURL string
 	FeeServiceURL          string
-	FraudServiceURL        string // New field for Fraud Service URL
-	FraudServiceAPIKey     string // New field for Fraud Service API Key
+	FraudServiceURL        string        // New field for Fraud Service URL
+	FraudServiceAPIKey     string        // New field for Fraud Service API Key
+	AdminAPIToken          string        // bearer token for /admin and /reviews; empty disables them
+	FraudFailPolicy        string        // closed, open or local when fraud-service is unreachable
+	FraudFailOpenMaxAmount float64       // payments above this fail closed even under open/local; 0 means no ceiling
+	FraudLocalFlagAmount   float64       // local rules flag payments at or above this amount
+	FraudLocalDenyAmount   float64       // local rules deny payments at or above this amount
+	FraudRescreenInterval  time.Duration // how often unscreened payments are retried against fraud-service
+	VelocityStore          string        // memory or redis
+	VelocityRules          string        // JSON rule list; empty uses the built-in defaults
+	// Limits for merchants without configured limits (newly onboarded); 0 means no limit
+	MerchantDefaultMinAmount     float64
+	MerchantDefaultMaxAmount     float64
+	MerchantDefaultDailyVolume   float64
+	MerchantDefaultMonthlyVolume float64
+	GeoIPDatabasePath            string        // .mmdb or start,end,country CSV; empty disables country resolution
+	GeoIPReloadInterval          time.Duration // how often the database file is checked for changes
+	PaymentLinkExpiryInterval    time.Duration // how often links past expires_at are marked expired
+	CheckoutBaseURL              string        // hosted checkout origin for payment link URLs, e.g. https://pay.kodrapay.com
+	PaymentLinkBulkLimit         int           // most links accepted by one bulk upload
+	InvoiceOverdueInterval       time.Duration // how often sent invoices past their due date are marked overdue
+	MerchantServiceURL           string
+	KYCTimeout                   time.Duration // per request to merchant-service
+	KYCCacheTTL                  time.Duration // how long a merchant's KYC status is served without revalidation
+	KYCStaleTTL                  time.Duration // how much longer a status is served while it is revalidated in the background
+	KYCFailOpen                  bool          // allow payments when the status is unknown and merchant-service is down
+	APIKeyEncryptionKey          string        // base64 AES-256 key sealing HMAC signing secrets; empty disables hmac keys
+	SignatureMaxSkew             time.Duration // how far a signed request's timestamp may drift from now
+	NonceStore                   string        // memory or redis; where signed-request nonces are remembered
+	CheckoutSessionTTL           time.Duration // how long a checkout session can be paid; 0 means sessions never expire
+	SessionExpiryInterval        time.Duration // how often sessions past expires_at are marked expired
+	WebhookDeliveryInterval      time.Duration // how often the webhook queue is checked for due deliveries
+	WebhookTimeout               time.Duration // per delivery attempt
+	WebhookMaxAttempts           int           // attempts before a delivery is dead-lettered
+	WebhookInitialBackoff        time.Duration // wait after the first failed attempt; doubles after each one
+	WebhookMaxBackoff            time.Duration
 }
 
 func Load(serviceName, defaultPort string) Config {
@@ -28,15 +64,48 @@ func Load(serviceName, defaultPort string) Config {
 	}
 
 	return Config{
-		ServiceName:            serviceName,
-		Port:                   getEnv("PORT", defaultPort),
-		PostgresDSN:            dsn,
-		RedisAddr:              getEnv("REDIS_ADDR", "redis:6379"),
-		TransactionServiceURL:  getEnv("TRANSACTION_SERVICE_URL", "http://transaction-service:7004/api/v1"),     // Align with docker-compose port
-		WalletLedgerServiceURL: getEnv("WALLET_LEDGER_SERVICE_URL", "http://wallet-ledger-service:7007/api/v1"), // Align with docker-compose port
-		FeeServiceURL:          getEnv("FEE_SERVICE_URL", "http://fee-service:7017"),                            // Fee service base
-		FraudServiceURL:        getEnv("FRAUD_SERVICE_URL", "http://fraud-service:7012"),                         // Fraud service base
-		FraudServiceAPIKey:     getEnv("FRAUD_SERVICE_API_KEY", "my-secret-api-key"),                          // Fraud service API key
+		ServiceName:                  serviceName,
+		Port:                         getEnv("PORT", defaultPort),
+		PostgresDSN:                  dsn,
+		RedisAddr:                    getEnv("REDIS_ADDR", "redis:6379"),
+		TransactionServiceURL:        getEnv("TRANSACTION_SERVICE_URL", "http://transaction-service:7004/api/v1"),     // Align with docker-compose port
+		WalletLedgerServiceURL:       getEnv("WALLET_LEDGER_SERVICE_URL", "http://wallet-ledger-service:7007/api/v1"), // Align with docker-compose port
+		FeeServiceURL:                getEnv("FEE_SERVICE_URL", "http://fee-service:7017"),                            // Fee service base
+		FraudServiceURL:              getEnv("FRAUD_SERVICE_URL", "http://fraud-service:7012"),                        // Fraud service base
+		FraudServiceAPIKey:           getEnv("FRAUD_SERVICE_API_KEY", "my-secret-api-key"),                            // Fraud service API key
+		AdminAPIToken:                getEnv("ADMIN_API_TOKEN", ""),
+		FraudFailPolicy:              getEnv("FRAUD_FAIL_POLICY", "closed"),
+		FraudFailOpenMaxAmount:       getEnvFloat("FRAUD_FAIL_OPEN_MAX_AMOUNT", 2000000),
+		FraudLocalFlagAmount:         getEnvFloat("FRAUD_LOCAL_FLAG_AMOUNT", 200000),
+		FraudLocalDenyAmount:         getEnvFloat("FRAUD_LOCAL_DENY_AMOUNT", 2000000),
+		FraudRescreenInterval:        getEnvDuration("FRAUD_RESCREEN_INTERVAL", time.Minute),
+		VelocityStore:                getEnv("VELOCITY_STORE", "memory"),
+		VelocityRules:                getEnv("VELOCITY_RULES", ""),
+		MerchantDefaultMinAmount:     getEnvFloat("MERCHANT_DEFAULT_MIN_AMOUNT", 0), // 0 leaves merchants without limits uncapped
+		MerchantDefaultMaxAmount:     getEnvFloat("MERCHANT_DEFAULT_MAX_AMOUNT", 0),
+		MerchantDefaultDailyVolume:   getEnvFloat("MERCHANT_DEFAULT_DAILY_VOLUME", 0),
+		MerchantDefaultMonthlyVolume: getEnvFloat("MERCHANT_DEFAULT_MONTHLY_VOLUME", 0),
+		GeoIPDatabasePath:            getEnv("GEOIP_DB_PATH", ""),
+		GeoIPReloadInterval:          getEnvDuration("GEOIP_RELOAD_INTERVAL", time.Minute),
+		PaymentLinkExpiryInterval:    getEnvDuration("PAYMENT_LINK_EXPIRY_INTERVAL", time.Minute),
+		CheckoutBaseURL:              getEnv("CHECKOUT_BASE_URL", ""),
+		PaymentLinkBulkLimit:         getEnvInt("PAYMENT_LINK_BULK_LIMIT", 500),
+		InvoiceOverdueInterval:       getEnvDuration("INVOICE_OVERDUE_INTERVAL", time.Hour),
+		MerchantServiceURL:           getEnv("MERCHANT_SERVICE_URL", "http://merchant-service:7002/api/v1"),
+		KYCTimeout:                   getEnvDuration("KYC_TIMEOUT", 3*time.Second),
+		KYCCacheTTL:                  getEnvDuration("KYC_CACHE_TTL", 5*time.Minute),
+		KYCStaleTTL:                  getEnvDuration("KYC_STALE_TTL", 30*time.Minute),
+		KYCFailOpen:                  getEnv("KYC_FAIL_POLICY", "closed") == "open",
+		APIKeyEncryptionKey:          getEnv("API_KEY_ENCRYPTION_KEY", ""),
+		SignatureMaxSkew:             getEnvDuration("SIGNATURE_MAX_SKEW", 5*time.Minute),
+		NonceStore:                   getEnv("NONCE_STORE", "memory"),
+		CheckoutSessionTTL:           getEnvDuration("CHECKOUT_SESSION_TTL", 24*time.Hour),
+		SessionExpiryInterval:        getEnvDuration("SESSION_EXPIRY_INTERVAL", time.Minute),
+		WebhookDeliveryInterval:      getEnvDuration("WEBHOOK_DELIVERY_INTERVAL", 5*time.Second),
+		WebhookTimeout:               getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+		WebhookMaxAttempts:           getEnvInt("WEBHOOK_MAX_ATTEMPTS", 10),
+		WebhookInitialBackoff:        getEnvDuration("WEBHOOK_INITIAL_BACKOFF", 30*time.Second),
+		WebhookMaxBackoff:            getEnvDuration("WEBHOOK_MAX_BACKOFF", 6*time.Hour),
 	}
 }
 
@@ -46,3 +115,30 @@ func getEnv(key, def string) string {
 	}
 	return def
 }
+
+func getEnvFloat(key string, def float64) float64 {
+	if v := os.Getenv(key); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	}
+	return def
+}
+
+func getEnvInt(key string, def int) int {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			return n
+		}
+	}
+	return def
+}
+
+func getEnvDuration(key string, def time.Duration) time.Duration {
+	if v := os.Getenv(key); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+	}
+	return def
+}
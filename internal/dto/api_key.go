@@ -0,0 +1,40 @@
+package dto
+
+type APIKeyCreateRequest struct {
+	Name string `json:"name"` // label shown in key listings, e.g. "production server"
+	Type string `json:"type"` // secret (default) or publishable
+	// bearer (default) sends the key in Authorization; hmac keys sign each request instead and
+	// are refused as bearer tokens
+	AuthMethod string `json:"auth_method,omitempty"`
+	// Secret keys: restrict the key to these scopes; omit for an unrestricted key
+	Scopes []string `json:"scopes,omitempty"`
+	// Publishable keys: origins allowed to use the key, e.g. https://shop.example.com; at least one
+	AllowedOrigins []string `json:"allowed_origins,omitempty"`
+}
+
+// APIKeyRotateRequest replaces a key; the old key keeps working for the grace period so
+// deployments can switch over. Omit grace_period_seconds for the default; 0 retires it now.
+type APIKeyRotateRequest struct {
+	GracePeriodSeconds *int64 `json:"grace_period_seconds,omitempty"`
+}
+
+type APIKeyResponse struct {
+	ID             int      `json:"id"`
+	MerchantID     int      `json:"merchant_id"`
+	Name           string   `json:"name"`
+	Type           string   `json:"type"`
+	AuthMethod     string   `json:"auth_method"`
+	Prefix         string   `json:"prefix"`
+	Scopes         []string `json:"scopes"` // every scope for unrestricted secret keys
+	AllowedOrigins []string `json:"allowed_origins,omitempty"`
+	Status         string   `json:"status"`           // active, expiring (rotated, in its grace period), expired or revoked
+	Secret         string   `json:"secret,omitempty"` // the full key; only returned when it is created
+	ExpiresAt      string   `json:"expires_at,omitempty"`
+	RevokedAt      string   `json:"revoked_at,omitempty"`
+	LastUsedAt     string   `json:"last_used_at,omitempty"`
+	CreatedAt      string   `json:"created_at"`
+}
+
+type APIKeyListResponse struct {
+	Keys []APIKeyResponse `json:"keys"`
+}
@@ -0,0 +1,15 @@
+package dto
+
+// BrandingRequest replaces a merchant's branding
+type BrandingRequest struct {
+	DisplayName string `json:"display_name"`
+	Logo        string `json:"logo"` // base64-encoded PNG or JPEG; empty removes the logo
+}
+
+type BrandingResponse struct {
+	MerchantID  int    `json:"merchant_id"`
+	DisplayName string `json:"display_name"`
+	HasLogo     bool   `json:"has_logo"`
+	LogoType    string `json:"logo_type,omitempty"`
+	UpdatedAt   string `json:"updated_at,omitempty"`
+}
@@ -0,0 +1,16 @@
+package dto
+
+// CountryRulesRequest replaces a merchant's country restrictions (ISO 3166-1 alpha-2 codes)
+type CountryRulesRequest struct {
+	AllowedCountries []string `json:"allowed_countries"` // empty allows all countries not blocked
+	BlockedCountries []string `json:"blocked_countries"`
+	BlockUnknown     bool     `json:"block_unknown"`
+}
+
+type CountryRulesResponse struct {
+	MerchantID       int      `json:"merchant_id"`
+	AllowedCountries []string `json:"allowed_countries"`
+	BlockedCountries []string `json:"blocked_countries"`
+	BlockUnknown     bool     `json:"block_unknown"`
+	UpdatedAt        string   `json:"updated_at,omitempty"`
+}
@@ -3,8 +3,8 @@ package dto
 import "time"
 
 type CheckoutSessionRequest struct {
-	MerchantID    int     `json:"merchant_id,omitempty"`
-	Amount        float64 `json:"amount,omitempty"` // currency units (e.g., NGN)
+	MerchantID    int     `json:"merchant_id,omitempty"` // set from the API key; a value in the body is ignored
+	Amount        float64 `json:"amount,omitempty"`      // currency units (e.g., NGN)
 	Currency      string  `json:"currency"`
 	Description   string  `json:"description"`
 	CustomerEmail string  `json:"customer_email,omitempty"`
@@ -12,10 +12,14 @@ type CheckoutSessionRequest struct {
 }
 
 type CheckoutSessionResponse struct {
-	ID       int     `json:"id"`
-	Status   string  `json:"status"`
-	Amount   float64 `json:"amount"` // currency units (e.g., NGN)
-	Currency string  `json:"currency"`
+	ID          int     `json:"id"`
+	MerchantID  int     `json:"merchant_id,omitempty"`
+	Status      string  `json:"status"`
+	Amount      float64 `json:"amount"` // currency units (e.g., NGN)
+	Currency    string  `json:"currency"`
+	Description string  `json:"description,omitempty"`
+	ExpiresAt   string  `json:"expires_at,omitempty"`
+	CreatedAt   string  `json:"created_at,omitempty"`
 }
 
 type CheckoutPayRequest struct {
@@ -23,6 +27,7 @@ type CheckoutPayRequest struct {
 	PaymentLinkID int     `json:"payment_link_id,omitempty"`
 	PaymentMethod string  `json:"payment_method,omitempty"`
 	TokenID       string  `json:"token_id,omitempty"`
+	CardBIN       string  `json:"card_bin,omitempty"` // first 6-8 digits of the card, screened against blocklists
 	MerchantID    int     `json:"merchant_id,omitempty"`
 	Amount        float64 `json:"amount,omitempty"` // currency units (e.g., NGN)
 	Currency      string  `json:"currency,omitempty"`
@@ -32,11 +37,64 @@ type CheckoutPayRequest struct {
 	Description   string  `json:"description,omitempty"`
 	Reference     string  `json:"reference,omitempty"`
 	Origin        string  `json:"origin,omitempty"` // Added for client IP
+	// Device signals forwarded to fraud-service
+	CustomFields      map[string]interface{} `json:"custom_fields,omitempty"`      // answers to the payment link's custom fields
+	DeviceFingerprint string                 `json:"device_fingerprint,omitempty"` // collected by the hosted page
+	UserAgent         string                 `json:"-"`                            // set from the request headers
+	AcceptLanguage    string                 `json:"-"`                            // set from the request headers
 }
 
 type CheckoutPayResponse struct {
-	TransactionReference string `json:"transaction_reference,omitempty"`
-	Status               string `json:"status"`
+	TransactionReference string                 `json:"transaction_reference,omitempty"`
+	Status               string                 `json:"status"`
+	Amount               float64                `json:"amount,omitempty"` // gross amount charged, currency units (e.g., NGN)
+	Currency             string                 `json:"currency,omitempty"`
+	Fee                  *FeeBreakdown          `json:"fee,omitempty"`
+	NetAmount            float64                `json:"net_amount,omitempty"` // amount minus fee, currency units (e.g., NGN)
+	Fraud                *FraudOutcome          `json:"fraud,omitempty"`
+	WalletCredit         string                 `json:"wallet_credit,omitempty"` // "credited", "failed" or "skipped"
+	SessionID            int                    `json:"session_id,omitempty"`
+	PaymentLinkID        int                    `json:"payment_link_id,omitempty"`
+	CustomFields         map[string]interface{} `json:"custom_fields,omitempty"`
+}
+
+// CheckoutPaymentResponse is a payment looked up by its transaction reference
+type CheckoutPaymentResponse struct {
+	TransactionReference string                 `json:"transaction_reference"`
+	Status               string                 `json:"status"`
+	MerchantID           int                    `json:"merchant_id"`
+	SessionID            *int                   `json:"session_id,omitempty"`
+	PaymentLinkID        *int                   `json:"payment_link_id,omitempty"`
+	CustomerID           int                    `json:"customer_id,omitempty"`
+	CustomerEmail        string                 `json:"customer_email,omitempty"`
+	Amount               float64                `json:"amount"`     // currency units (e.g., NGN)
+	FeeAmount            float64                `json:"fee_amount"` // currency units (e.g., NGN)
+	NetAmount            float64                `json:"net_amount"` // currency units (e.g., NGN)
+	Currency             string                 `json:"currency"`
+	PaymentMethod        string                 `json:"payment_method,omitempty"`
+	FraudDecision        string                 `json:"fraud_decision,omitempty"`
+	Country              string                 `json:"country,omitempty"`
+	CustomFields         map[string]interface{} `json:"custom_fields,omitempty"`
+	CreatedAt            string                 `json:"created_at"`
+}
+
+// FeeBreakdown describes how the fee on a payment was computed by fee-service
+type FeeBreakdown struct {
+	Total   float64 `json:"total"` // currency units (e.g., NGN)
+	Rate    float64 `json:"rate"`
+	Flat    float64 `json:"flat"`
+	Capped  bool    `json:"capped"`
+	Cap     float64 `json:"cap,omitempty"`
+	Channel string  `json:"channel,omitempty"`
+}
+
+// FraudOutcome is the fraud-service verdict returned alongside a payment
+type FraudOutcome struct {
+	Decision   string   `json:"decision"`
+	Score      float64  `json:"score"`
+	Reasons    []string `json:"reasons,omitempty"`
+	Source     string   `json:"source"`               // fraud_service, local_rules, fail_open, velocity, blocklist or country_restriction
+	Unscreened bool     `json:"unscreened,omitempty"` // fraud-service never saw the payment; it will be re-screened
 }
 
 // TransactionCreateRequest DTO for creating a new transaction in transaction-service
@@ -67,6 +125,12 @@ type TransactionResponse struct {
 	CreatedAt     time.Time `json:"created_at"`
 }
 
+// TransactionStatusUpdateRequest DTO for moving a transaction to a new status in transaction-service
+type TransactionStatusUpdateRequest struct {
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
 // CreateWalletRequest DTO for creating a new wallet in wallet-ledger-service
 type CreateWalletRequest struct {
 	UserID   int    `json:"user_id"`
@@ -7,7 +7,8 @@ type FraudCheckRequest struct {
 	Currency             string                 `json:"currency"`
 	CustomerID           string                 `json:"customer_id,omitempty"`
 	MerchantID           string                 `json:"merchant_id,omitempty"`
-	Origin               string                 `json:"origin,omitempty"` // e.g., IP address
+	Origin               string                 `json:"origin,omitempty"`  // e.g., IP address
+	Country              string                 `json:"country,omitempty"` // ISO country resolved from Origin
 	PaymentMethod        string                 `json:"payment_method,omitempty"`
 	CustomData           map[string]interface{} `json:"custom_data,omitempty"`
 }
@@ -17,4 +18,18 @@ type FraudDecision struct {
 	OverallScore float64  `json:"overall_score"`
 	Decision     string   `json:"decision"` // "approve", "flag", "deny"
 	Reasons      []string `json:"reasons"`
-}
\ No newline at end of file
+}
+
+// FraudPolicyRequest sets how checkout behaves for a merchant when fraud-service is unavailable
+type FraudPolicyRequest struct {
+	Mode      string  `json:"mode"`       // closed, open, local
+	MaxAmount float64 `json:"max_amount"` // payments above this fail closed; 0 means no ceiling
+}
+
+type FraudPolicyResponse struct {
+	MerchantID int     `json:"merchant_id"`
+	Mode       string  `json:"mode"`
+	MaxAmount  float64 `json:"max_amount"`
+	IsDefault  bool    `json:"is_default"`
+	UpdatedAt  string  `json:"updated_at,omitempty"`
+}
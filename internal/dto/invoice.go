@@ -0,0 +1,60 @@
+package dto
+
+// InvoiceCreateRequest creates a draft invoice; amounts are whole currency units
+type InvoiceCreateRequest struct {
+	MerchantID    int                  `json:"merchant_id"` // set from the API key; a value in the body is ignored
+	CustomerName  string               `json:"customer_name"`
+	CustomerEmail string               `json:"customer_email"`
+	Currency      string               `json:"currency"`
+	LineItems     []InvoiceLineItem    `json:"line_items"`
+	TaxLines      []InvoiceTaxLineSpec `json:"tax_lines,omitempty"`
+	Memo          string               `json:"memo,omitempty"`
+	DueDate       string               `json:"due_date"` // YYYY-MM-DD
+}
+
+type InvoiceLineItem struct {
+	Description string `json:"description"`
+	Quantity    int64  `json:"quantity"`
+	UnitAmount  int64  `json:"unit_amount"`
+	Amount      int64  `json:"amount,omitempty"` // computed; ignored on create
+}
+
+// InvoiceTaxLineSpec is a percentage tax applied to the invoice subtotal
+type InvoiceTaxLineSpec struct {
+	Name string  `json:"name"`
+	Rate float64 `json:"rate"` // percent, e.g. 7.5
+}
+
+type InvoiceTaxLine struct {
+	Name   string  `json:"name"`
+	Rate   float64 `json:"rate"`
+	Amount int64   `json:"amount"`
+}
+
+type InvoiceResponse struct {
+	ID                   int               `json:"id"`
+	MerchantID           int               `json:"merchant_id"`
+	Number               string            `json:"number,omitempty"` // e.g. INV-000042; empty for drafts
+	CustomerName         string            `json:"customer_name"`
+	CustomerEmail        string            `json:"customer_email,omitempty"`
+	Currency             string            `json:"currency"`
+	LineItems            []InvoiceLineItem `json:"line_items"`
+	TaxLines             []InvoiceTaxLine  `json:"tax_lines"`
+	Subtotal             int64             `json:"subtotal"`
+	TaxTotal             int64             `json:"tax_total"`
+	Total                int64             `json:"total"`
+	Memo                 string            `json:"memo,omitempty"`
+	DueDate              string            `json:"due_date"`
+	Status               string            `json:"status"`
+	PaymentLinkID        *int              `json:"payment_link_id,omitempty"`
+	PaymentURL           string            `json:"payment_url,omitempty"`
+	TransactionReference string            `json:"transaction_reference,omitempty"`
+	FinalizedAt          string            `json:"finalized_at,omitempty"`
+	PaidAt               string            `json:"paid_at,omitempty"`
+	VoidedAt             string            `json:"voided_at,omitempty"`
+	CreatedAt            string            `json:"created_at"`
+}
+
+type InvoiceListResponse struct {
+	Invoices []InvoiceResponse `json:"invoices"`
+}
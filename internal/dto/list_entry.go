@@ -0,0 +1,36 @@
+package dto
+
+import "time"
+
+type ListEntryCreateRequest struct {
+	MerchantID *int       `json:"merchant_id,omitempty"` // omit for a global entry
+	ListType   string     `json:"list_type"`             // block, allow
+	Kind       string     `json:"kind"`                  // email, ip, customer, card_bin
+	Value      string     `json:"value"`
+	Reason     string     `json:"reason"`
+	CreatedBy  string     `json:"created_by"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+}
+
+type ListEntryUpdateRequest struct {
+	Reason    *string    `json:"reason,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	NoExpiry  bool       `json:"no_expiry,omitempty"` // clears expires_at
+}
+
+type ListEntryResponse struct {
+	ID         int    `json:"id"`
+	MerchantID *int   `json:"merchant_id,omitempty"`
+	ListType   string `json:"list_type"`
+	Kind       string `json:"kind"`
+	Value      string `json:"value"`
+	Reason     string `json:"reason"`
+	CreatedBy  string `json:"created_by,omitempty"`
+	ExpiresAt  string `json:"expires_at,omitempty"`
+	CreatedAt  string `json:"created_at"`
+	UpdatedAt  string `json:"updated_at"`
+}
+
+type ListEntryListResponse struct {
+	Entries []ListEntryResponse `json:"entries"`
+}
@@ -0,0 +1,29 @@
+package dto
+
+type CurrencyLimit struct {
+	Currency      string  `json:"currency"`
+	MinAmount     float64 `json:"min_amount"`
+	MaxAmount     float64 `json:"max_amount"`
+	DailyVolume   float64 `json:"daily_volume"`
+	MonthlyVolume float64 `json:"monthly_volume"`
+}
+
+// MerchantLimitsRequest replaces a merchant's limits; amounts are currency units and 0 means no limit
+type MerchantLimitsRequest struct {
+	MinAmount     float64         `json:"min_amount"`
+	MaxAmount     float64         `json:"max_amount"`
+	DailyVolume   float64         `json:"daily_volume"`
+	MonthlyVolume float64         `json:"monthly_volume"`
+	Currencies    []CurrencyLimit `json:"currencies"`
+}
+
+type MerchantLimitsResponse struct {
+	MerchantID    int             `json:"merchant_id"`
+	MinAmount     float64         `json:"min_amount"`
+	MaxAmount     float64         `json:"max_amount"`
+	DailyVolume   float64         `json:"daily_volume"`
+	MonthlyVolume float64         `json:"monthly_volume"`
+	Currencies    []CurrencyLimit `json:"currencies"`
+	IsDefault     bool            `json:"is_default"`
+	UpdatedAt     string          `json:"updated_at,omitempty"`
+}
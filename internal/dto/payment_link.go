@@ -1,26 +1,139 @@
 package dto
 
+import "time"
+
 type PaymentLinkCreateRequest struct {
-	MerchantID  int     `json:"merchant_id"`
-	Mode        string  `json:"mode"` // fixed or open
-	Amount      *int64  `json:"amount,omitempty"`
-	Currency    string  `json:"currency"`
-	Description string  `json:"description"`
-	Reference   string  `json:"reference"`
+	MerchantID  int    `json:"merchant_id"` // set from the API key; bulk rows naming another merchant are rejected
+	Mode        string `json:"mode"`        // fixed or open
+	Amount      *int64 `json:"amount,omitempty"`
+	Currency    string `json:"currency"`
+	Description string `json:"description"`
+	Reference   string `json:"reference"` // unique per merchant
+	Slug        string `json:"slug"`      // optional vanity slug for /pay/{slug}: 3-64 of a-z, 0-9 and inner hyphens
+	// Optional expiry: an absolute time or a time-to-live from creation, not both
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	TTLSeconds int64      `json:"ttl_seconds,omitempty"`
+	MaxUses    *int       `json:"max_uses,omitempty"` // 1 for a single-use link; omit for unlimited
+	// Open links only: bounds on the payer's amount and presets for the hosted page
+	MinAmount        *int64        `json:"min_amount,omitempty"`
+	MaxAmount        *int64        `json:"max_amount,omitempty"`
+	SuggestedAmounts []int64       `json:"suggested_amounts,omitempty"`
+	CustomFields     []CustomField `json:"custom_fields,omitempty"`
+}
+
+// CustomField defines a question the payer answers before paying: type is text, number,
+// dropdown or checkbox; pattern is a regular expression for text and number values
+type CustomField struct {
+	Key      string   `json:"key"`
+	Label    string   `json:"label"`
+	Type     string   `json:"type"`
+	Required bool     `json:"required"`
+	Pattern  string   `json:"pattern,omitempty"`
+	Options  []string `json:"options,omitempty"` // dropdown choices
+}
+
+// PaymentLinkUpdateRequest changes a link; omitted fields are left as they are
+type PaymentLinkUpdateRequest struct {
+	Description *string    `json:"description,omitempty"`
+	Amount      *int64     `json:"amount,omitempty"` // open links only
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	ClearExpiry bool       `json:"clear_expiry,omitempty"` // removes expires_at
+	Slug        *string    `json:"slug,omitempty"`         // empty string removes the slug
+	MaxUses     *int       `json:"max_uses,omitempty"`     // cannot drop below uses already taken
+	// Open links only; send 0 to remove a bound and an empty list to remove presets
+	MinAmount        *int64         `json:"min_amount,omitempty"`
+	MaxAmount        *int64         `json:"max_amount,omitempty"`
+	SuggestedAmounts *[]int64       `json:"suggested_amounts,omitempty"`
+	CustomFields     *[]CustomField `json:"custom_fields,omitempty"` // replaces all fields; an empty list removes them
 }
 
 type PaymentLinkResponse struct {
-	ID          int     `json:"id"`
-	MerchantID  int     `json:"merchant_id"`
-	Mode        string  `json:"mode"`
-	Amount      *int64  `json:"amount,omitempty"`
-	Currency    string  `json:"currency"`
-	Description string  `json:"description"`
-	Reference   string  `json:"reference"`
-	Status      string  `json:"status"`
-	CreatedAt   string  `json:"created_at"`
+	ID               int                 `json:"id"`
+	MerchantID       int                 `json:"merchant_id"`
+	Mode             string              `json:"mode"`
+	Amount           *int64              `json:"amount,omitempty"`
+	Currency         string              `json:"currency"`
+	Description      string              `json:"description"`
+	Reference        string              `json:"reference"`
+	Slug             string              `json:"slug,omitempty"`
+	URL              string              `json:"url,omitempty"` // hosted payment page; omitted when no base URL is configured
+	Status           string              `json:"status"`
+	ExpiresAt        string              `json:"expires_at,omitempty"`
+	MaxUses          *int                `json:"max_uses,omitempty"`
+	UseCount         int                 `json:"use_count"`
+	RemainingUses    *int                `json:"remaining_uses,omitempty"` // omitted for unlimited links
+	MinAmount        *int64              `json:"min_amount,omitempty"`
+	MaxAmount        *int64              `json:"max_amount,omitempty"`
+	SuggestedAmounts []int64             `json:"suggested_amounts,omitempty"`
+	CustomFields     []CustomField       `json:"custom_fields"`
+	Stats            PaymentLinkCounters `json:"stats"`
+	CreatedAt        string              `json:"created_at"`
+}
+
+// PublicPaymentLinkResponse is what the hosted page and other anonymous callers see of a link:
+// enough to take a payment, without the merchant's reference or sales figures
+type PublicPaymentLinkResponse struct {
+	ID               int           `json:"id"`
+	MerchantID       int           `json:"merchant_id"`
+	Mode             string        `json:"mode"`
+	Amount           *int64        `json:"amount,omitempty"`
+	Currency         string        `json:"currency"`
+	Description      string        `json:"description"`
+	Slug             string        `json:"slug,omitempty"`
+	URL              string        `json:"url,omitempty"`
+	Status           string        `json:"status"`
+	ExpiresAt        string        `json:"expires_at,omitempty"`
+	MinAmount        *int64        `json:"min_amount,omitempty"`
+	MaxAmount        *int64        `json:"max_amount,omitempty"`
+	SuggestedAmounts []int64       `json:"suggested_amounts,omitempty"`
+	CustomFields     []CustomField `json:"custom_fields"`
 }
 
 type PaymentLinkListResponse struct {
-	Links []PaymentLinkResponse `json:"links"`
+	Links      []PaymentLinkResponse `json:"links"`
+	NextCursor string                `json:"next_cursor,omitempty"` // pass as cursor for the next page; omitted on the last page
+	TotalCount int                   `json:"total_count"`           // links matching the filters across all pages
+}
+
+// PaymentLinkCounters summarises a link's usage; conversion rates are 0 until there is a view or attempt
+type PaymentLinkCounters struct {
+	Views           int     `json:"views"`
+	Attempts        int     `json:"attempts"`
+	Successes       int     `json:"successes"`
+	FraudDenials    int     `json:"fraud_denials"`
+	CollectedAmount float64 `json:"collected_amount"`
+	ConversionRate  float64 `json:"conversion_rate"` // successes per view
+	AttemptSuccess  float64 `json:"attempt_success"` // successes per attempt
+}
+
+type PaymentLinkDailyStats struct {
+	Date            string  `json:"date"` // YYYY-MM-DD, UTC
+	Views           int     `json:"views"`
+	Attempts        int     `json:"attempts"`
+	Successes       int     `json:"successes"`
+	FraudDenials    int     `json:"fraud_denials"`
+	CollectedAmount float64 `json:"collected_amount"`
+	ConversionRate  float64 `json:"conversion_rate"`
+}
+
+type PaymentLinkStatsResponse struct {
+	PaymentLinkID int                     `json:"payment_link_id"`
+	Totals        PaymentLinkCounters     `json:"totals"` // all time
+	Daily         []PaymentLinkDailyStats `json:"daily"`  // one bucket per day in the window, oldest first
+}
+
+// PaymentLinkBulkResponse reports a bulk upload. Links are only created when Errors is empty
+// and the upload was not a dry run.
+type PaymentLinkBulkResponse struct {
+	DryRun  bool                      `json:"dry_run"`
+	Valid   int                       `json:"valid"`   // rows that passed validation
+	Created int                       `json:"created"` // 0 on dry runs and failed uploads
+	Links   []PaymentLinkResponse     `json:"links"`
+	Errors  []PaymentLinkBulkRowError `json:"errors"`
+}
+
+type PaymentLinkBulkRowError struct {
+	Row       int    `json:"row"` // 1-based, not counting the CSV header
+	Reference string `json:"reference,omitempty"`
+	Error     string `json:"error"`
 }
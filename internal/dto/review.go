@@ -0,0 +1,40 @@
+package dto
+
+// ReviewDecisionRequest is the body for approving or rejecting a flagged payment
+type ReviewDecisionRequest struct {
+	Reviewer string `json:"reviewer"`
+	Notes    string `json:"notes"`
+}
+
+type PaymentReviewEventResponse struct {
+	Action    string `json:"action"`
+	Actor     string `json:"actor"`
+	Notes     string `json:"notes,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+type PaymentReviewResponse struct {
+	ID                   int                          `json:"id"`
+	TransactionReference string                       `json:"transaction_reference"`
+	MerchantID           int                          `json:"merchant_id"`
+	CustomerID           int                          `json:"customer_id,omitempty"`
+	CustomerEmail        string                       `json:"customer_email,omitempty"`
+	Amount               float64                      `json:"amount"`     // currency units (e.g., NGN)
+	FeeAmount            float64                      `json:"fee_amount"` // currency units (e.g., NGN)
+	NetAmount            float64                      `json:"net_amount"` // currency units (e.g., NGN)
+	Currency             string                       `json:"currency"`
+	PaymentMethod        string                       `json:"payment_method,omitempty"`
+	FraudScore           float64                      `json:"fraud_score"`
+	FraudReasons         []string                     `json:"fraud_reasons"`
+	Status               string                       `json:"status"`
+	WalletCredit         string                       `json:"wallet_credit"`
+	ReviewedBy           string                       `json:"reviewed_by,omitempty"`
+	ReviewNotes          string                       `json:"review_notes,omitempty"`
+	ReviewedAt           string                       `json:"reviewed_at,omitempty"`
+	CreatedAt            string                       `json:"created_at"`
+	Events               []PaymentReviewEventResponse `json:"events,omitempty"`
+}
+
+type PaymentReviewListResponse struct {
+	Reviews []PaymentReviewResponse `json:"reviews"`
+}
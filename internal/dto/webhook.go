@@ -0,0 +1,80 @@
+package dto
+
+type WebhookEndpointCreateRequest struct {
+	URL         string `json:"url"` // https URL on a public host that receives POSTed events
+	Description string `json:"description,omitempty"`
+	// Event types to receive, e.g. payment.succeeded; omit to receive every event
+	Events []string `json:"events,omitempty"`
+}
+
+type WebhookEndpointResponse struct {
+	ID           int      `json:"id"`
+	MerchantID   int      `json:"merchant_id"`
+	URL          string   `json:"url"`
+	Description  string   `json:"description,omitempty"`
+	Events       []string `json:"events"` // every event type for endpoints subscribed to all
+	SecretPrefix string   `json:"secret_prefix"`
+	Secret       string   `json:"secret,omitempty"` // the signing secret; only returned when the endpoint is created
+	Status       string   `json:"status"`           // active or disabled
+	CreatedAt    string   `json:"created_at"`
+}
+
+type WebhookEndpointListResponse struct {
+	Endpoints []WebhookEndpointResponse `json:"endpoints"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID             int    `json:"id"`
+	EndpointID     int    `json:"endpoint_id"`
+	EventID        string `json:"event_id"`
+	EventType      string `json:"event_type"`
+	Status         string `json:"status"` // pending, delivered or dead
+	Attempts       int    `json:"attempts"`
+	NextAttemptAt  string `json:"next_attempt_at,omitempty"` // pending deliveries only
+	LastStatusCode *int   `json:"last_status_code,omitempty"`
+	LastError      string `json:"last_error,omitempty"`
+	DeliveredAt    string `json:"delivered_at,omitempty"`
+	CreatedAt      string `json:"created_at"`
+}
+
+type WebhookDeliveryListResponse struct {
+	Deliveries []WebhookDeliveryResponse `json:"deliveries"`
+}
+
+// WebhookEvent is the body POSTed to endpoints. id is the same on every delivery attempt, so
+// receivers can use it to drop duplicates.
+type WebhookEvent struct {
+	ID         string      `json:"id"` // evt_...
+	Type       string      `json:"type"`
+	MerchantID int         `json:"merchant_id"`
+	CreatedAt  string      `json:"created_at"`
+	Data       interface{} `json:"data"`
+}
+
+// PaymentEvent is the data of payment.* events: the payment as GET /checkout/payments/:reference
+// returns it, plus why it failed
+type PaymentEvent struct {
+	CheckoutPaymentResponse
+	Fraud         *FraudOutcome `json:"fraud,omitempty"`
+	FailureReason string        `json:"failure_reason,omitempty"` // payment.failed only
+}
+
+// RefundEvent is the data of refund.created events
+type RefundEvent struct {
+	TransactionReference string                  `json:"transaction_reference"`
+	Amount               float64                 `json:"amount"` // currency units (e.g., NGN)
+	Currency             string                  `json:"currency"`
+	Reason               string                  `json:"reason,omitempty"`
+	Payment              CheckoutPaymentResponse `json:"payment"`
+}
+
+// PaymentLinkEvent is the data of payment_link.* events
+type PaymentLinkEvent struct {
+	ID          int    `json:"id"`
+	MerchantID  int    `json:"merchant_id"`
+	Reference   string `json:"reference,omitempty"`
+	Description string `json:"description,omitempty"`
+	Status      string `json:"status"`
+	MaxUses     *int   `json:"max_uses,omitempty"`
+	UseCount    int    `json:"use_count"`
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/kodra-pay/checkout-service/internal/dto"
+	"github.com/kodra-pay/checkout-service/internal/middleware"
+	"github.com/kodra-pay/checkout-service/internal/services"
+)
+
+type APIKeyHandler struct {
+	svc *services.APIKeyService
+}
+
+func NewAPIKeyHandler(svc *services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{svc: svc}
+}
+
+// List serves GET /api-keys, the authenticated merchant's keys
+func (h *APIKeyHandler) List(c *fiber.Ctx) error {
+	resp, err := h.svc.List(c.Context(), middleware.MerchantID(c))
+	if err != nil {
+		return apiKeyError(err)
+	}
+	return c.JSON(resp)
+}
+
+func (h *APIKeyHandler) Create(c *fiber.Ctx) error {
+	return h.create(c, middleware.MerchantID(c))
+}
+
+// Rotate serves POST /api-keys/:id/rotate
+func (h *APIKeyHandler) Rotate(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid API key id")
+	}
+	var req dto.APIKeyRotateRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+		}
+	}
+	resp, err := h.svc.Rotate(c.Context(), middleware.MerchantID(c), id, req, middleware.APIKey(c))
+	if err != nil {
+		return apiKeyError(err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(resp)
+}
+
+// Revoke serves DELETE /api-keys/:id
+func (h *APIKeyHandler) Revoke(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid API key id")
+	}
+	resp, err := h.svc.Revoke(c.Context(), middleware.MerchantID(c), id, middleware.APIKey(c))
+	if err != nil {
+		return apiKeyError(err)
+	}
+	return c.JSON(resp)
+}
+
+// AdminList serves GET /admin/merchants/:id/api-keys
+func (h *APIKeyHandler) AdminList(c *fiber.Ctx) error {
+	merchantID, err := c.ParamsInt("id")
+	if err != nil || merchantID <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid merchant id")
+	}
+	resp, err := h.svc.List(c.Context(), merchantID)
+	if err != nil {
+		return apiKeyError(err)
+	}
+	return c.JSON(resp)
+}
+
+// AdminCreate serves POST /admin/merchants/:id/api-keys, which issues a merchant's first key
+func (h *APIKeyHandler) AdminCreate(c *fiber.Ctx) error {
+	merchantID, err := c.ParamsInt("id")
+	if err != nil || merchantID <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid merchant id")
+	}
+	return h.create(c, merchantID)
+}
+
+func (h *APIKeyHandler) create(c *fiber.Ctx, merchantID int) error {
+	var req dto.APIKeyCreateRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+		}
+	}
+	resp, err := h.svc.Create(c.Context(), merchantID, req, middleware.APIKey(c))
+	if err != nil {
+		return apiKeyError(err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(resp)
+}
+
+func apiKeyError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrAPIKeyNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrInvalidGrace), errors.Is(err, services.ErrInvalidAPIKeyReq):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrScopeEscalation):
+		return fiber.NewError(fiber.StatusForbidden, err.Error())
+	case errors.Is(err, services.ErrAPIKeyNotRotated):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrNoEncryptionKey):
+		return fiber.NewError(fiber.StatusServiceUnavailable, err.Error())
+	default:
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/kodra-pay/checkout-service/internal/dto"
+	"github.com/kodra-pay/checkout-service/internal/services"
+)
+
+type BrandingHandler struct {
+	svc *services.BrandingService
+}
+
+func NewBrandingHandler(svc *services.BrandingService) *BrandingHandler {
+	return &BrandingHandler{svc: svc}
+}
+
+func (h *BrandingHandler) Get(c *fiber.Ctx) error {
+	merchantID, err := c.ParamsInt("id")
+	if err != nil || merchantID <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid merchant id")
+	}
+	resp, err := h.svc.Get(c.Context(), merchantID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(resp)
+}
+
+func (h *BrandingHandler) Put(c *fiber.Ctx) error {
+	merchantID, err := c.ParamsInt("id")
+	if err != nil || merchantID <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid merchant id")
+	}
+	var req dto.BrandingRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	resp, err := h.svc.Set(c.Context(), merchantID, req)
+	if errors.Is(err, services.ErrInvalidLogo) {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(resp)
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/kodra-pay/checkout-service/internal/middleware"
+	"github.com/kodra-pay/checkout-service/internal/services"
+)
+
+type DocumentHandler struct {
+	svc *services.DocumentService
+}
+
+func NewDocumentHandler(svc *services.DocumentService) *DocumentHandler {
+	return &DocumentHandler{svc: svc}
+}
+
+// Receipt serves GET /checkout/payments/:reference/receipt.pdf
+func (h *DocumentHandler) Receipt(c *fiber.Ctx) error {
+	reference := c.Params("reference")
+	pdf, err := h.svc.Receipt(c.Context(), reference, middleware.MerchantID(c))
+	switch {
+	case errors.Is(err, services.ErrPaymentNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrReceiptUnavailable):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	case err != nil:
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	return sendPDF(c, pdf, fmt.Sprintf("receipt-%s.pdf", reference))
+}
+
+// Invoice serves GET /invoices/:id/pdf
+func (h *DocumentHandler) Invoice(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid invoice id")
+	}
+	pdf, err := h.svc.Invoice(c.Context(), id, middleware.MerchantID(c))
+	if err != nil {
+		return invoiceError(err)
+	}
+	return sendPDF(c, pdf, fmt.Sprintf("invoice-%d.pdf", id))
+}
+
+func sendPDF(c *fiber.Ctx, pdf []byte, filename string) error {
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("inline; filename=%q", filename))
+	c.Set(fiber.HeaderCacheControl, "private, no-store")
+	return c.Send(pdf)
+}
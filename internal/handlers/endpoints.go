@@ -1,9 +1,18 @@
 package handlers
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
 
 	"github.com/kodra-pay/checkout-service/internal/dto"
+	"github.com/kodra-pay/checkout-service/internal/middleware"
+	"github.com/kodra-pay/checkout-service/internal/repositories"
 	"github.com/kodra-pay/checkout-service/internal/services"
 )
 
@@ -20,7 +29,15 @@ func (h *CheckoutHandler) CreateSession(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
 	}
-	return c.JSON(h.svc.CreateSession(c.Context(), req))
+	req.MerchantID = middleware.MerchantID(c)
+	resp, err := h.svc.CreateSession(c.Context(), req)
+	if errors.Is(err, services.ErrInvalidSession) {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(resp)
 }
 
 func (h *CheckoutHandler) GetSession(c *fiber.Ctx) error {
@@ -28,7 +45,11 @@ func (h *CheckoutHandler) GetSession(c *fiber.Ctx) error {
 	if err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, "Invalid session ID")
 	}
-	return c.JSON(h.svc.GetSession(c.Context(), id))
+	session, err := h.svc.GetSession(c.Context(), id)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "Session not found")
+	}
+	return c.JSON(session)
 }
 
 func (h *CheckoutHandler) Pay(c *fiber.Ctx) error {
@@ -37,10 +58,61 @@ func (h *CheckoutHandler) Pay(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
 	}
 	req.Origin = c.IP() // Set the client IP from Fiber context
+	req.UserAgent = c.Get(fiber.HeaderUserAgent)
+	req.AcceptLanguage = c.Get(fiber.HeaderAcceptLanguage)
 	resp, err := h.svc.Pay(c.Context(), req)
+	var limitErr *services.LimitError
+	if errors.As(err, &limitErr) {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error":   "merchant_limit_exceeded",
+			"limit":   limitErr.Limit,
+			"message": limitErr.Message,
+			"status":  resp.Status,
+		})
+	}
+	if errors.Is(err, services.ErrLimitsUnavailable) {
+		return fiber.NewError(fiber.StatusServiceUnavailable, err.Error())
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return c.JSON(resp)
+}
+
+// ResolveMerchant runs before the KYC gate on /checkout/pay and puts the merchant being paid
+// in the context. Anonymous callers (the hosted page) can only pay sessions and payment links;
+// a key, typically publishable, must belong to the merchant being paid.
+func (h *CheckoutHandler) ResolveMerchant(c *fiber.Ctx) error {
+	var req dto.CheckoutPayRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	merchantID, err := h.svc.PayMerchant(c.Context(), req)
 	if err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
+	if merchantID <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "merchant_id, amount, and currency are required")
+	}
+	key := middleware.APIKey(c)
+	if key == nil && req.SessionID == 0 && req.PaymentLinkID == 0 {
+		return fiber.NewError(fiber.StatusUnauthorized, "an API key is required to pay without a session or payment link")
+	}
+	if key != nil && key.MerchantID != merchantID {
+		return fiber.NewError(fiber.StatusForbidden, "API key does not belong to the merchant being paid")
+	}
+	c.Locals(middleware.LocalMerchantID, strconv.Itoa(merchantID))
+	return c.Next()
+}
+
+func (h *CheckoutHandler) GetPayment(c *fiber.Ctx) error {
+	resp, err := h.svc.GetPayment(c.Context(), c.Params("reference"), middleware.MerchantID(c))
+	if errors.Is(err, services.ErrPaymentNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
 	return c.JSON(resp)
 }
 
@@ -57,26 +129,65 @@ func (h *PaymentLinkHandler) Create(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
 	}
+	req.MerchantID = middleware.MerchantID(c)
 	resp, err := h.svc.Create(c.Context(), req)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		return paymentLinkError(err)
 	}
 	return c.JSON(resp)
 }
 
+// List serves GET /payment-links?status=&mode=&currency=&created_from=&created_to=
+// &q=&sort=created_at|updated_at|amount|collected_amount&order=asc|desc&limit=&cursor=
 func (h *PaymentLinkHandler) List(c *fiber.Ctx) error {
-	merchantID := c.QueryInt("merchant_id", 0) // Use c.QueryInt for query parameters
-	// If merchantID is mandatory, you might add an error check here:
-	// if merchantID == 0 {
-	//    return fiber.NewError(fiber.StatusBadRequest, "merchant_id query parameter is required")
-	// }
-	return c.JSON(h.svc.ListByMerchant(c.Context(), merchantID))
+	f := repositories.PaymentLinkFilter{
+		MerchantID: middleware.MerchantID(c),
+		Status:     c.Query("status"),
+		Mode:       c.Query("mode"),
+		Currency:   c.Query("currency"),
+		Search:     strings.TrimSpace(c.Query("q")),
+		Sort:       c.Query("sort"),
+		Desc:       c.Query("order", "desc") != "asc",
+		Limit:      c.QueryInt("limit", 50),
+	}
+	if f.Limit <= 0 || f.Limit > 200 {
+		f.Limit = 50
+	}
+	for param, dst := range map[string]**time.Time{"created_from": &f.CreatedFrom, "created_to": &f.CreatedTo} {
+		if raw := c.Query(param); raw != "" {
+			t, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return fiber.NewError(fiber.StatusBadRequest, "Invalid "+param+"; use RFC 3339")
+			}
+			*dst = &t
+		}
+	}
+	resp, err := h.svc.List(c.Context(), f, c.Query("cursor"))
+	if errors.Is(err, services.ErrInvalidListQuery) {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(resp)
 }
 
+// Get serves the owning merchant the full link, stats included, and anyone else its public view
 func (h *PaymentLinkHandler) Get(c *fiber.Ctx) error {
-	id, err := c.ParamsInt("id")
+	if middleware.MerchantID(c) == 0 {
+		id, err := c.ParamsInt("id")
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid payment link id")
+		}
+		pl, err := h.svc.GetPublic(c.Context(), id)
+		if err != nil {
+			return paymentLinkError(err)
+		}
+		return c.JSON(pl)
+	}
+	id, err := h.ownedLinkID(c)
 	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "Invalid payment link id")
+		return err
 	}
 	pl, err := h.svc.Get(c.Context(), id)
 	if err != nil {
@@ -84,3 +195,151 @@ func (h *PaymentLinkHandler) Get(c *fiber.Ctx) error {
 	}
 	return c.JSON(pl)
 }
+
+func (h *PaymentLinkHandler) GetBySlug(c *fiber.Ctx) error {
+	pl, err := h.svc.GetBySlug(c.Context(), c.Params("slug"))
+	if err != nil {
+		return paymentLinkError(err)
+	}
+	return c.JSON(pl)
+}
+
+func (h *PaymentLinkHandler) Update(c *fiber.Ctx) error {
+	id, err := h.ownedLinkID(c)
+	if err != nil {
+		return err
+	}
+	var req dto.PaymentLinkUpdateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	pl, err := h.svc.Update(c.Context(), id, req)
+	if err != nil {
+		return paymentLinkError(err)
+	}
+	return c.JSON(pl)
+}
+
+func (h *PaymentLinkHandler) Activate(c *fiber.Ctx) error {
+	id, err := h.ownedLinkID(c)
+	if err != nil {
+		return err
+	}
+	pl, err := h.svc.Activate(c.Context(), id)
+	if err != nil {
+		return paymentLinkError(err)
+	}
+	return c.JSON(pl)
+}
+
+func (h *PaymentLinkHandler) Deactivate(c *fiber.Ctx) error {
+	id, err := h.ownedLinkID(c)
+	if err != nil {
+		return err
+	}
+	pl, err := h.svc.Deactivate(c.Context(), id)
+	if err != nil {
+		return paymentLinkError(err)
+	}
+	return c.JSON(pl)
+}
+
+// BulkCreate serves POST /payment-links/bulk?dry_run=true. The body is a JSON array of create
+// requests, or CSV with a header row when sent as text/csv. Links are created for the
+// authenticated merchant.
+func (h *PaymentLinkHandler) BulkCreate(c *fiber.Ctx) error {
+	var rows []services.BulkRow
+	if strings.HasPrefix(c.Get(fiber.HeaderContentType), "text/csv") {
+		var err error
+		rows, err = services.ParseBulkCSV(bytes.NewReader(c.Body()))
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+	} else {
+		var reqs []dto.PaymentLinkCreateRequest
+		if err := json.Unmarshal(c.Body(), &reqs); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid request body; expected a JSON array or text/csv")
+		}
+		for _, req := range reqs {
+			rows = append(rows, services.BulkRow{Req: req})
+		}
+	}
+
+	resp, err := h.svc.BulkCreate(c.Context(), rows, middleware.MerchantID(c), c.QueryBool("dry_run"))
+	switch {
+	case errors.Is(err, services.ErrBulkEmpty):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrBulkTooLarge):
+		return fiber.NewError(fiber.StatusRequestEntityTooLarge, err.Error())
+	case err != nil:
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	case len(resp.Errors) > 0:
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(resp)
+	case resp.Created > 0:
+		return c.Status(fiber.StatusCreated).JSON(resp)
+	}
+	return c.JSON(resp)
+}
+
+// RecordView is called by the hosted page each time it renders the link
+func (h *PaymentLinkHandler) RecordView(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid payment link id")
+	}
+	if err := h.svc.RecordView(c.Context(), id); err != nil {
+		return paymentLinkError(err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *PaymentLinkHandler) Stats(c *fiber.Ctx) error {
+	id, err := h.ownedLinkID(c)
+	if err != nil {
+		return err
+	}
+	resp, err := h.svc.Stats(c.Context(), id, c.QueryInt("days", 30))
+	if err != nil {
+		return paymentLinkError(err)
+	}
+	return c.JSON(resp)
+}
+
+func (h *PaymentLinkHandler) Delete(c *fiber.Ctx) error {
+	id, err := h.ownedLinkID(c)
+	if err != nil {
+		return err
+	}
+	if err := h.svc.Delete(c.Context(), id); err != nil {
+		return paymentLinkError(err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ownedLinkID parses the :id param and checks the link belongs to the authenticated merchant
+func (h *PaymentLinkHandler) ownedLinkID(c *fiber.Ctx) (int, error) {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return 0, fiber.NewError(fiber.StatusBadRequest, "Invalid payment link id")
+	}
+	if err := h.svc.Authorize(c.Context(), id, middleware.MerchantID(c)); err != nil {
+		return 0, paymentLinkError(err)
+	}
+	return id, nil
+}
+
+func paymentLinkError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrPaymentLinkNotFound):
+		return fiber.NewError(fiber.StatusNotFound, "Payment link not found")
+	case errors.Is(err, services.ErrPaymentLinkAmountFixed), errors.Is(err, services.ErrInvalidPaymentLink),
+		errors.Is(err, services.ErrInvalidSlug):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrPaymentLinkExpired), errors.Is(err, services.ErrPaymentLinkExhausted),
+		errors.Is(err, services.ErrDuplicateReference), errors.Is(err, services.ErrSlugTaken),
+		errors.Is(err, services.ErrPaymentLinkInvoiced):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	default:
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/kodra-pay/checkout-service/internal/dto"
+	"github.com/kodra-pay/checkout-service/internal/services"
+)
+
+type FraudPolicyHandler struct {
+	screener *services.FraudScreener
+}
+
+func NewFraudPolicyHandler(screener *services.FraudScreener) *FraudPolicyHandler {
+	return &FraudPolicyHandler{screener: screener}
+}
+
+func (h *FraudPolicyHandler) Get(c *fiber.Ctx) error {
+	merchantID, err := c.ParamsInt("id")
+	if err != nil || merchantID <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid merchant id")
+	}
+	resp, err := h.screener.GetPolicy(c.Context(), merchantID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(resp)
+}
+
+func (h *FraudPolicyHandler) Put(c *fiber.Ctx) error {
+	merchantID, err := c.ParamsInt("id")
+	if err != nil || merchantID <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid merchant id")
+	}
+	var req dto.FraudPolicyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	resp, err := h.screener.SetPolicy(c.Context(), merchantID, req)
+	if errors.Is(err, services.ErrInvalidFraudPolicy) {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(resp)
+}
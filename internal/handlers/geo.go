@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/kodra-pay/checkout-service/internal/dto"
+	"github.com/kodra-pay/checkout-service/internal/services"
+)
+
+type GeoHandler struct {
+	svc *services.GeoService
+}
+
+func NewGeoHandler(svc *services.GeoService) *GeoHandler {
+	return &GeoHandler{svc: svc}
+}
+
+func (h *GeoHandler) GetRules(c *fiber.Ctx) error {
+	merchantID, err := c.ParamsInt("id")
+	if err != nil || merchantID <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid merchant id")
+	}
+	resp, err := h.svc.GetRules(c.Context(), merchantID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(resp)
+}
+
+func (h *GeoHandler) PutRules(c *fiber.Ctx) error {
+	merchantID, err := c.ParamsInt("id")
+	if err != nil || merchantID <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid merchant id")
+	}
+	var req dto.CountryRulesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	resp, err := h.svc.SetRules(c.Context(), merchantID, req)
+	if errors.Is(err, services.ErrInvalidCountryCode) {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(resp)
+}
+
+func (h *GeoHandler) Reload(c *fiber.Ctx) error {
+	if err := h.svc.Reload(); err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(fiber.Map{"status": "reloaded"})
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/kodra-pay/checkout-service/internal/dto"
+	"github.com/kodra-pay/checkout-service/internal/middleware"
+	"github.com/kodra-pay/checkout-service/internal/services"
+)
+
+type InvoiceHandler struct {
+	svc *services.InvoiceService
+}
+
+func NewInvoiceHandler(svc *services.InvoiceService) *InvoiceHandler {
+	return &InvoiceHandler{svc: svc}
+}
+
+func (h *InvoiceHandler) Create(c *fiber.Ctx) error {
+	var req dto.InvoiceCreateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	req.MerchantID = middleware.MerchantID(c)
+	resp, err := h.svc.Create(c.Context(), req)
+	if err != nil {
+		return invoiceError(err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(resp)
+}
+
+// List serves GET /invoices?status=&limit= for the authenticated merchant
+func (h *InvoiceHandler) List(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 50)
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	resp, err := h.svc.List(c.Context(), middleware.MerchantID(c), c.Query("status"), limit)
+	if err != nil {
+		return invoiceError(err)
+	}
+	return c.JSON(resp)
+}
+
+func (h *InvoiceHandler) Get(c *fiber.Ctx) error {
+	id, err := h.ownedInvoiceID(c)
+	if err != nil {
+		return err
+	}
+	resp, err := h.svc.Get(c.Context(), id)
+	if err != nil {
+		return invoiceError(err)
+	}
+	return c.JSON(resp)
+}
+
+func (h *InvoiceHandler) Finalize(c *fiber.Ctx) error {
+	id, err := h.ownedInvoiceID(c)
+	if err != nil {
+		return err
+	}
+	resp, err := h.svc.Finalize(c.Context(), id)
+	if err != nil {
+		return invoiceError(err)
+	}
+	return c.JSON(resp)
+}
+
+func (h *InvoiceHandler) Void(c *fiber.Ctx) error {
+	id, err := h.ownedInvoiceID(c)
+	if err != nil {
+		return err
+	}
+	resp, err := h.svc.Void(c.Context(), id)
+	if err != nil {
+		return invoiceError(err)
+	}
+	return c.JSON(resp)
+}
+
+// ownedInvoiceID parses the :id param and checks the invoice belongs to the authenticated merchant
+func (h *InvoiceHandler) ownedInvoiceID(c *fiber.Ctx) (int, error) {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return 0, fiber.NewError(fiber.StatusBadRequest, "Invalid invoice id")
+	}
+	if err := h.svc.Authorize(c.Context(), id, middleware.MerchantID(c)); err != nil {
+		return 0, invoiceError(err)
+	}
+	return id, nil
+}
+
+func invoiceError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvoiceNotFound):
+		return fiber.NewError(fiber.StatusNotFound, "Invoice not found")
+	case errors.Is(err, services.ErrInvalidInvoice):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrInvoiceNotDraft), errors.Is(err, services.ErrInvoiceNotVoidable),
+		errors.Is(err, services.ErrDuplicateReference):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	default:
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/kodra-pay/checkout-service/internal/dto"
+	"github.com/kodra-pay/checkout-service/internal/repositories"
+	"github.com/kodra-pay/checkout-service/internal/services"
+)
+
+type ListEntryHandler struct {
+	svc *services.ListService
+}
+
+func NewListEntryHandler(svc *services.ListService) *ListEntryHandler {
+	return &ListEntryHandler{svc: svc}
+}
+
+func (h *ListEntryHandler) Create(c *fiber.Ctx) error {
+	var req dto.ListEntryCreateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	resp, err := h.svc.Create(c.Context(), req)
+	if err != nil {
+		return listEntryError(err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(resp)
+}
+
+// List filters by ?merchant_id= (0 for global entries), ?list_type=, ?kind= and ?include_expired=true
+func (h *ListEntryHandler) List(c *fiber.Ctx) error {
+	f := repositories.ListEntryFilter{
+		ListType:       c.Query("list_type"),
+		Kind:           c.Query("kind"),
+		IncludeExpired: c.QueryBool("include_expired", false),
+		Limit:          c.QueryInt("limit", 100),
+	}
+	if f.Limit <= 0 || f.Limit > 500 {
+		f.Limit = 100
+	}
+	if raw := c.Query("merchant_id"); raw != "" {
+		merchantID, err := strconv.Atoi(raw)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid merchant_id")
+		}
+		f.MerchantID = &merchantID
+	}
+	resp, err := h.svc.List(c.Context(), f)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(resp)
+}
+
+func (h *ListEntryHandler) Get(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid list entry id")
+	}
+	resp, err := h.svc.Get(c.Context(), id)
+	if err != nil {
+		return listEntryError(err)
+	}
+	return c.JSON(resp)
+}
+
+func (h *ListEntryHandler) Update(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid list entry id")
+	}
+	var req dto.ListEntryUpdateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	resp, err := h.svc.Update(c.Context(), id, req)
+	if err != nil {
+		return listEntryError(err)
+	}
+	return c.JSON(resp)
+}
+
+func (h *ListEntryHandler) Delete(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid list entry id")
+	}
+	if err := h.svc.Delete(c.Context(), id); err != nil {
+		return listEntryError(err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func listEntryError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrListEntryNotFound):
+		return fiber.NewError(fiber.StatusNotFound, "List entry not found")
+	case errors.Is(err, services.ErrInvalidListEntry):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrDuplicateListEntry):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	default:
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+}
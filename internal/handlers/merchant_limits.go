@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/kodra-pay/checkout-service/internal/dto"
+	"github.com/kodra-pay/checkout-service/internal/services"
+)
+
+type MerchantLimitHandler struct {
+	svc *services.MerchantLimitService
+}
+
+func NewMerchantLimitHandler(svc *services.MerchantLimitService) *MerchantLimitHandler {
+	return &MerchantLimitHandler{svc: svc}
+}
+
+func (h *MerchantLimitHandler) Get(c *fiber.Ctx) error {
+	merchantID, err := c.ParamsInt("id")
+	if err != nil || merchantID <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid merchant id")
+	}
+	resp, err := h.svc.Get(c.Context(), merchantID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(resp)
+}
+
+func (h *MerchantLimitHandler) Put(c *fiber.Ctx) error {
+	merchantID, err := c.ParamsInt("id")
+	if err != nil || merchantID <= 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid merchant id")
+	}
+	var req dto.MerchantLimitsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	resp, err := h.svc.Set(c.Context(), merchantID, req)
+	if errors.Is(err, services.ErrInvalidMerchantLimits) {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(resp)
+}
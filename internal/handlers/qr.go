@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/kodra-pay/checkout-service/internal/services"
+)
+
+type QRHandler struct {
+	svc *services.QRService
+}
+
+func NewQRHandler(svc *services.QRService) *QRHandler {
+	return &QRHandler{svc: svc}
+}
+
+// PaymentLink renders GET /payment-links/:id/qr?format=png|svg&size=&margin=&ecc=L|M|Q|H&logo=true
+func (h *QRHandler) PaymentLink(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid payment link id")
+	}
+	img, contentType, err := h.svc.PaymentLinkQR(c.Context(), id, qrOptions(c))
+	if err != nil {
+		return qrError(err)
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	return c.Send(img)
+}
+
+// Session renders GET /checkout/session/:id/qr with the same options as PaymentLink
+func (h *QRHandler) Session(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid session ID")
+	}
+	img, contentType, err := h.svc.SessionQR(c.Context(), id, qrOptions(c))
+	if err != nil {
+		return qrError(err)
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	return c.Send(img)
+}
+
+func qrOptions(c *fiber.Ctx) services.QROptions {
+	return services.QROptions{
+		Format: c.Query("format", "png"),
+		Size:   c.QueryInt("size", 256),
+		Margin: c.QueryInt("margin", 4),
+		ECC:    c.Query("ecc"),
+		Logo:   c.QueryBool("logo"),
+	}
+}
+
+func qrError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrPaymentLinkNotFound), errors.Is(err, services.ErrSessionNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrInvalidQROptions), errors.Is(err, services.ErrQRLogoNeedsECC):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrNoCheckoutBaseURL):
+		return fiber.NewError(fiber.StatusServiceUnavailable, err.Error())
+	default:
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/kodra-pay/checkout-service/internal/dto"
+	"github.com/kodra-pay/checkout-service/internal/services"
+)
+
+type ReviewHandler struct {
+	svc *services.ReviewService
+}
+
+func NewReviewHandler(svc *services.ReviewService) *ReviewHandler {
+	return &ReviewHandler{svc: svc}
+}
+
+func (h *ReviewHandler) List(c *fiber.Ctx) error {
+	status := c.Query("status", "pending")
+	if status == "all" {
+		status = ""
+	}
+	limit := c.QueryInt("limit", 50)
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	resp, err := h.svc.List(c.Context(), status, limit)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(resp)
+}
+
+func (h *ReviewHandler) Get(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid review id")
+	}
+	resp, err := h.svc.Get(c.Context(), id)
+	if err != nil {
+		return reviewError(err)
+	}
+	return c.JSON(resp)
+}
+
+func (h *ReviewHandler) Approve(c *fiber.Ctx) error {
+	id, req, err := parseReviewDecision(c)
+	if err != nil {
+		return err
+	}
+	resp, err := h.svc.Approve(c.Context(), id, req)
+	if err != nil {
+		return reviewError(err)
+	}
+	return c.JSON(resp)
+}
+
+func (h *ReviewHandler) Reject(c *fiber.Ctx) error {
+	id, req, err := parseReviewDecision(c)
+	if err != nil {
+		return err
+	}
+	resp, err := h.svc.Reject(c.Context(), id, req)
+	if err != nil {
+		return reviewError(err)
+	}
+	return c.JSON(resp)
+}
+
+func parseReviewDecision(c *fiber.Ctx) (int, dto.ReviewDecisionRequest, error) {
+	var req dto.ReviewDecisionRequest
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return 0, req, fiber.NewError(fiber.StatusBadRequest, "Invalid review id")
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return 0, req, fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	return id, req, nil
+}
+
+func reviewError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrReviewNotFound):
+		return fiber.NewError(fiber.StatusNotFound, "Review not found")
+	case errors.Is(err, services.ErrReviewNotPending):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrReviewerRequired):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	default:
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/kodra-pay/checkout-service/internal/dto"
+	"github.com/kodra-pay/checkout-service/internal/middleware"
+	"github.com/kodra-pay/checkout-service/internal/services"
+)
+
+type WebhookHandler struct {
+	svc *services.WebhookService
+}
+
+func NewWebhookHandler(svc *services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{svc: svc}
+}
+
+// ListEndpoints serves GET /webhooks/endpoints
+func (h *WebhookHandler) ListEndpoints(c *fiber.Ctx) error {
+	resp, err := h.svc.ListEndpoints(c.Context(), middleware.MerchantID(c))
+	if err != nil {
+		return webhookError(err)
+	}
+	return c.JSON(resp)
+}
+
+// CreateEndpoint serves POST /webhooks/endpoints; the response carries the signing secret
+func (h *WebhookHandler) CreateEndpoint(c *fiber.Ctx) error {
+	var req dto.WebhookEndpointCreateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	resp, err := h.svc.CreateEndpoint(c.Context(), middleware.MerchantID(c), req)
+	if err != nil {
+		return webhookError(err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(resp)
+}
+
+// DisableEndpoint serves DELETE /webhooks/endpoints/:id
+func (h *WebhookHandler) DisableEndpoint(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid webhook endpoint id")
+	}
+	resp, err := h.svc.DisableEndpoint(c.Context(), middleware.MerchantID(c), id)
+	if err != nil {
+		return webhookError(err)
+	}
+	return c.JSON(resp)
+}
+
+// ListDeliveries serves GET /webhooks/deliveries, filtered by ?endpoint_id= and ?status=
+func (h *WebhookHandler) ListDeliveries(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 50)
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	resp, err := h.svc.ListDeliveries(c.Context(), middleware.MerchantID(c), c.QueryInt("endpoint_id", 0), c.Query("status"), limit)
+	if err != nil {
+		return webhookError(err)
+	}
+	return c.JSON(resp)
+}
+
+// RetryDelivery serves POST /webhooks/deliveries/:id/retry, which requeues a dead delivery
+func (h *WebhookHandler) RetryDelivery(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid webhook delivery id")
+	}
+	resp, err := h.svc.RetryDelivery(c.Context(), middleware.MerchantID(c), id)
+	if err != nil {
+		return webhookError(err)
+	}
+	return c.JSON(resp)
+}
+
+func webhookError(err error) error {
+	switch {
+	case errors.Is(err, services.ErrWebhookEndpointNotFound), errors.Is(err, services.ErrWebhookDeliveryNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrInvalidWebhookEndpoint):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrNoEncryptionKey):
+		return fiber.NewError(fiber.StatusServiceUnavailable, err.Error())
+	default:
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+}
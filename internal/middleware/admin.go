@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequireAdminToken guards operator endpoints with a shared bearer token. With no token
+// configured every request is refused, so admin routes are never left open by accident.
+func RequireAdminToken(token string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if token == "" {
+			return fiber.NewError(fiber.StatusForbidden, "admin API is disabled; set ADMIN_API_TOKEN to enable it")
+		}
+		presented, ok := bearerToken(c)
+		if !ok || subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+			c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="admin"`)
+			return fiber.NewError(fiber.StatusUnauthorized, "invalid admin token")
+		}
+		return c.Next()
+	}
+}
+
+func bearerToken(c *fiber.Ctx) (string, bool) {
+	auth := c.Get(fiber.HeaderAuthorization)
+	if len(auth) < 7 || !strings.EqualFold(auth[:7], "Bearer ") {
+		return "", false
+	}
+	token := strings.TrimSpace(auth[7:])
+	return token, token != ""
+}
@@ -1,9 +1,13 @@
 package middleware
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -16,21 +20,45 @@ type MerchantResponse struct {
 	CanTransact bool   `json:"can_transact"`
 }
 
-// KYCCheckMiddleware validates merchant KYC status before allowing checkout
+// errMerchantServiceUnavailable covers timeouts, network errors, 5xx and unreadable responses,
+// where the fail policy decides; other non-200 responses are definitive
+var errMerchantServiceUnavailable = errors.New("merchant service unavailable")
+
+// kycEntry is a cached merchant status and when it was fetched
+type kycEntry struct {
+	merchant   MerchantResponse
+	fetchedAt  time.Time
+	refreshing bool // a background revalidation is in flight
+}
+
+// KYCCheckMiddleware validates merchant KYC status before allowing checkout. Statuses are cached:
+// fresh for ttl, then served stale for up to staleTTL more while being revalidated in the background.
 type KYCCheckMiddleware struct {
 	merchantServiceURL string
+	client             *http.Client
+	ttl                time.Duration
+	staleTTL           time.Duration
+	failOpen           bool // let requests through when the status is unknown and merchant-service is down
+
+	mu    sync.Mutex
+	cache map[string]*kycEntry
 }
 
-func NewKYCCheckMiddleware(merchantServiceURL string) *KYCCheckMiddleware {
+func NewKYCCheckMiddleware(merchantServiceURL string, timeout, ttl, staleTTL time.Duration, failOpen bool) *KYCCheckMiddleware {
 	return &KYCCheckMiddleware{
 		merchantServiceURL: merchantServiceURL,
+		client:             &http.Client{Timeout: timeout},
+		ttl:                ttl,
+		staleTTL:           staleTTL,
+		failOpen:           failOpen,
+		cache:              map[string]*kycEntry{},
 	}
 }
 
 // RequireApprovedKYC checks if merchant has approved KYC via merchant service
 func (m *KYCCheckMiddleware) RequireApprovedKYC(c *fiber.Ctx) error {
-	// Extract merchant ID from request body or context
-	merchantID := c.Locals("merchant_id")
+	// The merchant is set by API key auth, or resolved from the payment for /checkout/pay
+	merchantID := c.Locals(LocalMerchantID)
 	if merchantID == nil {
 		return fiber.NewError(fiber.StatusUnauthorized, "merchant not authenticated")
 	}
@@ -40,34 +68,110 @@ func (m *KYCCheckMiddleware) RequireApprovedKYC(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusInternalServerError, "invalid merchant ID format")
 	}
 
-	// Call merchant service to check KYC status
-	url := fmt.Sprintf("%s/merchants/%s", m.merchantServiceURL, merchantIDStr)
-	resp, err := http.Get(url)
-	if err != nil {
-		// If merchant service is unavailable, log error but don't block
-		// In production, you might want to cache merchant KYC status
-		return c.Next()
+	merchant, err := m.status(c.Context(), merchantIDStr)
+	if errors.Is(err, errMerchantServiceUnavailable) {
+		if m.failOpen {
+			fmt.Printf("Warning: KYC status of merchant %s unknown, failing open: %v\n", merchantIDStr, err)
+			return c.Next()
+		}
+		return fiber.NewError(fiber.StatusServiceUnavailable, "unable to verify merchant status; try again shortly")
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
+	if err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, "unable to verify merchant status")
 	}
 
-	var merchant MerchantResponse
-	if err := json.NewDecoder(resp.Body).Decode(&merchant); err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, "failed to parse merchant data")
-	}
-
 	// Check if merchant can transact
 	if !merchant.CanTransact {
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"error": "kyc_not_approved",
-			"message": "Your KYC verification must be approved before you can process transactions. Please complete your business KYC verification at /merchant/kyc",
-			"kyc_status": merchant.KYCStatus,
+			"error":        "kyc_not_approved",
+			"message":      "Your KYC verification must be approved before you can process transactions. Please complete your business KYC verification at /merchant/kyc",
+			"kyc_status":   merchant.KYCStatus,
 			"can_transact": false,
 		})
 	}
 
 	return c.Next()
 }
+
+// status returns the merchant's status from cache when it is fresh enough, revalidating stale
+// entries in the background and fetching synchronously on a miss
+func (m *KYCCheckMiddleware) status(ctx context.Context, merchantID string) (MerchantResponse, error) {
+	now := time.Now()
+	m.mu.Lock()
+	entry, ok := m.cache[merchantID]
+	if ok {
+		age := now.Sub(entry.fetchedAt)
+		if age < m.ttl {
+			m.mu.Unlock()
+			return entry.merchant, nil
+		}
+		if age < m.ttl+m.staleTTL {
+			if !entry.refreshing {
+				entry.refreshing = true
+				go m.refresh(merchantID)
+			}
+			merchant := entry.merchant
+			m.mu.Unlock()
+			return merchant, nil
+		}
+	}
+	m.mu.Unlock()
+
+	merchant, err := m.fetch(ctx, merchantID)
+	if err != nil {
+		return MerchantResponse{}, err
+	}
+	m.store(merchantID, merchant)
+	return merchant, nil
+}
+
+// refresh revalidates a stale entry; on failure the stale status keeps being served until it
+// ages out of the stale window
+func (m *KYCCheckMiddleware) refresh(merchantID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.client.Timeout)
+	defer cancel()
+	merchant, err := m.fetch(ctx, merchantID)
+	if err != nil {
+		fmt.Printf("Warning: failed to revalidate KYC status of merchant %s: %v\n", merchantID, err)
+		m.mu.Lock()
+		if entry, ok := m.cache[merchantID]; ok {
+			entry.refreshing = false
+		}
+		m.mu.Unlock()
+		return
+	}
+	m.store(merchantID, merchant)
+}
+
+func (m *KYCCheckMiddleware) store(merchantID string, merchant MerchantResponse) {
+	m.mu.Lock()
+	m.cache[merchantID] = &kycEntry{merchant: merchant, fetchedAt: time.Now()}
+	m.mu.Unlock()
+}
+
+// fetch calls merchant service for the merchant's current status
+func (m *KYCCheckMiddleware) fetch(ctx context.Context, merchantID string) (MerchantResponse, error) {
+	url := fmt.Sprintf("%s/merchants/%s", m.merchantServiceURL, merchantID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return MerchantResponse{}, err
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return MerchantResponse{}, fmt.Errorf("%w: %v", errMerchantServiceUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return MerchantResponse{}, fmt.Errorf("%w: status %d", errMerchantServiceUnavailable, resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return MerchantResponse{}, fmt.Errorf("merchant service returned status %d", resp.StatusCode)
+	}
+
+	var merchant MerchantResponse
+	if err := json.NewDecoder(resp.Body).Decode(&merchant); err != nil {
+		return MerchantResponse{}, fmt.Errorf("%w: failed to parse merchant data: %v", errMerchantServiceUnavailable, err)
+	}
+	return merchant, nil
+}
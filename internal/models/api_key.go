@@ -0,0 +1,94 @@
+package models
+
+import "time"
+
+// API key kinds. Secret keys stay on merchant servers; publishable keys are embedded in
+// browser code and can only create and pay sessions from allowed origins.
+const (
+	APIKeySecret      = "secret"
+	APIKeyPublishable = "publishable"
+)
+
+// How a key authenticates: presented as a bearer token, or used to HMAC-sign each request
+// without ever being sent
+const (
+	APIKeyAuthBearer = "bearer"
+	APIKeyAuthHMAC   = "hmac"
+)
+
+// API key scopes
+const (
+	ScopeKeysRead      = "keys:read"
+	ScopeKeysWrite     = "keys:write"
+	ScopeLinksRead     = "links:read"
+	ScopeLinksWrite    = "links:write"
+	ScopeInvoicesRead  = "invoices:read"
+	ScopeInvoicesWrite = "invoices:write"
+	ScopeSessionsWrite = "sessions:write"
+	ScopePaymentsRead  = "payments:read"
+	ScopePaymentsWrite = "payments:write"
+	ScopeWebhooksRead  = "webhooks:read"
+	ScopeWebhooksWrite = "webhooks:write"
+)
+
+// AllScopes lists every scope a secret key can be restricted to
+var AllScopes = []string{
+	ScopeKeysRead, ScopeKeysWrite, ScopeLinksRead, ScopeLinksWrite, ScopeInvoicesRead, ScopeInvoicesWrite,
+	ScopeSessionsWrite, ScopePaymentsRead, ScopePaymentsWrite, ScopeWebhooksRead, ScopeWebhooksWrite,
+}
+
+// PublishableScopes are the fixed scopes of every publishable key
+var PublishableScopes = []string{ScopeSessionsWrite, ScopePaymentsWrite}
+
+// APIKey is a merchant's API key. The key itself is never stored, only its hash.
+type APIKey struct {
+	ID             int        `json:"id"`
+	MerchantID     int        `json:"merchant_id"`
+	Name           string     `json:"name"`
+	Kind           string     `json:"kind"`            // secret or publishable
+	Prefix         string     `json:"prefix"`          // e.g. kp_sk_3f9a1c, enough to tell keys apart
+	Scopes         []string   `json:"scopes"`          // nil on a secret key means unrestricted
+	AllowedOrigins []string   `json:"allowed_origins"` // publishable keys only, e.g. https://shop.example.com
+	AuthMethod     string     `json:"auth_method"`     // bearer or hmac
+	KeyHash        string     `json:"-"`
+	SecretCipher   []byte     `json:"-"`                    // encrypted secret of hmac keys, needed to verify signatures
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"` // set on keys rotated out with a grace period
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt     *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// Allows reports whether the key grants scope
+func (k *APIKey) Allows(scope string) bool {
+	scopes := k.Scopes
+	if k.Kind == APIKeyPublishable {
+		scopes = PublishableScopes
+	} else if scopes == nil {
+		return true
+	}
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsOrigin reports whether a browser request from origin may use the key. Secret keys are
+// not meant for browsers and are not origin-restricted.
+func (k *APIKey) AllowsOrigin(origin string) bool {
+	if k.Kind != APIKeyPublishable {
+		return true
+	}
+	for _, o := range k.AllowedOrigins {
+		if o == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// Active reports whether the key can still authenticate at now
+func (k *APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// MerchantBranding decorates a merchant's hosted pages, QR codes and documents
+type MerchantBranding struct {
+	MerchantID  int       `json:"merchant_id"`
+	DisplayName string    `json:"display_name"`
+	Logo        []byte    `json:"-"`         // PNG or JPEG; nil when no logo is set
+	LogoType    string    `json:"logo_type"` // image/png or image/jpeg
+	UpdatedAt   time.Time `json:"updated_at"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// Checkout session statuses
+const (
+	SessionStatusPending    = "pending"
+	SessionStatusProcessing = "processing"
+	SessionStatusCompleted  = "completed"
+	SessionStatusExpired    = "expired"
+)
+
+type CheckoutSession struct {
+	ID            int        `json:"id"`
+	MerchantID    int        `json:"merchant_id"`
+	Amount        float64    `json:"amount"` // currency units (e.g., NGN)
+	Currency      string     `json:"currency"`
+	Description   string     `json:"description"`
+	CustomerEmail string     `json:"customer_email"`
+	CustomerID    int        `json:"customer_id"`
+	Status        string     `json:"status"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"` // nil for sessions created before expiry existed
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// IsExpired reports whether the session's expiry has passed at now
+func (cs *CheckoutSession) IsExpired(now time.Time) bool {
+	return cs.ExpiresAt != nil && !now.Before(*cs.ExpiresAt)
+}
+
+// CheckoutPayment is the local record of a payment taken through checkout
+type CheckoutPayment struct {
+	ID                   int                    `json:"id"`
+	TransactionReference string                 `json:"transaction_reference"`
+	SessionID            *int                   `json:"session_id,omitempty"`
+	PaymentLinkID        *int                   `json:"payment_link_id,omitempty"`
+	MerchantID           int                    `json:"merchant_id"`
+	CustomerID           int                    `json:"customer_id"`
+	CustomerEmail        string                 `json:"customer_email"`
+	Amount               float64                `json:"amount"`     // currency units (e.g., NGN)
+	FeeAmount            float64                `json:"fee_amount"` // currency units (e.g., NGN)
+	NetAmount            float64                `json:"net_amount"` // currency units (e.g., NGN)
+	Currency             string                 `json:"currency"`
+	PaymentMethod        string                 `json:"payment_method"`
+	Status               string                 `json:"status"`
+	FraudDecision        string                 `json:"fraud_decision"`
+	FraudScore           float64                `json:"fraud_score"`
+	Origin               string                 `json:"origin"`
+	Country              string                 `json:"country"`
+	CustomFields         map[string]interface{} `json:"custom_fields,omitempty"` // payer answers to the link's custom fields
+	CreatedAt            time.Time              `json:"created_at"`
+}
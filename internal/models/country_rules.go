@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// MerchantCountryRules restricts which client countries may pay a merchant
+type MerchantCountryRules struct {
+	MerchantID       int       `json:"merchant_id"`
+	AllowedCountries []string  `json:"allowed_countries"` // ISO 3166-1 alpha-2; empty allows all
+	BlockedCountries []string  `json:"blocked_countries"`
+	BlockUnknown     bool      `json:"block_unknown"` // deny when the client IP cannot be resolved
+	UpdatedAt        time.Time `json:"updated_at"`
+}
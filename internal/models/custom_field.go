@@ -0,0 +1,19 @@
+package models
+
+// Custom payer field types
+const (
+	CustomFieldText     = "text"
+	CustomFieldNumber   = "number"
+	CustomFieldDropdown = "dropdown"
+	CustomFieldCheckbox = "checkbox"
+)
+
+// CustomField is a merchant-defined question the payer answers before paying a link
+type CustomField struct {
+	Key      string   `json:"key"` // name the value is stored under
+	Label    string   `json:"label"`
+	Type     string   `json:"type"`
+	Required bool     `json:"required"`          // a required checkbox must be ticked
+	Pattern  string   `json:"pattern,omitempty"` // regular expression text and number values must match
+	Options  []string `json:"options,omitempty"` // dropdown choices
+}
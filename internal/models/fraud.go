@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// Fraud fail policy modes applied when fraud-service cannot be reached
+const (
+	FraudFailClosed = "closed" // reject the payment
+	FraudFailOpen   = "open"   // accept the payment unscreened
+	FraudFailLocal  = "local"  // decide with the local rules evaluator
+)
+
+// Unscreened payment statuses
+const (
+	UnscreenedPending   = "pending"
+	UnscreenedScreened  = "screened"
+	UnscreenedAbandoned = "abandoned"
+)
+
+// MerchantFraudPolicy overrides the default fraud fail policy for one merchant
+type MerchantFraudPolicy struct {
+	MerchantID int       `json:"merchant_id"`
+	Mode       string    `json:"mode"`       // closed, open, local
+	MaxAmount  float64   `json:"max_amount"` // currency units; above this the payment fails closed, 0 means no ceiling
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// UnscreenedPayment is a payment accepted while fraud-service was down, kept for re-screening
+type UnscreenedPayment struct {
+	ID                   int                    `json:"id"`
+	TransactionReference string                 `json:"transaction_reference"`
+	MerchantID           int                    `json:"merchant_id"`
+	CustomerID           int                    `json:"customer_id"`
+	CustomerEmail        string                 `json:"customer_email"`
+	Amount               float64                `json:"amount"`     // currency units (e.g., NGN)
+	FeeAmount            float64                `json:"fee_amount"` // currency units (e.g., NGN)
+	NetAmount            float64                `json:"net_amount"` // currency units (e.g., NGN)
+	Currency             string                 `json:"currency"`
+	PaymentMethod        string                 `json:"payment_method"`
+	Origin               string                 `json:"origin"`
+	Country              string                 `json:"country"`
+	FraudSignals         map[string]interface{} `json:"fraud_signals"` // custom data sent with the fraud check at payment time
+	FailPolicy           string                 `json:"fail_policy"`
+	LocalDecision        string                 `json:"local_decision"`
+	FraudScore           float64                `json:"fraud_score"` // score and reasons of the local or remote decision at payment time
+	FraudReasons         []string               `json:"fraud_reasons"`
+	WalletCredit         string                 `json:"wallet_credit"`
+	Status               string                 `json:"status"` // pending, screened, abandoned
+	Attempts             int                    `json:"attempts"`
+	LastError            string                 `json:"last_error"`
+	ScreenedDecision     string                 `json:"screened_decision"`
+	ScreenedScore        float64                `json:"screened_score"`
+	ScreenedAt           *time.Time             `json:"screened_at,omitempty"`
+	CreatedAt            time.Time              `json:"created_at"`
+}
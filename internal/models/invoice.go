@@ -0,0 +1,62 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Invoice statuses
+const (
+	InvoiceDraft   = "draft"
+	InvoiceSent    = "sent" // finalized; payable through its payment link
+	InvoicePaid    = "paid"
+	InvoiceOverdue = "overdue"
+	InvoiceVoid    = "void"
+)
+
+// Invoice amounts are whole currency units, matching PaymentLink.Amount
+type Invoice struct {
+	ID                   int               `json:"id"`
+	MerchantID           int               `json:"merchant_id"`
+	Number               *int              `json:"number,omitempty"` // nil until finalized
+	CustomerName         string            `json:"customer_name"`
+	CustomerEmail        string            `json:"customer_email"`
+	Currency             string            `json:"currency"`
+	LineItems            []InvoiceLineItem `json:"line_items"`
+	TaxLines             []InvoiceTaxLine  `json:"tax_lines"`
+	Subtotal             int64             `json:"subtotal"`
+	TaxTotal             int64             `json:"tax_total"`
+	Total                int64             `json:"total"`
+	Memo                 string            `json:"memo"`
+	DueDate              time.Time         `json:"due_date"`
+	Status               string            `json:"status"`
+	PaymentLinkID        *int              `json:"payment_link_id,omitempty"`
+	TransactionReference string            `json:"transaction_reference,omitempty"`
+	FinalizedAt          *time.Time        `json:"finalized_at,omitempty"`
+	PaidAt               *time.Time        `json:"paid_at,omitempty"`
+	VoidedAt             *time.Time        `json:"voided_at,omitempty"`
+	CreatedAt            time.Time         `json:"created_at"`
+	UpdatedAt            time.Time         `json:"updated_at"`
+}
+
+// DisplayNumber is the printed invoice number, e.g. INV-000042, or empty for drafts
+func (inv *Invoice) DisplayNumber() string {
+	if inv.Number == nil {
+		return ""
+	}
+	return fmt.Sprintf("INV-%06d", *inv.Number)
+}
+
+type InvoiceLineItem struct {
+	Description string `json:"description"`
+	Quantity    int64  `json:"quantity"`
+	UnitAmount  int64  `json:"unit_amount"`
+	Amount      int64  `json:"amount"` // Quantity * UnitAmount
+}
+
+// InvoiceTaxLine is a percentage tax charged on the subtotal
+type InvoiceTaxLine struct {
+	Name   string  `json:"name"`
+	Rate   float64 `json:"rate"`   // percent, e.g. 7.5
+	Amount int64   `json:"amount"` // rounded half up to a whole unit
+}
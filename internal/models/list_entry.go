@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// List types
+const (
+	ListBlock = "block"
+	ListAllow = "allow"
+)
+
+// Values a list entry can match on
+const (
+	ListKindEmail    = "email"
+	ListKindIP       = "ip"
+	ListKindCustomer = "customer"
+	ListKindCardBIN  = "card_bin"
+)
+
+// ListEntry blocks or allows one email, IP, customer or card BIN, globally when MerchantID is nil
+type ListEntry struct {
+	ID         int        `json:"id"`
+	MerchantID *int       `json:"merchant_id,omitempty"`
+	ListType   string     `json:"list_type"` // block, allow
+	Kind       string     `json:"kind"`      // email, ip, customer, card_bin
+	Value      string     `json:"value"`
+	Reason     string     `json:"reason"`
+	CreatedBy  string     `json:"created_by"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// MerchantLimits caps what a merchant may process; zero values mean no limit
+type MerchantLimits struct {
+	MerchantID    int             `json:"merchant_id"`
+	MinAmount     float64         `json:"min_amount"`     // currency units, per payment
+	MaxAmount     float64         `json:"max_amount"`     // currency units, per payment
+	DailyVolume   float64         `json:"daily_volume"`   // currency units, per currency per UTC day
+	MonthlyVolume float64         `json:"monthly_volume"` // currency units, per currency per UTC month
+	Currencies    []CurrencyLimit `json:"currencies"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+// CurrencyLimit overrides the merchant-wide limits for one currency where its values are non-zero
+type CurrencyLimit struct {
+	Currency      string  `json:"currency"`
+	MinAmount     float64 `json:"min_amount"`
+	MaxAmount     float64 `json:"max_amount"`
+	DailyVolume   float64 `json:"daily_volume"`
+	MonthlyVolume float64 `json:"monthly_volume"`
+}
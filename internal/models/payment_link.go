@@ -2,6 +2,14 @@ package models
 
 import "time"
 
+// Payment link statuses
+const (
+	PaymentLinkActive    = "active"
+	PaymentLinkInactive  = "inactive"
+	PaymentLinkExpired   = "expired"
+	PaymentLinkCompleted = "completed" // every use taken
+)
+
 type PaymentLink struct {
 	ID          int        `json:"id"`
 	MerchantID  int        `json:"merchant_id"`
@@ -9,8 +17,52 @@ type PaymentLink struct {
 	Amount      *int64     `json:"amount,omitempty"`
 	Currency    string     `json:"currency"`
 	Description string     `json:"description"`
+	Reference   string     `json:"reference,omitempty"` // merchant's own reference, unique per merchant
+	Slug        *string    `json:"slug,omitempty"`      // vanity path segment, globally unique
 	Status      string     `json:"status"`
 	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
+	// Bounds and presets for open links, in the same units as Amount
+	MinAmount        *int64        `json:"min_amount,omitempty"`
+	MaxAmount        *int64        `json:"max_amount,omitempty"`
+	SuggestedAmounts []int64       `json:"suggested_amounts,omitempty"`
+	CustomFields     []CustomField `json:"custom_fields,omitempty"` // answered by the payer before paying
+	MaxUses          *int          `json:"max_uses,omitempty"`      // nil means unlimited; 1 for single-use links
+	UseCount         int           `json:"use_count"`               // uses reserved by in-flight or completed payments
+	// Running analytics counters, bumped alongside payment_link_events
+	ViewCount        int        `json:"view_count"`
+	AttemptCount     int        `json:"attempt_count"`
+	SuccessCount     int        `json:"success_count"`
+	FraudDenialCount int        `json:"fraud_denial_count"`
+	CollectedAmount  float64    `json:"collected_amount"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	DeletedAt        *time.Time `json:"deleted_at,omitempty"`
+}
+
+// IsExpired reports whether the link's expiry has passed at now
+func (pl *PaymentLink) IsExpired(now time.Time) bool {
+	return pl.ExpiresAt != nil && !now.Before(*pl.ExpiresAt)
+}
+
+// RemainingUses is the number of payments the link can still take, or nil when unlimited
+func (pl *PaymentLink) RemainingUses() *int {
+	if pl.MaxUses == nil {
+		return nil
+	}
+	remaining := *pl.MaxUses - pl.UseCount
+	if remaining < 0 {
+		remaining = 0
+	}
+	return &remaining
+}
+
+// AmountInBounds reports whether amount lies within the link's min and max amounts, if set
+func (pl *PaymentLink) AmountInBounds(amount float64) bool {
+	if pl.MinAmount != nil && amount < float64(*pl.MinAmount) {
+		return false
+	}
+	if pl.MaxAmount != nil && amount > float64(*pl.MaxAmount) {
+		return false
+	}
+	return true
 }
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Payment link analytics events
+const (
+	LinkEventView        = "view"         // hosted page opened
+	LinkEventAttempt     = "attempt"      // Pay called on an active link
+	LinkEventSuccess     = "success"      // transaction created; amount is collected
+	LinkEventFraudDenied = "fraud_denied" // denied by fraud rules, lists, velocity or country
+)
+
+// PaymentLinkDailyStats is one day's event counts for a link
+type PaymentLinkDailyStats struct {
+	Day             time.Time
+	Views           int
+	Attempts        int
+	Successes       int
+	FraudDenials    int
+	CollectedAmount float64
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Review queue statuses
+const (
+	ReviewStatusPending  = "pending"
+	ReviewStatusApproved = "approved"
+	ReviewStatusRejected = "rejected"
+)
+
+// PaymentReview is a fraud-flagged payment whose wallet credit is held until a reviewer acts on it
+type PaymentReview struct {
+	ID                   int        `json:"id"`
+	TransactionReference string     `json:"transaction_reference"`
+	MerchantID           int        `json:"merchant_id"`
+	CustomerID           int        `json:"customer_id"`
+	CustomerEmail        string     `json:"customer_email"`
+	Amount               float64    `json:"amount"`     // currency units (e.g., NGN)
+	FeeAmount            float64    `json:"fee_amount"` // currency units (e.g., NGN)
+	NetAmount            float64    `json:"net_amount"` // currency units (e.g., NGN)
+	Currency             string     `json:"currency"`
+	PaymentMethod        string     `json:"payment_method"`
+	FraudScore           float64    `json:"fraud_score"`
+	FraudReasons         []string   `json:"fraud_reasons"`
+	Status               string     `json:"status"`        // pending, approved, rejected
+	WalletCredit         string     `json:"wallet_credit"` // held, credited, failed, skipped, cancelled, reversed
+	ReviewedBy           *string    `json:"reviewed_by,omitempty"`
+	ReviewNotes          *string    `json:"review_notes,omitempty"`
+	ReviewedAt           *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt            time.Time  `json:"created_at"`
+	UpdatedAt            time.Time  `json:"updated_at"`
+}
+
+// PaymentReviewEvent is one entry in a review's audit trail
+type PaymentReviewEvent struct {
+	ID        int       `json:"id"`
+	ReviewID  int       `json:"review_id"`
+	Action    string    `json:"action"`
+	Actor     string    `json:"actor"`
+	Notes     string    `json:"notes"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,77 @@
+package models
+
+import "time"
+
+// Webhook event types
+const (
+	EventPaymentSucceeded     = "payment.succeeded"
+	EventPaymentFailed        = "payment.failed"
+	EventPaymentPendingReview = "payment.pending_review"
+	EventSessionExpired       = "session.expired"
+	EventRefundCreated        = "refund.created"
+	EventPaymentLinkExhausted = "payment_link.exhausted"
+)
+
+// WebhookEventTypes lists every event an endpoint can subscribe to
+var WebhookEventTypes = []string{
+	EventPaymentSucceeded, EventPaymentFailed, EventPaymentPendingReview,
+	EventSessionExpired, EventRefundCreated, EventPaymentLinkExhausted,
+}
+
+// Webhook endpoint statuses
+const (
+	WebhookEndpointActive   = "active"
+	WebhookEndpointDisabled = "disabled"
+)
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryDead      = "dead" // gave up after the maximum number of attempts
+)
+
+// WebhookEndpoint is a merchant URL that receives signed event notifications
+type WebhookEndpoint struct {
+	ID           int       `json:"id"`
+	MerchantID   int       `json:"merchant_id"`
+	URL          string    `json:"url"`
+	Description  string    `json:"description"`
+	Events       []string  `json:"events"`        // nil subscribes to every event
+	SecretPrefix string    `json:"secret_prefix"` // e.g. whsec_3f9a1c, enough to tell secrets apart
+	SecretCipher []byte    `json:"-"`             // encrypted signing secret
+	Status       string    `json:"status"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// Subscribes reports whether the endpoint wants events of eventType
+func (e *WebhookEndpoint) Subscribes(eventType string) bool {
+	if e.Events == nil {
+		return true
+	}
+	for _, ev := range e.Events {
+		if ev == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is one event queued for one endpoint, with its delivery state
+type WebhookDelivery struct {
+	ID             int        `json:"id"`
+	EndpointID     int        `json:"endpoint_id"`
+	MerchantID     int        `json:"merchant_id"`
+	EventID        string     `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Payload        []byte     `json:"-"` // the JSON body sent, identical on every attempt
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	LastStatusCode *int       `json:"last_status_code,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,133 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/kodra-pay/checkout-service/internal/models"
+)
+
+type APIKeyRepository struct {
+	db *sql.DB
+}
+
+func NewAPIKeyRepository(db *sql.DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+const apiKeyColumns = `id, merchant_id, name, kind, prefix, scopes, allowed_origins, auth_method, key_hash,
+	secret_ciphertext, expires_at, revoked_at, last_used_at, created_at`
+
+func scanAPIKey(row interface{ Scan(...any) error }) (*models.APIKey, error) {
+	var k models.APIKey
+	err := row.Scan(
+		&k.ID, &k.MerchantID, &k.Name, &k.Kind, &k.Prefix, pq.Array(&k.Scopes), pq.Array(&k.AllowedOrigins), &k.AuthMethod, &k.KeyHash,
+		&k.SecretCipher, &k.ExpiresAt, &k.RevokedAt, &k.LastUsedAt, &k.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+func (r *APIKeyRepository) Create(ctx context.Context, k *models.APIKey) error {
+	return insertAPIKey(ctx, r.db, k)
+}
+
+func insertAPIKey(ctx context.Context, q interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}, k *models.APIKey) error {
+	query := `
+		INSERT INTO merchant_api_keys (merchant_id, name, kind, prefix, scopes, allowed_origins, auth_method, key_hash, secret_ciphertext)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at
+	`
+	origins := k.AllowedOrigins
+	if origins == nil {
+		origins = []string{}
+	}
+	return q.QueryRowContext(ctx, query,
+		k.MerchantID, k.Name, k.Kind, k.Prefix, pq.Array(k.Scopes), pq.Array(origins), k.AuthMethod, k.KeyHash, k.SecretCipher,
+	).Scan(&k.ID, &k.CreatedAt)
+}
+
+// GetByHash looks a key up by the hash of the presented secret, including revoked and expired keys
+func (r *APIKeyRepository) GetByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	return scanAPIKey(r.db.QueryRowContext(ctx, `SELECT `+apiKeyColumns+` FROM merchant_api_keys WHERE key_hash = $1`, hash))
+}
+
+// Lookup returns any merchant's key by id, as named by a signed request
+func (r *APIKeyRepository) Lookup(ctx context.Context, id int) (*models.APIKey, error) {
+	return scanAPIKey(r.db.QueryRowContext(ctx, `SELECT `+apiKeyColumns+` FROM merchant_api_keys WHERE id = $1`, id))
+}
+
+// GetByID returns a merchant's key, or sql.ErrNoRows when the key belongs to another merchant
+func (r *APIKeyRepository) GetByID(ctx context.Context, merchantID, id int) (*models.APIKey, error) {
+	return scanAPIKey(r.db.QueryRowContext(ctx,
+		`SELECT `+apiKeyColumns+` FROM merchant_api_keys WHERE id = $1 AND merchant_id = $2`, id, merchantID))
+}
+
+func (r *APIKeyRepository) ListByMerchant(ctx context.Context, merchantID int) ([]*models.APIKey, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+apiKeyColumns+` FROM merchant_api_keys WHERE merchant_id = $1 ORDER BY created_at DESC, id DESC`, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*models.APIKey
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// Revoke disables a key immediately. It returns sql.ErrNoRows when the merchant has no such
+// unrevoked key.
+func (r *APIKeyRepository) Revoke(ctx context.Context, merchantID, id int) (*models.APIKey, error) {
+	return scanAPIKey(r.db.QueryRowContext(ctx, `
+		UPDATE merchant_api_keys SET revoked_at = NOW()
+		WHERE id = $1 AND merchant_id = $2 AND revoked_at IS NULL
+		RETURNING `+apiKeyColumns, id, merchantID))
+}
+
+// Rotate stores the replacement key and retires the old one at retireAt, in one transaction.
+// It returns sql.ErrNoRows when the old key is not an active key of the merchant.
+func (r *APIKeyRepository) Rotate(ctx context.Context, old *models.APIKey, replacement *models.APIKey, retireAt time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		UPDATE merchant_api_keys
+		SET expires_at = LEAST(COALESCE(expires_at, $3), $3)
+		WHERE id = $1 AND merchant_id = $2 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())
+		RETURNING expires_at
+	`, old.ID, old.MerchantID, retireAt).Scan(&old.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	if err := insertAPIKey(ctx, tx, replacement); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// TouchLastUsed records that a key was used. Writes are coalesced to one a minute per key so
+// busy keys don't turn every request into a row update.
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id int) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE merchant_api_keys SET last_used_at = NOW()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')
+	`, id)
+	return err
+}
@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/kodra-pay/checkout-service/internal/models"
+)
+
+type BrandingRepository struct {
+	db *sql.DB
+}
+
+func NewBrandingRepository(db *sql.DB) *BrandingRepository {
+	return &BrandingRepository{db: db}
+}
+
+// GetByMerchant returns the merchant's branding, or sql.ErrNoRows when it has none
+func (r *BrandingRepository) GetByMerchant(ctx context.Context, merchantID int) (*models.MerchantBranding, error) {
+	query := `
+		SELECT merchant_id, display_name, logo, logo_type, updated_at
+		FROM merchant_branding
+		WHERE merchant_id = $1
+	`
+	var b models.MerchantBranding
+	err := r.db.QueryRowContext(ctx, query, merchantID).Scan(
+		&b.MerchantID, &b.DisplayName, &b.Logo, &b.LogoType, &b.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (r *BrandingRepository) Upsert(ctx context.Context, b *models.MerchantBranding) error {
+	query := `
+		INSERT INTO merchant_branding (merchant_id, display_name, logo, logo_type)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (merchant_id) DO UPDATE SET
+			display_name = EXCLUDED.display_name, logo = EXCLUDED.logo,
+			logo_type = EXCLUDED.logo_type, updated_at = NOW()
+		RETURNING updated_at
+	`
+	return r.db.QueryRowContext(ctx, query, b.MerchantID, b.DisplayName, b.Logo, b.LogoType).Scan(&b.UpdatedAt)
+}
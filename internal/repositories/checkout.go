@@ -1,14 +1,178 @@
 package repositories
 
-import "log"
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
 
+	"github.com/kodra-pay/checkout-service/internal/models"
+)
+
+// CheckoutRepository persists checkout sessions and the payments taken through them
 type CheckoutRepository struct {
-    dsn string
+	db *sql.DB
+}
+
+func NewCheckoutRepository(db *sql.DB) *CheckoutRepository {
+	return &CheckoutRepository{db: db}
+}
+
+const checkoutSessionColumns = `id, merchant_id, amount, currency, description, customer_email, customer_id, status,
+	expires_at, created_at, updated_at`
+
+func scanCheckoutSession(row interface{ Scan(...any) error }) (*models.CheckoutSession, error) {
+	var cs models.CheckoutSession
+	err := row.Scan(
+		&cs.ID, &cs.MerchantID, &cs.Amount, &cs.Currency, &cs.Description,
+		&cs.CustomerEmail, &cs.CustomerID, &cs.Status, &cs.ExpiresAt, &cs.CreatedAt, &cs.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &cs, nil
+}
+
+func (r *CheckoutRepository) CreateSession(ctx context.Context, cs *models.CheckoutSession) error {
+	query := `
+		INSERT INTO checkout_sessions (merchant_id, amount, currency, description, customer_email, customer_id, status, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at, updated_at
+	`
+	return r.db.QueryRowContext(ctx, query,
+		cs.MerchantID, cs.Amount, cs.Currency, cs.Description, cs.CustomerEmail, cs.CustomerID, cs.Status, cs.ExpiresAt,
+	).Scan(&cs.ID, &cs.CreatedAt, &cs.UpdatedAt)
+}
+
+func (r *CheckoutRepository) GetSession(ctx context.Context, id int) (*models.CheckoutSession, error) {
+	return scanCheckoutSession(r.db.QueryRowContext(ctx, `SELECT `+checkoutSessionColumns+` FROM checkout_sessions WHERE id = $1`, id))
+}
+
+// ExpireDueSessions flips pending sessions whose expiry has passed to expired and returns them
+func (r *CheckoutRepository) ExpireDueSessions(ctx context.Context) ([]*models.CheckoutSession, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		UPDATE checkout_sessions
+		SET status = 'expired', updated_at = NOW()
+		WHERE status = 'pending' AND expires_at IS NOT NULL AND expires_at <= NOW()
+		RETURNING `+checkoutSessionColumns)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []*models.CheckoutSession
+	for rows.Next() {
+		cs, err := scanCheckoutSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, cs)
+	}
+	return sessions, rows.Err()
 }
 
-func NewCheckoutRepository(dsn string) *CheckoutRepository {
-    log.Printf("CheckoutRepository using DSN: %s", dsn)
-    return &CheckoutRepository{dsn: dsn}
+// ClaimSession moves a pending, unexpired session to processing so only one payment can be taken
+// through it. It returns sql.ErrNoRows when the session is no longer payable.
+func (r *CheckoutRepository) ClaimSession(ctx context.Context, id int) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE checkout_sessions SET status = 'processing', updated_at = NOW()
+		WHERE id = $1 AND status = 'pending' AND (expires_at IS NULL OR expires_at > NOW())
+	`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
 }
 
-// TODO: implement persistence for checkout sessions and payments.
+// ReleaseSession hands a claimed session back when the payment taken through it failed
+func (r *CheckoutRepository) ReleaseSession(ctx context.Context, id int) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE checkout_sessions SET status = 'pending', updated_at = NOW()
+		WHERE id = $1 AND status = 'processing'
+	`, id)
+	return err
+}
+
+// CompleteSession marks a claimed session paid. It returns sql.ErrNoRows when the session was not claimed.
+func (r *CheckoutRepository) CompleteSession(ctx context.Context, id int) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE checkout_sessions SET status = 'completed', updated_at = NOW()
+		WHERE id = $1 AND status = 'processing'
+	`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// UpdatePaymentStatus sets the status of a recorded payment, e.g. once a review settles it
+func (r *CheckoutRepository) UpdatePaymentStatus(ctx context.Context, reference, status string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE checkout_payments SET status = $2 WHERE transaction_reference = $1`, reference, status)
+	return err
+}
+
+func (r *CheckoutRepository) RecordPayment(ctx context.Context, p *models.CheckoutPayment) error {
+	query := `
+		INSERT INTO checkout_payments (transaction_reference, session_id, payment_link_id, merchant_id, customer_id, customer_email,
+			amount, fee_amount, net_amount, currency, payment_method, status, fraud_decision, fraud_score, origin, country,
+			custom_fields)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+		RETURNING id, created_at
+	`
+	return r.db.QueryRowContext(ctx, query,
+		p.TransactionReference, p.SessionID, p.PaymentLinkID, p.MerchantID, p.CustomerID, p.CustomerEmail,
+		p.Amount, p.FeeAmount, p.NetAmount, p.Currency, p.PaymentMethod, p.Status, p.FraudDecision, p.FraudScore, p.Origin, p.Country,
+		customFieldValuesJSON(p.CustomFields),
+	).Scan(&p.ID, &p.CreatedAt)
+}
+
+// GetPaymentByReference returns the payment recorded for a transaction reference, or sql.ErrNoRows
+func (r *CheckoutRepository) GetPaymentByReference(ctx context.Context, reference string) (*models.CheckoutPayment, error) {
+	query := `
+		SELECT id, transaction_reference, session_id, payment_link_id, merchant_id, customer_id, customer_email,
+			amount, fee_amount, net_amount, currency, payment_method, status, fraud_decision, fraud_score, origin, country,
+			custom_fields, created_at
+		FROM checkout_payments
+		WHERE transaction_reference = $1
+	`
+	var p models.CheckoutPayment
+	var customFields []byte
+	err := r.db.QueryRowContext(ctx, query, reference).Scan(
+		&p.ID, &p.TransactionReference, &p.SessionID, &p.PaymentLinkID, &p.MerchantID, &p.CustomerID, &p.CustomerEmail,
+		&p.Amount, &p.FeeAmount, &p.NetAmount, &p.Currency, &p.PaymentMethod, &p.Status, &p.FraudDecision, &p.FraudScore,
+		&p.Origin, &p.Country, &customFields, &p.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(customFields, &p.CustomFields); err != nil {
+		return nil, fmt.Errorf("decode custom fields of payment %s: %w", reference, err)
+	}
+	return &p, nil
+}
+
+func customFieldValuesJSON(values map[string]interface{}) string {
+	if len(values) == 0 {
+		return "{}"
+	}
+	raw, _ := json.Marshal(values)
+	return string(raw)
+}
+
+// CountCustomerPayments counts earlier payments by the customer ID, or by email when there is no ID
+func (r *CheckoutRepository) CountCustomerPayments(ctx context.Context, customerID int, email string) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM checkout_payments
+		WHERE ($1 <> 0 AND customer_id = $1) OR ($1 = 0 AND $2 <> '' AND lower(customer_email) = lower($2))
+	`
+	var n int
+	err := r.db.QueryRowContext(ctx, query, customerID, email).Scan(&n)
+	return n, err
+}
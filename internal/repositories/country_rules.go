@@ -0,0 +1,49 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+
+	"github.com/kodra-pay/checkout-service/internal/models"
+)
+
+type CountryRuleRepository struct {
+	db *sql.DB
+}
+
+func NewCountryRuleRepository(db *sql.DB) *CountryRuleRepository {
+	return &CountryRuleRepository{db: db}
+}
+
+// GetByMerchant returns the merchant's rules, or sql.ErrNoRows when it has none
+func (r *CountryRuleRepository) GetByMerchant(ctx context.Context, merchantID int) (*models.MerchantCountryRules, error) {
+	query := `
+		SELECT merchant_id, allowed_countries, blocked_countries, block_unknown, updated_at
+		FROM merchant_country_rules
+		WHERE merchant_id = $1
+	`
+	var cr models.MerchantCountryRules
+	err := r.db.QueryRowContext(ctx, query, merchantID).Scan(
+		&cr.MerchantID, pq.Array(&cr.AllowedCountries), pq.Array(&cr.BlockedCountries), &cr.BlockUnknown, &cr.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &cr, nil
+}
+
+func (r *CountryRuleRepository) Upsert(ctx context.Context, cr *models.MerchantCountryRules) error {
+	query := `
+		INSERT INTO merchant_country_rules (merchant_id, allowed_countries, blocked_countries, block_unknown)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (merchant_id) DO UPDATE SET
+			allowed_countries = EXCLUDED.allowed_countries, blocked_countries = EXCLUDED.blocked_countries,
+			block_unknown = EXCLUDED.block_unknown, updated_at = NOW()
+		RETURNING updated_at
+	`
+	return r.db.QueryRowContext(ctx, query,
+		cr.MerchantID, pq.Array(cr.AllowedCountries), pq.Array(cr.BlockedCountries), cr.BlockUnknown,
+	).Scan(&cr.UpdatedAt)
+}
@@ -0,0 +1,130 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"github.com/kodra-pay/checkout-service/internal/models"
+)
+
+type FraudPolicyRepository struct {
+	db *sql.DB
+}
+
+func NewFraudPolicyRepository(db *sql.DB) *FraudPolicyRepository {
+	return &FraudPolicyRepository{db: db}
+}
+
+// GetByMerchant returns the merchant's override, or sql.ErrNoRows when the default applies
+func (r *FraudPolicyRepository) GetByMerchant(ctx context.Context, merchantID int) (*models.MerchantFraudPolicy, error) {
+	query := `
+		SELECT merchant_id, mode, max_amount, updated_at
+		FROM merchant_fraud_policies
+		WHERE merchant_id = $1
+	`
+	var p models.MerchantFraudPolicy
+	err := r.db.QueryRowContext(ctx, query, merchantID).Scan(&p.MerchantID, &p.Mode, &p.MaxAmount, &p.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *FraudPolicyRepository) Upsert(ctx context.Context, p *models.MerchantFraudPolicy) error {
+	query := `
+		INSERT INTO merchant_fraud_policies (merchant_id, mode, max_amount)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (merchant_id) DO UPDATE SET mode = EXCLUDED.mode, max_amount = EXCLUDED.max_amount, updated_at = NOW()
+		RETURNING updated_at
+	`
+	return r.db.QueryRowContext(ctx, query, p.MerchantID, p.Mode, p.MaxAmount).Scan(&p.UpdatedAt)
+}
+
+type UnscreenedPaymentRepository struct {
+	db *sql.DB
+}
+
+func NewUnscreenedPaymentRepository(db *sql.DB) *UnscreenedPaymentRepository {
+	return &UnscreenedPaymentRepository{db: db}
+}
+
+func (r *UnscreenedPaymentRepository) Create(ctx context.Context, up *models.UnscreenedPayment) error {
+	query := `
+		INSERT INTO unscreened_payments (transaction_reference, merchant_id, customer_id, customer_email, amount, fee_amount,
+			net_amount, currency, payment_method, origin, country, fraud_signals, fail_policy, local_decision, fraud_score, fraud_reasons, wallet_credit, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+		RETURNING id, created_at
+	`
+	return r.db.QueryRowContext(ctx, query,
+		up.TransactionReference, up.MerchantID, up.CustomerID, up.CustomerEmail, up.Amount, up.FeeAmount,
+		up.NetAmount, up.Currency, up.PaymentMethod, up.Origin, up.Country, customFieldValuesJSON(up.FraudSignals),
+		up.FailPolicy, up.LocalDecision, up.FraudScore, pq.Array(up.FraudReasons), up.WalletCredit, up.Status,
+	).Scan(&up.ID, &up.CreatedAt)
+}
+
+// ListPending returns the payments still waiting for a fraud-service verdict whose next attempt is due, oldest first
+func (r *UnscreenedPaymentRepository) ListPending(ctx context.Context, limit int) ([]*models.UnscreenedPayment, error) {
+	query := `
+		SELECT id, transaction_reference, merchant_id, customer_id, customer_email, amount, fee_amount, net_amount,
+			currency, payment_method, origin, country, fraud_signals, fail_policy, local_decision, fraud_score, fraud_reasons, wallet_credit, status,
+			attempts, last_error, screened_decision, screened_score, screened_at, created_at
+		FROM unscreened_payments
+		WHERE status = 'pending' AND next_attempt_at <= NOW()
+		ORDER BY created_at ASC
+		LIMIT $1
+	`
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var payments []*models.UnscreenedPayment
+	for rows.Next() {
+		var up models.UnscreenedPayment
+		var signals []byte
+		if err := rows.Scan(
+			&up.ID, &up.TransactionReference, &up.MerchantID, &up.CustomerID, &up.CustomerEmail, &up.Amount, &up.FeeAmount, &up.NetAmount,
+			&up.Currency, &up.PaymentMethod, &up.Origin, &up.Country, &signals, &up.FailPolicy, &up.LocalDecision, &up.FraudScore, pq.Array(&up.FraudReasons),
+			&up.WalletCredit, &up.Status, &up.Attempts, &up.LastError,
+			&up.ScreenedDecision, &up.ScreenedScore, &up.ScreenedAt, &up.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(signals, &up.FraudSignals); err != nil {
+			return nil, fmt.Errorf("decode fraud signals of unscreened payment %s: %w", up.TransactionReference, err)
+		}
+		payments = append(payments, &up)
+	}
+	return payments, rows.Err()
+}
+
+func (r *UnscreenedPaymentRepository) MarkScreened(ctx context.Context, id int, decision string, score float64) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE unscreened_payments
+		SET status = 'screened', screened_decision = $2, screened_score = $3, screened_at = NOW(),
+			attempts = attempts + 1, last_error = '', updated_at = NOW()
+		WHERE id = $1
+	`, id, decision, score)
+	return err
+}
+
+// RecordFailure counts a failed re-screening attempt, backing the payment off exponentially
+// (1 minute after the first failure, capped at an hour), and abandons it after maxAttempts.
+// Payments whose wallet credit is held are never abandoned: they are retried until they reach
+// the review queue.
+func (r *UnscreenedPaymentRepository) RecordFailure(ctx context.Context, id int, lastError string, maxAttempts int) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE unscreened_payments
+		SET attempts = attempts + 1, last_error = $2,
+			status = CASE WHEN attempts + 1 >= $3 AND wallet_credit <> 'held' THEN 'abandoned' ELSE status END,
+			next_attempt_at = NOW() + LEAST(INTERVAL '1 minute' * power(2, LEAST(attempts, 6)), INTERVAL '1 hour'),
+			updated_at = NOW()
+		WHERE id = $1
+	`, id, lastError, maxAttempts)
+	return err
+}
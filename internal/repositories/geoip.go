@@ -0,0 +1,234 @@
+package repositories
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// geoDB resolves an IP to an ISO country code ("" when unknown)
+type geoDB interface {
+	country(ip net.IP) string
+	close() error
+}
+
+// GeoIPResolver looks up client countries in a local MaxMind .mmdb file or a CSV of
+// "start_ip,end_ip,country_code" ranges. The file can be swapped on disk and reloaded
+// without restarting the service.
+type GeoIPResolver struct {
+	path    string
+	mu      sync.RWMutex
+	db      geoDB
+	modTime time.Time
+}
+
+// NewGeoIPResolver loads the database at path. An empty path gives a resolver that never resolves.
+func NewGeoIPResolver(path string) (*GeoIPResolver, error) {
+	g := &GeoIPResolver{path: path}
+	if path == "" {
+		return g, nil
+	}
+	if err := g.Reload(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// Country returns the ISO 3166-1 alpha-2 code for ip, or "" when it cannot be resolved
+func (g *GeoIPResolver) Country(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if g.db == nil {
+		return ""
+	}
+	return g.db.country(parsed)
+}
+
+// Reload re-reads the database file and swaps it in
+func (g *GeoIPResolver) Reload() error {
+	if g.path == "" {
+		return fmt.Errorf("no geoip database configured")
+	}
+	info, err := os.Stat(g.path)
+	if err != nil {
+		return fmt.Errorf("stat geoip database: %w", err)
+	}
+
+	var db geoDB
+	if strings.HasSuffix(strings.ToLower(g.path), ".mmdb") {
+		db, err = openMMDB(g.path)
+	} else {
+		db, err = openCSVGeoDB(g.path)
+	}
+	if err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	old := g.db
+	g.db = db
+	g.modTime = info.ModTime()
+	g.mu.Unlock()
+
+	if old != nil {
+		old.close()
+	}
+	return nil
+}
+
+// Watch reloads the database whenever the file's modification time changes
+func (g *GeoIPResolver) Watch(ctx context.Context, interval time.Duration) {
+	if g.path == "" {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			info, err := os.Stat(g.path)
+			if err != nil {
+				fmt.Printf("Warning: geoip database unavailable: %v\n", err)
+				continue
+			}
+			g.mu.RLock()
+			changed := !info.ModTime().Equal(g.modTime)
+			g.mu.RUnlock()
+			if !changed {
+				continue
+			}
+			if err := g.Reload(); err != nil {
+				fmt.Printf("Warning: failed to reload geoip database, keeping previous one: %v\n", err)
+			}
+		}
+	}
+}
+
+type mmdbGeoDB struct {
+	reader *maxminddb.Reader
+}
+
+func openMMDB(path string) (*mmdbGeoDB, error) {
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open mmdb: %w", err)
+	}
+	return &mmdbGeoDB{reader: reader}, nil
+}
+
+func (m *mmdbGeoDB) country(ip net.IP) string {
+	var record struct {
+		Country struct {
+			ISOCode string `maxminddb:"iso_code"`
+		} `maxminddb:"country"`
+	}
+	if err := m.reader.Lookup(ip, &record); err != nil {
+		return ""
+	}
+	return record.Country.ISOCode
+}
+
+func (m *mmdbGeoDB) close() error {
+	return m.reader.Close()
+}
+
+type ipRange struct {
+	start, end [16]byte
+	country    string
+}
+
+// csvGeoDB holds sorted, non-overlapping IP ranges searched with binary search
+type csvGeoDB struct {
+	ranges []ipRange
+}
+
+// openCSVGeoDB reads "start,end,country" rows; start and end may be IP addresses or IPv4
+// integers as in the IP2Location/DB-IP lite exports. Rows that don't parse (headers, comments) are skipped.
+func openCSVGeoDB(path string) (*csvGeoDB, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open geoip csv: %w", err)
+	}
+	defer f.Close()
+
+	db := &csvGeoDB{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), ",")
+		if len(fields) < 3 {
+			continue
+		}
+		start, okStart := parseRangeBound(fields[0])
+		end, okEnd := parseRangeBound(fields[1])
+		country := strings.ToUpper(strings.Trim(strings.TrimSpace(fields[2]), `"`))
+		if !okStart || !okEnd || len(country) != 2 || country == "--" {
+			continue
+		}
+		db.ranges = append(db.ranges, ipRange{start: start, end: end, country: country})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read geoip csv: %w", err)
+	}
+	if len(db.ranges) == 0 {
+		return nil, fmt.Errorf("geoip csv %s has no usable ranges", path)
+	}
+	sort.Slice(db.ranges, func(i, j int) bool {
+		return bytes.Compare(db.ranges[i].start[:], db.ranges[j].start[:]) < 0
+	})
+	return db, nil
+}
+
+func parseRangeBound(field string) ([16]byte, bool) {
+	var out [16]byte
+	field = strings.Trim(strings.TrimSpace(field), `"`)
+	if ip := net.ParseIP(field); ip != nil {
+		copy(out[:], ip.To16())
+		return out, true
+	}
+	n, err := strconv.ParseUint(field, 10, 32)
+	if err != nil {
+		return out, false
+	}
+	ip := make(net.IP, 4)
+	binary.BigEndian.PutUint32(ip, uint32(n))
+	copy(out[:], ip.To16())
+	return out, true
+}
+
+func (c *csvGeoDB) country(ip net.IP) string {
+	var key [16]byte
+	copy(key[:], ip.To16())
+	// First range starting after ip; the candidate is the one before it
+	i := sort.Search(len(c.ranges), func(i int) bool {
+		return bytes.Compare(c.ranges[i].start[:], key[:]) > 0
+	})
+	if i == 0 {
+		return ""
+	}
+	r := c.ranges[i-1]
+	if bytes.Compare(key[:], r.end[:]) <= 0 {
+		return r.country
+	}
+	return ""
+}
+
+func (c *csvGeoDB) close() error {
+	return nil
+}
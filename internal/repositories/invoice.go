@@ -0,0 +1,222 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/kodra-pay/checkout-service/internal/models"
+)
+
+type InvoiceRepository struct {
+	db *sql.DB
+}
+
+func NewInvoiceRepository(db *sql.DB) *InvoiceRepository {
+	return &InvoiceRepository{db: db}
+}
+
+const invoiceColumns = `id, merchant_id, number, customer_name, customer_email, currency, line_items, tax_lines,
+	subtotal, tax_total, total, memo, due_date, status, payment_link_id, transaction_reference,
+	finalized_at, paid_at, voided_at, created_at, updated_at`
+
+func scanInvoice(row interface{ Scan(...any) error }) (*models.Invoice, error) {
+	var inv models.Invoice
+	var lineItems, taxLines []byte
+	err := row.Scan(
+		&inv.ID, &inv.MerchantID, &inv.Number, &inv.CustomerName, &inv.CustomerEmail, &inv.Currency, &lineItems, &taxLines,
+		&inv.Subtotal, &inv.TaxTotal, &inv.Total, &inv.Memo, &inv.DueDate, &inv.Status, &inv.PaymentLinkID, &inv.TransactionReference,
+		&inv.FinalizedAt, &inv.PaidAt, &inv.VoidedAt, &inv.CreatedAt, &inv.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(lineItems, &inv.LineItems); err != nil {
+		return nil, fmt.Errorf("decode line items of invoice %d: %w", inv.ID, err)
+	}
+	if err := json.Unmarshal(taxLines, &inv.TaxLines); err != nil {
+		return nil, fmt.Errorf("decode tax lines of invoice %d: %w", inv.ID, err)
+	}
+	return &inv, nil
+}
+
+// Create stores a draft invoice
+func (r *InvoiceRepository) Create(ctx context.Context, inv *models.Invoice) error {
+	lineItems, err := json.Marshal(inv.LineItems)
+	if err != nil {
+		return err
+	}
+	taxLines, err := json.Marshal(inv.TaxLines)
+	if err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO invoices (merchant_id, customer_name, customer_email, currency, line_items, tax_lines,
+			subtotal, tax_total, total, memo, due_date, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id, created_at, updated_at
+	`
+	return r.db.QueryRowContext(ctx, query,
+		inv.MerchantID, inv.CustomerName, inv.CustomerEmail, inv.Currency, string(lineItems), string(taxLines),
+		inv.Subtotal, inv.TaxTotal, inv.Total, inv.Memo, inv.DueDate, inv.Status,
+	).Scan(&inv.ID, &inv.CreatedAt, &inv.UpdatedAt)
+}
+
+func (r *InvoiceRepository) GetByID(ctx context.Context, id int) (*models.Invoice, error) {
+	return scanInvoice(r.db.QueryRowContext(ctx, `SELECT `+invoiceColumns+` FROM invoices WHERE id = $1`, id))
+}
+
+// GetByPaymentLink returns the invoice paid through a link, or sql.ErrNoRows when the link has none
+func (r *InvoiceRepository) GetByPaymentLink(ctx context.Context, linkID int) (*models.Invoice, error) {
+	return scanInvoice(r.db.QueryRowContext(ctx, `SELECT `+invoiceColumns+` FROM invoices WHERE payment_link_id = $1`, linkID))
+}
+
+// List returns a merchant's invoices, newest first, optionally narrowed to one status
+func (r *InvoiceRepository) List(ctx context.Context, merchantID int, status string, limit int) ([]*models.Invoice, error) {
+	query := `
+		SELECT ` + invoiceColumns + `
+		FROM invoices
+		WHERE merchant_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3
+	`
+	rows, err := r.db.QueryContext(ctx, query, merchantID, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invoices []*models.Invoice
+	for rows.Next() {
+		inv, err := scanInvoice(rows)
+		if err != nil {
+			return nil, err
+		}
+		invoices = append(invoices, inv)
+	}
+	return invoices, rows.Err()
+}
+
+// Finalize numbers a draft invoice, creates its payment link and marks it sent in one transaction.
+// link is filled in by the caller except for the reference, which becomes the invoice number,
+// and an empty description, which defaults to "Invoice INV-000042".
+// It returns sql.ErrNoRows when the invoice is no longer a draft.
+func (r *InvoiceRepository) Finalize(ctx context.Context, inv *models.Invoice, link *models.PaymentLink) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the draft first so concurrent finalizes cannot both take a number
+	var status string
+	if err := tx.QueryRowContext(ctx, `SELECT status FROM invoices WHERE id = $1 FOR UPDATE`, inv.ID).Scan(&status); err != nil {
+		return err
+	}
+	if status != models.InvoiceDraft {
+		return sql.ErrNoRows
+	}
+
+	var number int
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO invoice_sequences (merchant_id, last_number) VALUES ($1, 1)
+		ON CONFLICT (merchant_id) DO UPDATE SET last_number = invoice_sequences.last_number + 1
+		RETURNING last_number
+	`, inv.MerchantID).Scan(&number)
+	if err != nil {
+		return err
+	}
+	inv.Number = &number
+	link.Reference = inv.DisplayNumber()
+	if link.Description == "" {
+		link.Description = "Invoice " + link.Reference
+	}
+
+	if err := insertPaymentLink(ctx, tx, link); err != nil {
+		return err
+	}
+	inv.PaymentLinkID = &link.ID
+
+	err = tx.QueryRowContext(ctx, `
+		UPDATE invoices
+		SET number = $2, payment_link_id = $3, status = 'sent', finalized_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+		RETURNING status, finalized_at, updated_at
+	`, inv.ID, number, link.ID).Scan(&inv.Status, &inv.FinalizedAt, &inv.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Void cancels an unpaid invoice and deactivates its payment link. It returns sql.ErrNoRows
+// when the invoice is already paid or void.
+func (r *InvoiceRepository) Void(ctx context.Context, id int) (*models.Invoice, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	inv, err := scanInvoice(tx.QueryRowContext(ctx, `
+		UPDATE invoices SET status = 'void', voided_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status IN ('draft', 'sent', 'overdue')
+		RETURNING `+invoiceColumns, id))
+	if err != nil {
+		return nil, err
+	}
+	if inv.PaymentLinkID != nil {
+		_, err := tx.ExecContext(ctx,
+			`UPDATE payment_links SET status = 'inactive', updated_at = NOW() WHERE id = $1 AND status = 'active'`,
+			*inv.PaymentLinkID,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return inv, tx.Commit()
+}
+
+// MarkPaidByPaymentLink settles the open invoice behind a payment link. It reports whether an
+// invoice was updated; links that don't belong to an invoice are ignored.
+func (r *InvoiceRepository) MarkPaidByPaymentLink(ctx context.Context, linkID int, reference string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE invoices
+		SET status = 'paid', paid_at = NOW(), transaction_reference = $2, updated_at = NOW()
+		WHERE payment_link_id = $1 AND status IN ('sent', 'overdue')
+	`, linkID, reference)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ReopenByPaymentLink undoes MarkPaidByPaymentLink when the payment that settled the invoice is
+// refunded, putting it back to sent or overdue depending on its due date
+func (r *InvoiceRepository) ReopenByPaymentLink(ctx context.Context, linkID int, reference string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE invoices
+		SET status = CASE WHEN due_date < CURRENT_DATE THEN 'overdue' ELSE 'sent' END,
+			paid_at = NULL, transaction_reference = '', updated_at = NOW()
+		WHERE payment_link_id = $1 AND status = 'paid' AND transaction_reference = $2
+	`, linkID, reference)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// MarkOverdue flips sent invoices whose due date has passed to overdue and returns how many changed
+func (r *InvoiceRepository) MarkOverdue(ctx context.Context) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE invoices SET status = 'overdue', updated_at = NOW()
+		WHERE status = 'sent' AND due_date < CURRENT_DATE
+	`)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
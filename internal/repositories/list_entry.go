@@ -0,0 +1,135 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/kodra-pay/checkout-service/internal/models"
+)
+
+type ListEntryRepository struct {
+	db *sql.DB
+}
+
+func NewListEntryRepository(db *sql.DB) *ListEntryRepository {
+	return &ListEntryRepository{db: db}
+}
+
+const listEntryColumns = `id, merchant_id, list_type, kind, value, reason, created_by, expires_at, created_at, updated_at`
+
+func scanListEntry(row interface{ Scan(...any) error }) (*models.ListEntry, error) {
+	var e models.ListEntry
+	if err := row.Scan(
+		&e.ID, &e.MerchantID, &e.ListType, &e.Kind, &e.Value, &e.Reason, &e.CreatedBy, &e.ExpiresAt, &e.CreatedAt, &e.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *ListEntryRepository) Create(ctx context.Context, e *models.ListEntry) error {
+	query := `
+		INSERT INTO list_entries (merchant_id, list_type, kind, value, reason, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at, updated_at
+	`
+	return r.db.QueryRowContext(ctx, query,
+		e.MerchantID, e.ListType, e.Kind, e.Value, e.Reason, e.CreatedBy, e.ExpiresAt,
+	).Scan(&e.ID, &e.CreatedAt, &e.UpdatedAt)
+}
+
+func (r *ListEntryRepository) GetByID(ctx context.Context, id int) (*models.ListEntry, error) {
+	return scanListEntry(r.db.QueryRowContext(ctx, `SELECT `+listEntryColumns+` FROM list_entries WHERE id = $1`, id))
+}
+
+// ListEntryFilter narrows List; zero values match everything
+type ListEntryFilter struct {
+	MerchantID     *int // nil matches every scope; pointer to 0 matches global entries only
+	ListType       string
+	Kind           string
+	IncludeExpired bool
+	Limit          int
+}
+
+func (r *ListEntryRepository) List(ctx context.Context, f ListEntryFilter) ([]*models.ListEntry, error) {
+	var merchantID sql.NullInt64
+	if f.MerchantID != nil {
+		merchantID = sql.NullInt64{Int64: int64(*f.MerchantID), Valid: true}
+	}
+	query := `
+		SELECT ` + listEntryColumns + `
+		FROM list_entries
+		WHERE ($1::INTEGER IS NULL OR COALESCE(merchant_id, 0) = $1)
+			AND ($2 = '' OR list_type = $2)
+			AND ($3 = '' OR kind = $3)
+			AND ($4 OR expires_at IS NULL OR expires_at > NOW())
+		ORDER BY created_at DESC
+		LIMIT $5
+	`
+	rows, err := r.db.QueryContext(ctx, query, merchantID, f.ListType, f.Kind, f.IncludeExpired, f.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*models.ListEntry
+	for rows.Next() {
+		e, err := scanListEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// Update changes an entry's reason and expiry
+func (r *ListEntryRepository) Update(ctx context.Context, id int, reason string, expiresAt *time.Time) (*models.ListEntry, error) {
+	query := `
+		UPDATE list_entries SET reason = $2, expires_at = $3, updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + listEntryColumns
+	return scanListEntry(r.db.QueryRowContext(ctx, query, id, reason, expiresAt))
+}
+
+func (r *ListEntryRepository) Delete(ctx context.Context, id int) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM list_entries WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Match returns unexpired entries of listType, global or for merchantID, whose kind and value
+// match one of the candidates. kinds and values are parallel slices.
+func (r *ListEntryRepository) Match(ctx context.Context, merchantID int, listType string, kinds, values []string) ([]*models.ListEntry, error) {
+	query := `
+		SELECT e.id, e.merchant_id, e.list_type, e.kind, e.value, e.reason, e.created_by, e.expires_at, e.created_at, e.updated_at
+		FROM list_entries e
+		JOIN UNNEST($3::TEXT[], $4::TEXT[]) AS c(kind, value) ON e.kind = c.kind AND e.value = c.value
+		WHERE (e.merchant_id IS NULL OR e.merchant_id = $1)
+			AND e.list_type = $2
+			AND (e.expires_at IS NULL OR e.expires_at > NOW())
+	`
+	rows, err := r.db.QueryContext(ctx, query, merchantID, listType, pq.Array(kinds), pq.Array(values))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*models.ListEntry
+	for rows.Next() {
+		e, err := scanListEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
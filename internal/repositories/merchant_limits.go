@@ -0,0 +1,100 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/kodra-pay/checkout-service/internal/models"
+)
+
+type MerchantLimitRepository struct {
+	db *sql.DB
+}
+
+func NewMerchantLimitRepository(db *sql.DB) *MerchantLimitRepository {
+	return &MerchantLimitRepository{db: db}
+}
+
+// GetByMerchant returns the merchant's limits with currency overrides, or sql.ErrNoRows when defaults apply
+func (r *MerchantLimitRepository) GetByMerchant(ctx context.Context, merchantID int) (*models.MerchantLimits, error) {
+	query := `
+		SELECT merchant_id, min_amount, max_amount, daily_volume, monthly_volume, updated_at
+		FROM merchant_limits
+		WHERE merchant_id = $1
+	`
+	var l models.MerchantLimits
+	err := r.db.QueryRowContext(ctx, query, merchantID).Scan(
+		&l.MerchantID, &l.MinAmount, &l.MaxAmount, &l.DailyVolume, &l.MonthlyVolume, &l.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT currency, min_amount, max_amount, daily_volume, monthly_volume
+		FROM merchant_currency_limits
+		WHERE merchant_id = $1
+		ORDER BY currency
+	`, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var cl models.CurrencyLimit
+		if err := rows.Scan(&cl.Currency, &cl.MinAmount, &cl.MaxAmount, &cl.DailyVolume, &cl.MonthlyVolume); err != nil {
+			return nil, err
+		}
+		l.Currencies = append(l.Currencies, cl)
+	}
+	return &l, rows.Err()
+}
+
+// Upsert saves the merchant's limits and replaces its currency overrides
+func (r *MerchantLimitRepository) Upsert(ctx context.Context, l *models.MerchantLimits) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO merchant_limits (merchant_id, min_amount, max_amount, daily_volume, monthly_volume)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (merchant_id) DO UPDATE SET
+			min_amount = EXCLUDED.min_amount, max_amount = EXCLUDED.max_amount,
+			daily_volume = EXCLUDED.daily_volume, monthly_volume = EXCLUDED.monthly_volume, updated_at = NOW()
+		RETURNING updated_at
+	`, l.MerchantID, l.MinAmount, l.MaxAmount, l.DailyVolume, l.MonthlyVolume).Scan(&l.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("upsert merchant limits: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM merchant_currency_limits WHERE merchant_id = $1`, l.MerchantID); err != nil {
+		return fmt.Errorf("clear currency limits: %w", err)
+	}
+	for _, cl := range l.Currencies {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO merchant_currency_limits (merchant_id, currency, min_amount, max_amount, daily_volume, monthly_volume)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, l.MerchantID, cl.Currency, cl.MinAmount, cl.MaxAmount, cl.DailyVolume, cl.MonthlyVolume)
+		if err != nil {
+			return fmt.Errorf("insert currency limit %s: %w", cl.Currency, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// VolumeSince sums the merchant's accepted payments in a currency, in any letter case, from the given time
+func (r *MerchantLimitRepository) VolumeSince(ctx context.Context, merchantID int, currency string, since time.Time) (float64, error) {
+	query := `
+		SELECT COALESCE(SUM(amount), 0)
+		FROM checkout_payments
+		WHERE merchant_id = $1 AND upper(currency) = upper($2) AND created_at >= $3 AND status IN ('paid', 'pending_review')
+	`
+	var total float64
+	err := r.db.QueryRowContext(ctx, query, merchantID, currency, since).Scan(&total)
+	return total, err
+}
@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// MemoryNonceStore remembers request nonces in process memory. Nonces are per instance, so
+// replays are only caught across replicas with the Redis store.
+type MemoryNonceStore struct {
+	mu     sync.Mutex
+	nonces map[string]time.Time // nonce -> when it may be forgotten
+	adds   int
+}
+
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{nonces: make(map[string]time.Time)}
+}
+
+// Remember records nonce for ttl and reports whether it was unseen
+func (s *MemoryNonceStore) Remember(_ context.Context, nonce string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if until, ok := s.nonces[nonce]; ok && now.Before(until) {
+		return false, nil
+	}
+	s.nonces[nonce] = now.Add(ttl)
+
+	s.adds++
+	if s.adds%sweepEvery == 0 {
+		for n, until := range s.nonces {
+			if !now.Before(until) {
+				delete(s.nonces, n)
+			}
+		}
+	}
+	return true, nil
+}
+
+// RedisNonceStore remembers request nonces in Redis so replays are caught across replicas
+type RedisNonceStore struct {
+	client *redis.Client
+}
+
+func NewRedisNonceStore(addr string) (*RedisNonceStore, error) {
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("ping redis: %w", err)
+	}
+	return &RedisNonceStore{client: client}, nil
+}
+
+// Remember records nonce for ttl and reports whether it was unseen
+func (s *RedisNonceStore) Remember(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	fresh, err := s.client.SetNX(ctx, "checkout:nonce:"+nonce, 1, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("remember nonce: %w", err)
+	}
+	return fresh, nil
+}
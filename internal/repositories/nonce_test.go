@@ -0,0 +1,36 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryNonceStoreRemember(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryNonceStore()
+
+	tests := []struct {
+		name      string
+		nonce     string
+		ttl       time.Duration
+		wantFresh bool
+	}{
+		{name: "first use", nonce: "1:abc", ttl: time.Minute, wantFresh: true},
+		{name: "replay", nonce: "1:abc", ttl: time.Minute, wantFresh: false},
+		{name: "same nonce for another key", nonce: "2:abc", ttl: time.Minute, wantFresh: true},
+		{name: "expired nonce", nonce: "1:short", ttl: -time.Second, wantFresh: true},
+		{name: "expired nonce reused", nonce: "1:short", ttl: time.Minute, wantFresh: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fresh, err := s.Remember(ctx, tt.nonce, tt.ttl)
+			if err != nil {
+				t.Fatalf("Remember: %v", err)
+			}
+			if fresh != tt.wantFresh {
+				t.Errorf("fresh = %v, want %v", fresh, tt.wantFresh)
+			}
+		})
+	}
+}
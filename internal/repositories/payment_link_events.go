@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kodra-pay/checkout-service/internal/models"
+)
+
+// linkEventCounters maps each event type to the running counter it bumps on payment_links
+var linkEventCounters = map[string]string{
+	models.LinkEventView:        "view_count",
+	models.LinkEventAttempt:     "attempt_count",
+	models.LinkEventSuccess:     "success_count",
+	models.LinkEventFraudDenied: "fraud_denial_count",
+}
+
+// RecordEvent logs an analytics event and bumps the link's matching counter in one statement.
+// amount is added to collected_amount and is only meaningful for success events.
+func (r *PaymentLinkRepository) RecordEvent(ctx context.Context, linkID int, eventType string, amount float64) error {
+	counter, ok := linkEventCounters[eventType]
+	if !ok {
+		return fmt.Errorf("unknown payment link event %q", eventType)
+	}
+	query := `
+		WITH event AS (
+			INSERT INTO payment_link_events (payment_link_id, event_type, amount)
+			VALUES ($1, $2, $3)
+		)
+		UPDATE payment_links
+		SET ` + counter + ` = ` + counter + ` + 1, collected_amount = collected_amount + $3
+		WHERE id = $1
+	`
+	_, err := r.db.ExecContext(ctx, query, linkID, eventType, amount)
+	return err
+}
+
+// DailyStats buckets a link's events by UTC day from since onwards; days without events are omitted
+func (r *PaymentLinkRepository) DailyStats(ctx context.Context, linkID int, since time.Time) ([]models.PaymentLinkDailyStats, error) {
+	query := `
+		SELECT (created_at AT TIME ZONE 'UTC')::date AS day,
+			COUNT(*) FILTER (WHERE event_type = 'view'),
+			COUNT(*) FILTER (WHERE event_type = 'attempt'),
+			COUNT(*) FILTER (WHERE event_type = 'success'),
+			COUNT(*) FILTER (WHERE event_type = 'fraud_denied'),
+			COALESCE(SUM(amount) FILTER (WHERE event_type = 'success'), 0)
+		FROM payment_link_events
+		WHERE payment_link_id = $1 AND created_at >= $2
+		GROUP BY day
+		ORDER BY day
+	`
+	rows, err := r.db.QueryContext(ctx, query, linkID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []models.PaymentLinkDailyStats
+	for rows.Next() {
+		var d models.PaymentLinkDailyStats
+		if err := rows.Scan(&d.Day, &d.Views, &d.Attempts, &d.Successes, &d.FraudDenials, &d.CollectedAmount); err != nil {
+			return nil, err
+		}
+		stats = append(stats, d)
+	}
+	return stats, rows.Err()
+}
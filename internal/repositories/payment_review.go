@@ -0,0 +1,202 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"github.com/kodra-pay/checkout-service/internal/models"
+)
+
+// ErrReviewNotPending is returned when resolving a review that has already been approved or rejected
+var ErrReviewNotPending = errors.New("review is not pending")
+
+type PaymentReviewRepository struct {
+	db *sql.DB
+}
+
+func NewPaymentReviewRepository(db *sql.DB) *PaymentReviewRepository {
+	return &PaymentReviewRepository{db: db}
+}
+
+const paymentReviewColumns = `id, transaction_reference, merchant_id, customer_id, customer_email, amount, fee_amount,
+	net_amount, currency, payment_method, fraud_score, fraud_reasons, status, wallet_credit,
+	reviewed_by, review_notes, reviewed_at, created_at, updated_at`
+
+func scanPaymentReview(row interface{ Scan(...any) error }) (*models.PaymentReview, error) {
+	var pr models.PaymentReview
+	err := row.Scan(
+		&pr.ID, &pr.TransactionReference, &pr.MerchantID, &pr.CustomerID, &pr.CustomerEmail, &pr.Amount, &pr.FeeAmount,
+		&pr.NetAmount, &pr.Currency, &pr.PaymentMethod, &pr.FraudScore, pq.Array(&pr.FraudReasons), &pr.Status, &pr.WalletCredit,
+		&pr.ReviewedBy, &pr.ReviewNotes, &pr.ReviewedAt, &pr.CreatedAt, &pr.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// Enqueue stores a new pending review together with its "enqueued" audit event. A payment that
+// is already in the queue is left as it is.
+func (r *PaymentReviewRepository) Enqueue(ctx context.Context, pr *models.PaymentReview) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO payment_reviews (transaction_reference, merchant_id, customer_id, customer_email, amount, fee_amount,
+			net_amount, currency, payment_method, fraud_score, fraud_reasons, status, wallet_credit)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT (transaction_reference) DO NOTHING
+		RETURNING id, created_at, updated_at
+	`
+	err = tx.QueryRowContext(ctx, query,
+		pr.TransactionReference, pr.MerchantID, pr.CustomerID, pr.CustomerEmail, pr.Amount, pr.FeeAmount,
+		pr.NetAmount, pr.Currency, pr.PaymentMethod, pr.FraudScore, pq.Array(pr.FraudReasons), pr.Status, pr.WalletCredit,
+	).Scan(&pr.ID, &pr.CreatedAt, &pr.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("insert payment review: %w", err)
+	}
+
+	if err := insertReviewEvent(ctx, tx, pr.ID, "enqueued", "system", fmt.Sprintf("fraud score %.2f", pr.FraudScore)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *PaymentReviewRepository) GetByID(ctx context.Context, id int) (*models.PaymentReview, error) {
+	query := `SELECT ` + paymentReviewColumns + ` FROM payment_reviews WHERE id = $1`
+	return scanPaymentReview(r.db.QueryRowContext(ctx, query, id))
+}
+
+// List returns reviews with the given status (all statuses when empty), oldest first so the queue is worked in order
+func (r *PaymentReviewRepository) List(ctx context.Context, status string, limit int) ([]*models.PaymentReview, error) {
+	query := `
+		SELECT ` + paymentReviewColumns + `
+		FROM payment_reviews
+		WHERE ($1 = '' OR status = $1)
+		ORDER BY created_at ASC
+		LIMIT $2
+	`
+	rows, err := r.db.QueryContext(ctx, query, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reviews []*models.PaymentReview
+	for rows.Next() {
+		pr, err := scanPaymentReview(rows)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, pr)
+	}
+	return reviews, rows.Err()
+}
+
+// Resolve moves a pending review to approved or rejected and records the decision in the audit trail.
+// Only one caller can win the transition; others get ErrReviewNotPending.
+func (r *PaymentReviewRepository) Resolve(ctx context.Context, id int, status, reviewer, notes string) (*models.PaymentReview, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE payment_reviews
+		SET status = $2, reviewed_by = $3, review_notes = $4, reviewed_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = 'pending'
+		RETURNING ` + paymentReviewColumns
+	pr, err := scanPaymentReview(tx.QueryRowContext(ctx, query, id, status, reviewer, notes))
+	if errors.Is(err, sql.ErrNoRows) {
+		if _, getErr := r.GetByID(ctx, id); getErr != nil {
+			return nil, getErr
+		}
+		return nil, ErrReviewNotPending
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := insertReviewEvent(ctx, tx, id, status, reviewer, notes); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return pr, nil
+}
+
+// SetWalletCredit records what happened to the held credit after a decision, with an audit event
+func (r *PaymentReviewRepository) SetWalletCredit(ctx context.Context, id int, walletCredit, action, actor, notes string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE payment_reviews SET wallet_credit = $2, updated_at = NOW() WHERE id = $1`,
+		id, walletCredit,
+	); err != nil {
+		return fmt.Errorf("update wallet credit: %w", err)
+	}
+	if err := insertReviewEvent(ctx, tx, id, action, actor, notes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// AddEvent appends an entry to a review's audit trail
+func (r *PaymentReviewRepository) AddEvent(ctx context.Context, reviewID int, action, actor, notes string) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO payment_review_events (review_id, action, actor, notes) VALUES ($1, $2, $3, $4)`,
+		reviewID, action, actor, notes,
+	)
+	return err
+}
+
+func (r *PaymentReviewRepository) ListEvents(ctx context.Context, reviewID int) ([]*models.PaymentReviewEvent, error) {
+	query := `
+		SELECT id, review_id, action, actor, notes, created_at
+		FROM payment_review_events
+		WHERE review_id = $1
+		ORDER BY created_at ASC, id ASC
+	`
+	rows, err := r.db.QueryContext(ctx, query, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*models.PaymentReviewEvent
+	for rows.Next() {
+		var ev models.PaymentReviewEvent
+		if err := rows.Scan(&ev.ID, &ev.ReviewID, &ev.Action, &ev.Actor, &ev.Notes, &ev.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, &ev)
+	}
+	return events, rows.Err()
+}
+
+func insertReviewEvent(ctx context.Context, tx *sql.Tx, reviewID int, action, actor, notes string) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO payment_review_events (review_id, action, actor, notes) VALUES ($1, $2, $3, $4)`,
+		reviewID, action, actor, notes,
+	)
+	if err != nil {
+		return fmt.Errorf("insert review event: %w", err)
+	}
+	return nil
+}
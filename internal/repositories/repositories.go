@@ -3,19 +3,18 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 
 	"github.com/kodra-pay/checkout-service/internal/models"
 )
 
-type PaymentLinkRepository struct {
-	db *sql.DB
-}
-
-func NewPaymentLinkRepository(dsn string) (*PaymentLinkRepository, error) {
+// OpenDB opens and verifies the Postgres connection pool shared by all repositories
+func OpenDB(dsn string) (*sql.DB, error) {
 	db, err := sql.Open("postgres", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("open db: %w", err)
@@ -26,46 +25,174 @@ func NewPaymentLinkRepository(dsn string) (*PaymentLinkRepository, error) {
 	db.SetMaxOpenConns(10)
 	db.SetMaxIdleConns(5)
 	db.SetConnMaxLifetime(5 * time.Minute)
-	return &PaymentLinkRepository{db: db}, nil
+	return db, nil
+}
+
+type PaymentLinkRepository struct {
+	db *sql.DB
+}
+
+func NewPaymentLinkRepository(db *sql.DB) *PaymentLinkRepository {
+	return &PaymentLinkRepository{db: db}
 }
 
 func (r *PaymentLinkRepository) Create(ctx context.Context, pl *models.PaymentLink) error {
+	return insertPaymentLink(ctx, r.db, pl)
+}
+
+// CreateBatch inserts all links in one transaction. On failure nothing is kept and the
+// index of the link that failed is returned alongside the error.
+func (r *PaymentLinkRepository) CreateBatch(ctx context.Context, links []*models.PaymentLink) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+	for i, pl := range links {
+		if err := insertPaymentLink(ctx, tx, pl); err != nil {
+			return i, err
+		}
+	}
+	return -1, tx.Commit()
+}
+
+func insertPaymentLink(ctx context.Context, q interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}, pl *models.PaymentLink) error {
 	query := `
-		INSERT INTO payment_links (merchant_id, mode, amount, currency, description, status)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO payment_links (merchant_id, mode, amount, currency, description, status, expires_at, max_uses,
+			min_amount, max_amount, suggested_amounts, reference, slug, custom_fields)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
 		RETURNING id, created_at, updated_at
 	`
-	return r.db.QueryRowContext(ctx, query,
-		pl.MerchantID, pl.Mode, pl.Amount, pl.Currency, pl.Description, pl.Status,
+	return q.QueryRowContext(ctx, query,
+		pl.MerchantID, pl.Mode, pl.Amount, pl.Currency, pl.Description, pl.Status, pl.ExpiresAt, pl.MaxUses,
+		pl.MinAmount, pl.MaxAmount, pq.Array(pl.SuggestedAmounts), pl.Reference, pl.Slug,
+		customFieldsJSON(pl.CustomFields),
 	).Scan(&pl.ID, &pl.CreatedAt, &pl.UpdatedAt)
 }
 
-func (r *PaymentLinkRepository) GetByID(ctx context.Context, id int) (*models.PaymentLink, error) {
-	query := `
-		SELECT id, merchant_id, mode, amount, currency, description, status, expires_at, created_at, updated_at
-		FROM payment_links
-		WHERE id = $1
-	`
+// paymentLinkColumns is the column list read by scanPaymentLink
+const paymentLinkColumns = `id, merchant_id, mode, amount, currency, description, reference, slug, status, expires_at,
+	max_uses, use_count, min_amount, max_amount, suggested_amounts, custom_fields,
+	view_count, attempt_count, success_count, fraud_denial_count, collected_amount, created_at, updated_at`
+
+func scanPaymentLink(row interface{ Scan(...any) error }) (*models.PaymentLink, error) {
 	var pl models.PaymentLink
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	var customFields []byte
+	err := row.Scan(
 		&pl.ID, &pl.MerchantID, &pl.Mode, &pl.Amount, &pl.Currency,
-		&pl.Description, &pl.Status, &pl.ExpiresAt, &pl.CreatedAt, &pl.UpdatedAt,
+		&pl.Description, &pl.Reference, &pl.Slug, &pl.Status, &pl.ExpiresAt,
+		&pl.MaxUses, &pl.UseCount, &pl.MinAmount, &pl.MaxAmount, pq.Array(&pl.SuggestedAmounts), &customFields,
+		&pl.ViewCount, &pl.AttemptCount, &pl.SuccessCount, &pl.FraudDenialCount, &pl.CollectedAmount,
+		&pl.CreatedAt, &pl.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
 	}
+	if err := json.Unmarshal(customFields, &pl.CustomFields); err != nil {
+		return nil, fmt.Errorf("decode custom fields of payment link %d: %w", pl.ID, err)
+	}
 	return &pl, nil
 }
 
-func (r *PaymentLinkRepository) ListByMerchant(ctx context.Context, merchantID int, limit int) ([]*models.PaymentLink, error) {
-	query := `
-		SELECT id, merchant_id, mode, amount, currency, description, status, expires_at, created_at, updated_at
+func (r *PaymentLinkRepository) GetByID(ctx context.Context, id int) (*models.PaymentLink, error) {
+	query := `SELECT ` + paymentLinkColumns + `
 		FROM payment_links
-		WHERE merchant_id = $1
-		ORDER BY created_at DESC
-		LIMIT $2
+		WHERE id = $1 AND deleted_at IS NULL
 	`
-	rows, err := r.db.QueryContext(ctx, query, merchantID, limit)
+	return scanPaymentLink(r.db.QueryRowContext(ctx, query, id))
+}
+
+// GetBySlug looks up a link by its vanity slug; slugs are stored lower-case
+func (r *PaymentLinkRepository) GetBySlug(ctx context.Context, slug string) (*models.PaymentLink, error) {
+	query := `SELECT ` + paymentLinkColumns + `
+		FROM payment_links
+		WHERE slug = $1 AND deleted_at IS NULL
+	`
+	return scanPaymentLink(r.db.QueryRowContext(ctx, query, slug))
+}
+
+// customFieldsJSON encodes field definitions for the JSONB column. It is passed as a string
+// because lib/pq sends []byte as bytea.
+func customFieldsJSON(fields []models.CustomField) string {
+	if len(fields) == 0 {
+		return "[]"
+	}
+	raw, _ := json.Marshal(fields)
+	return string(raw)
+}
+
+// PaymentLinkSorts maps each accepted sort key to the expression it orders by and the
+// type its cursor value is cast to
+var PaymentLinkSorts = map[string]struct{ Expr, Cast string }{
+	"created_at":       {"created_at", "timestamptz"},
+	"updated_at":       {"updated_at", "timestamptz"},
+	"amount":           {"COALESCE(amount, 0)", "bigint"},
+	"collected_amount": {"collected_amount", "numeric"},
+}
+
+// PaymentLinkFilter narrows List and Count for one merchant; zero values match everything
+type PaymentLinkFilter struct {
+	MerchantID  int
+	Status      string
+	Mode        string
+	Currency    string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time // exclusive
+	Search      string     // case-insensitive substring of description or reference
+	Sort        string     // a PaymentLinkSorts key
+	Desc        bool
+	After       *PaymentLinkCursor // resume after this row; ignored by Count
+	Limit       int
+}
+
+// PaymentLinkCursor is the sort value and id of the last row of a page
+type PaymentLinkCursor struct {
+	Value string
+	ID    int
+}
+
+// where builds the shared filter clause; args continue from $1
+func (f PaymentLinkFilter) where() (string, []interface{}) {
+	search := ""
+	if f.Search != "" {
+		search = "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(f.Search) + "%"
+	}
+	clause := `merchant_id = $1 AND deleted_at IS NULL
+		AND ($2 = '' OR status = $2)
+		AND ($3 = '' OR mode = $3)
+		AND ($4 = '' OR currency = $4)
+		AND ($5::timestamptz IS NULL OR created_at >= $5)
+		AND ($6::timestamptz IS NULL OR created_at < $6)
+		AND ($7 = '' OR description ILIKE $7 OR reference ILIKE $7)`
+	return clause, []interface{}{f.MerchantID, f.Status, f.Mode, f.Currency, f.CreatedFrom, f.CreatedTo, search}
+}
+
+// List returns one page of a merchant's links in the filter's sort order, using keyset pagination
+func (r *PaymentLinkRepository) List(ctx context.Context, f PaymentLinkFilter) ([]*models.PaymentLink, error) {
+	sort, ok := PaymentLinkSorts[f.Sort]
+	if !ok {
+		return nil, fmt.Errorf("unknown payment link sort %q", f.Sort)
+	}
+	dir, cmp := "ASC", ">"
+	if f.Desc {
+		dir, cmp = "DESC", "<"
+	}
+
+	where, args := f.where()
+	if f.After != nil {
+		where += fmt.Sprintf(" AND (%s, id) %s ($%d::%s, $%d)", sort.Expr, cmp, len(args)+1, sort.Cast, len(args)+2)
+		args = append(args, f.After.Value, f.After.ID)
+	}
+	query := `SELECT ` + paymentLinkColumns + `
+		FROM payment_links
+		WHERE ` + where + `
+		ORDER BY ` + sort.Expr + ` ` + dir + `, id ` + dir + fmt.Sprintf(`
+		LIMIT $%d`, len(args)+1)
+	args = append(args, f.Limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -73,14 +200,132 @@ func (r *PaymentLinkRepository) ListByMerchant(ctx context.Context, merchantID i
 
 	var links []*models.PaymentLink
 	for rows.Next() {
-		var pl models.PaymentLink
-		if err := rows.Scan(
-			&pl.ID, &pl.MerchantID, &pl.Mode, &pl.Amount, &pl.Currency,
-			&pl.Description, &pl.Status, &pl.ExpiresAt, &pl.CreatedAt, &pl.UpdatedAt,
-		); err != nil {
+		pl, err := scanPaymentLink(rows)
+		if err != nil {
 			return nil, err
 		}
-		links = append(links, &pl)
+		links = append(links, pl)
 	}
 	return links, rows.Err()
 }
+
+// Count returns how many links match the filter across all pages
+func (r *PaymentLinkRepository) Count(ctx context.Context, f PaymentLinkFilter) (int, error) {
+	where, args := f.where()
+	var n int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM payment_links WHERE `+where, args...).Scan(&n)
+	return n, err
+}
+
+// Update saves a link's editable fields: description, amount, expiry, slug, max uses and amount bounds
+func (r *PaymentLinkRepository) Update(ctx context.Context, pl *models.PaymentLink) error {
+	query := `
+		UPDATE payment_links
+		SET description = $2, amount = $3, expires_at = $4, max_uses = $5,
+			min_amount = $6, max_amount = $7, suggested_amounts = $8, slug = $9, custom_fields = $10,
+			updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING updated_at
+	`
+	return r.db.QueryRowContext(ctx, query,
+		pl.ID, pl.Description, pl.Amount, pl.ExpiresAt, pl.MaxUses,
+		pl.MinAmount, pl.MaxAmount, pq.Array(pl.SuggestedAmounts), pl.Slug,
+		customFieldsJSON(pl.CustomFields),
+	).Scan(&pl.UpdatedAt)
+}
+
+func (r *PaymentLinkRepository) SetStatus(ctx context.Context, id int, status string) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE payment_links SET status = $2, updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL`,
+		id, status,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// BacksInvoice reports whether the link was issued for an invoice
+func (r *PaymentLinkRepository) BacksInvoice(ctx context.Context, id int) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM invoices WHERE payment_link_id = $1)`, id).Scan(&exists)
+	return exists, err
+}
+
+// SoftDelete hides a link from lookups and deactivates it; the row is kept for transaction history
+func (r *PaymentLinkRepository) SoftDelete(ctx context.Context, id int) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE payment_links SET status = 'inactive', deleted_at = NOW(), updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL`,
+		id,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ExpireDue flips active links whose expiry has passed to expired and returns how many changed
+func (r *PaymentLinkRepository) ExpireDue(ctx context.Context) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE payment_links
+		SET status = 'expired', updated_at = NOW()
+		WHERE status = 'active' AND expires_at IS NOT NULL AND expires_at <= NOW() AND deleted_at IS NULL
+	`)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// ReserveUse atomically claims one use of an active link, returning sql.ErrNoRows when the
+// link is not active or every use is already taken. The claim counts toward use_count until
+// it is released, so concurrent payers cannot both take the last use.
+func (r *PaymentLinkRepository) ReserveUse(ctx context.Context, id int) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE payment_links
+		SET use_count = use_count + 1, updated_at = NOW()
+		WHERE id = $1 AND status = 'active' AND deleted_at IS NULL
+			AND (max_uses IS NULL OR use_count < max_uses)
+	`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ReleaseUse gives back a use claimed by ReserveUse when the payment did not go through, or was
+// rejected later. A link completed by CompleteIfExhausted is reopened unless it has expired.
+func (r *PaymentLinkRepository) ReleaseUse(ctx context.Context, id int) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE payment_links
+		SET use_count = use_count - 1,
+			status = CASE WHEN status = 'completed' AND (expires_at IS NULL OR expires_at > NOW()) THEN 'active' ELSE status END,
+			updated_at = NOW()
+		WHERE id = $1 AND use_count > 0
+	`, id)
+	return err
+}
+
+// CompleteIfExhausted marks an active link completed once all of its uses are taken.
+// It reports whether the link was completed by this call.
+func (r *PaymentLinkRepository) CompleteIfExhausted(ctx context.Context, id int) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE payment_links
+		SET status = 'completed', updated_at = NOW()
+		WHERE id = $1 AND status = 'active' AND max_uses IS NOT NULL AND use_count >= max_uses
+	`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
@@ -0,0 +1,120 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// sweepEvery is how many Add calls pass between sweeps of idle keys in the memory store
+const sweepEvery = 1000
+
+type velocityEvent struct {
+	at     time.Time
+	amount float64
+}
+
+type velocityWindow struct {
+	events []velocityEvent
+	window time.Duration
+}
+
+// MemoryVelocityStore keeps sliding-window payment counters in process memory.
+// Counters are per instance, so limits are only exact for a single replica.
+type MemoryVelocityStore struct {
+	mu   sync.Mutex
+	keys map[string]*velocityWindow
+	adds int
+}
+
+func NewMemoryVelocityStore() *MemoryVelocityStore {
+	return &MemoryVelocityStore{keys: make(map[string]*velocityWindow)}
+}
+
+// Add records a payment under key and returns the number and total amount of payments within window, including this one
+func (s *MemoryVelocityStore) Add(_ context.Context, key string, at time.Time, amount float64, window time.Duration) (int, float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, ok := s.keys[key]
+	if !ok {
+		w = &velocityWindow{}
+		s.keys[key] = w
+	}
+	w.window = window
+
+	cutoff := at.Add(-window)
+	kept := w.events[:0]
+	for _, ev := range w.events {
+		if ev.at.After(cutoff) {
+			kept = append(kept, ev)
+		}
+	}
+	w.events = append(kept, velocityEvent{at: at, amount: amount})
+
+	var total float64
+	for _, ev := range w.events {
+		total += ev.amount
+	}
+
+	s.adds++
+	if s.adds%sweepEvery == 0 {
+		s.sweep(at)
+	}
+	return len(w.events), total, nil
+}
+
+// sweep drops keys with no events inside their window so idle customers don't accumulate
+func (s *MemoryVelocityStore) sweep(now time.Time) {
+	for key, w := range s.keys {
+		if len(w.events) == 0 || !w.events[len(w.events)-1].at.After(now.Add(-w.window)) {
+			delete(s.keys, key)
+		}
+	}
+}
+
+// RedisVelocityStore keeps sliding-window counters in Redis sorted sets so limits hold across replicas
+type RedisVelocityStore struct {
+	client *redis.Client
+}
+
+func NewRedisVelocityStore(addr string) (*RedisVelocityStore, error) {
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("ping redis: %w", err)
+	}
+	return &RedisVelocityStore{client: client}, nil
+}
+
+// Add records a payment under key and returns the number and total amount of payments within window, including this one.
+// Each event is a sorted-set member scored by its time in milliseconds, with the amount encoded in the member.
+func (s *RedisVelocityStore) Add(ctx context.Context, key string, at time.Time, amount float64, window time.Duration) (int, float64, error) {
+	redisKey := "checkout:velocity:" + key
+	member := fmt.Sprintf("%s|%s", strconv.FormatFloat(amount, 'f', -1, 64), uuid.New().String())
+
+	pipe := s.client.TxPipeline()
+	pipe.ZRemRangeByScore(ctx, redisKey, "-inf", strconv.FormatInt(at.Add(-window).UnixMilli(), 10))
+	pipe.ZAdd(ctx, redisKey, redis.Z{Score: float64(at.UnixMilli()), Member: member})
+	members := pipe.ZRange(ctx, redisKey, 0, -1)
+	pipe.PExpire(ctx, redisKey, window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, 0, fmt.Errorf("update velocity window: %w", err)
+	}
+
+	var total float64
+	for _, m := range members.Val() {
+		amountStr, _, _ := strings.Cut(m, "|")
+		if v, err := strconv.ParseFloat(amountStr, 64); err == nil {
+			total += v
+		}
+	}
+	return len(members.Val()), total, nil
+}
@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryVelocityStoreSlidingWindow(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		offsets   []time.Duration // when each payment is added, relative to base
+		amounts   []float64
+		window    time.Duration
+		wantCount int
+		wantTotal float64
+	}{
+		{
+			name:      "all inside window",
+			offsets:   []time.Duration{0, 10 * time.Second, 20 * time.Second},
+			amounts:   []float64{100, 200, 300},
+			window:    time.Minute,
+			wantCount: 3,
+			wantTotal: 600,
+		},
+		{
+			name:      "older payments slide out",
+			offsets:   []time.Duration{0, 40 * time.Second, 90 * time.Second},
+			amounts:   []float64{100, 200, 300},
+			window:    time.Minute,
+			wantCount: 2,
+			wantTotal: 500,
+		},
+		{
+			name:      "payment exactly one window old is excluded",
+			offsets:   []time.Duration{0, time.Minute},
+			amounts:   []float64{100, 200},
+			window:    time.Minute,
+			wantCount: 1,
+			wantTotal: 200,
+		},
+		{
+			name:      "long gap resets the window",
+			offsets:   []time.Duration{0, time.Second, 2 * time.Hour},
+			amounts:   []float64{100, 100, 50},
+			window:    time.Hour,
+			wantCount: 1,
+			wantTotal: 50,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMemoryVelocityStore()
+			var count int
+			var total float64
+			var err error
+			for i, off := range tt.offsets {
+				count, total, err = s.Add(context.Background(), "k", base.Add(off), tt.amounts[i], tt.window)
+				if err != nil {
+					t.Fatalf("Add: %v", err)
+				}
+			}
+			if count != tt.wantCount || total != tt.wantTotal {
+				t.Errorf("got count %d total %.2f, want %d %.2f", count, total, tt.wantCount, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestMemoryVelocityStoreKeysAreIndependent(t *testing.T) {
+	s := NewMemoryVelocityStore()
+	now := time.Now()
+	s.Add(context.Background(), "a", now, 10, time.Minute)
+	s.Add(context.Background(), "a", now, 10, time.Minute)
+	count, total, _ := s.Add(context.Background(), "b", now, 5, time.Minute)
+	if count != 1 || total != 5 {
+		t.Errorf("key b: got count %d total %.2f, want 1 5.00", count, total)
+	}
+}
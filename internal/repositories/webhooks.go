@@ -0,0 +1,188 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/kodra-pay/checkout-service/internal/models"
+)
+
+type WebhookRepository struct {
+	db *sql.DB
+}
+
+func NewWebhookRepository(db *sql.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+const webhookEndpointColumns = `id, merchant_id, url, description, events, secret_prefix, secret_ciphertext, status,
+	created_at, updated_at`
+
+func scanWebhookEndpoint(row interface{ Scan(...any) error }) (*models.WebhookEndpoint, error) {
+	var e models.WebhookEndpoint
+	err := row.Scan(
+		&e.ID, &e.MerchantID, &e.URL, &e.Description, pq.Array(&e.Events), &e.SecretPrefix, &e.SecretCipher, &e.Status,
+		&e.CreatedAt, &e.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+const webhookDeliveryColumns = `id, endpoint_id, merchant_id, event_id, event_type, payload, status, attempts,
+	next_attempt_at, last_status_code, last_error, delivered_at, created_at, updated_at`
+
+func scanWebhookDelivery(row interface{ Scan(...any) error }) (*models.WebhookDelivery, error) {
+	var d models.WebhookDelivery
+	err := row.Scan(
+		&d.ID, &d.EndpointID, &d.MerchantID, &d.EventID, &d.EventType, &d.Payload, &d.Status, &d.Attempts,
+		&d.NextAttemptAt, &d.LastStatusCode, &d.LastError, &d.DeliveredAt, &d.CreatedAt, &d.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (r *WebhookRepository) CreateEndpoint(ctx context.Context, e *models.WebhookEndpoint) error {
+	query := `
+		INSERT INTO webhook_endpoints (merchant_id, url, description, events, secret_prefix, secret_ciphertext, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at, updated_at
+	`
+	return r.db.QueryRowContext(ctx, query,
+		e.MerchantID, e.URL, e.Description, pq.Array(e.Events), e.SecretPrefix, e.SecretCipher, e.Status,
+	).Scan(&e.ID, &e.CreatedAt, &e.UpdatedAt)
+}
+
+// GetEndpoint returns an endpoint by id regardless of merchant, as named by a queued delivery
+func (r *WebhookRepository) GetEndpoint(ctx context.Context, id int) (*models.WebhookEndpoint, error) {
+	return scanWebhookEndpoint(r.db.QueryRowContext(ctx, `SELECT `+webhookEndpointColumns+` FROM webhook_endpoints WHERE id = $1`, id))
+}
+
+func (r *WebhookRepository) ListEndpoints(ctx context.Context, merchantID int) ([]*models.WebhookEndpoint, error) {
+	return r.listEndpoints(ctx, `SELECT `+webhookEndpointColumns+` FROM webhook_endpoints
+		WHERE merchant_id = $1 ORDER BY created_at DESC, id DESC`, merchantID)
+}
+
+// ListSubscribed returns a merchant's active endpoints that subscribe to eventType
+func (r *WebhookRepository) ListSubscribed(ctx context.Context, merchantID int, eventType string) ([]*models.WebhookEndpoint, error) {
+	return r.listEndpoints(ctx, `SELECT `+webhookEndpointColumns+` FROM webhook_endpoints
+		WHERE merchant_id = $1 AND status = 'active' AND (events IS NULL OR $2 = ANY(events))
+		ORDER BY id`, merchantID, eventType)
+}
+
+func (r *WebhookRepository) listEndpoints(ctx context.Context, query string, args ...any) ([]*models.WebhookEndpoint, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var endpoints []*models.WebhookEndpoint
+	for rows.Next() {
+		e, err := scanWebhookEndpoint(rows)
+		if err != nil {
+			return nil, err
+		}
+		endpoints = append(endpoints, e)
+	}
+	return endpoints, rows.Err()
+}
+
+// DisableEndpoint stops deliveries to an endpoint. It returns sql.ErrNoRows when the merchant
+// has no such active endpoint.
+func (r *WebhookRepository) DisableEndpoint(ctx context.Context, merchantID, id int) (*models.WebhookEndpoint, error) {
+	return scanWebhookEndpoint(r.db.QueryRowContext(ctx, `
+		UPDATE webhook_endpoints SET status = 'disabled', updated_at = NOW()
+		WHERE id = $1 AND merchant_id = $2 AND status = 'active'
+		RETURNING `+webhookEndpointColumns, id, merchantID))
+}
+
+// Enqueue queues an event for an endpoint; queuing the same event twice is a no-op
+func (r *WebhookRepository) Enqueue(ctx context.Context, d *models.WebhookDelivery) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO webhook_deliveries (endpoint_id, merchant_id, event_id, event_type, payload)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (endpoint_id, event_id) DO NOTHING
+	`, d.EndpointID, d.MerchantID, d.EventID, d.EventType, string(d.Payload))
+	return err
+}
+
+// ClaimDue takes up to limit pending deliveries whose next attempt is due, pushing their next
+// attempt out by lease so no other worker picks them up meanwhile. A delivery whose worker dies
+// before recording the attempt becomes due again once the lease runs out.
+func (r *WebhookRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*models.WebhookDelivery, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		UPDATE webhook_deliveries
+		SET next_attempt_at = NOW() + make_interval(secs => $2), updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+webhookDeliveryColumns, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanWebhookDeliveries(rows)
+}
+
+// RecordAttempt stores the outcome of a delivery attempt. status is delivered, dead, or pending
+// with nextAttemptAt set to the retry time; statusCode is nil when no response was received.
+func (r *WebhookRepository) RecordAttempt(ctx context.Context, id int, status string, statusCode *int, lastError string, nextAttemptAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = attempts + 1, last_status_code = $3, last_error = $4, next_attempt_at = $5,
+			delivered_at = CASE WHEN $2 = 'delivered' THEN NOW() ELSE delivered_at END, updated_at = NOW()
+		WHERE id = $1
+	`, id, status, statusCode, lastError, nextAttemptAt)
+	return err
+}
+
+// ListDeliveries returns a merchant's deliveries, newest first, optionally narrowed to one
+// endpoint (0 for all) and one status
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, merchantID, endpointID int, status string, limit int) ([]*models.WebhookDelivery, error) {
+	query := `
+		SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries
+		WHERE merchant_id = $1 AND ($2 = 0 OR endpoint_id = $2) AND ($3 = '' OR status = $3)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $4
+	`
+	rows, err := r.db.QueryContext(ctx, query, merchantID, endpointID, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanWebhookDeliveries(rows)
+}
+
+// Requeue puts a dead delivery back in the queue with a fresh set of attempts. It returns
+// sql.ErrNoRows when the merchant has no such dead delivery.
+func (r *WebhookRepository) Requeue(ctx context.Context, merchantID, id int) (*models.WebhookDelivery, error) {
+	return scanWebhookDelivery(r.db.QueryRowContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = 'pending', attempts = 0, next_attempt_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND merchant_id = $2 AND status = 'dead'
+		RETURNING `+webhookDeliveryColumns, id, merchantID))
+}
+
+func scanWebhookDeliveries(rows *sql.Rows) ([]*models.WebhookDelivery, error) {
+	var deliveries []*models.WebhookDelivery
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
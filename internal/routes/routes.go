@@ -1,13 +1,18 @@
 package routes
 
 import (
+	"context"
+	"fmt"
 	"log"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
 
 	"github.com/kodra-pay/checkout-service/internal/clients"
 	"github.com/kodra-pay/checkout-service/internal/config"
 	"github.com/kodra-pay/checkout-service/internal/handlers"
+	"github.com/kodra-pay/checkout-service/internal/middleware"
+	"github.com/kodra-pay/checkout-service/internal/models"
 	"github.com/kodra-pay/checkout-service/internal/repositories"
 	"github.com/kodra-pay/checkout-service/internal/services"
 )
@@ -19,24 +24,194 @@ func Register(app *fiber.App, serviceName string, txClient clients.TransactionCl
 	// Note: PaymentLinkRepository and PaymentLinkService setup might need a dedicated DB connection or be refactored
 	// to use clients if they interact with other services. For now, assuming they use local DB.
 	cfg := config.Load(serviceName, "7005") // Still needed for PaymentLinkRepository's DSN
-	repo, err := repositories.NewPaymentLinkRepository(cfg.PostgresDSN)
+	db, err := repositories.OpenDB(cfg.PostgresDSN)
 	if err != nil {
-		log.Fatalf("Failed to initialize PaymentLinkRepository: %v", err) // Use log.Fatalf instead of panic
+		log.Fatalf("Failed to connect to database: %v", err) // Use log.Fatalf instead of panic
 	}
-	plSvc := services.NewPaymentLinkService(repo)
+	repo := repositories.NewPaymentLinkRepository(db)
+	reviewRepo := repositories.NewPaymentReviewRepository(db)
+	fraudPolicyRepo := repositories.NewFraudPolicyRepository(db)
+	unscreenedRepo := repositories.NewUnscreenedPaymentRepository(db)
+	checkoutRepo := repositories.NewCheckoutRepository(db)
+	limitRepo := repositories.NewMerchantLimitRepository(db)
+	listRepo := repositories.NewListEntryRepository(db)
+	countryRuleRepo := repositories.NewCountryRuleRepository(db)
+	secretBox, err := services.NewSecretBox(cfg.APIKeyEncryptionKey)
+	if err != nil {
+		log.Fatalf("Invalid API key encryption key: %v", err)
+	}
+	var nonceStore services.NonceStore = repositories.NewMemoryNonceStore()
+	if cfg.NonceStore == "redis" {
+		nonceStore, err = repositories.NewRedisNonceStore(cfg.RedisAddr)
+		if err != nil {
+			log.Fatalf("Failed to initialize Redis nonce store: %v", err)
+		}
+	}
+	apiKeySvc := services.NewAPIKeyService(repositories.NewAPIKeyRepository(db), secretBox, nonceStore, cfg.SignatureMaxSkew)
+	plSvc := services.NewPaymentLinkService(repo, cfg.CheckoutBaseURL, cfg.PaymentLinkBulkLimit)
+	go plSvc.RunExpiry(context.Background(), cfg.PaymentLinkExpiryInterval)
 	plHandler := handlers.NewPaymentLinkHandler(plSvc)
 
 	// Initialize FraudClient
 	fraudClient := clients.NewHTTPFraudClient(cfg.FraudServiceURL, cfg.FraudServiceAPIKey)
+	localFraudRules := services.LocalFraudRules{
+		FlagAmount: cfg.FraudLocalFlagAmount,
+		DenyAmount: cfg.FraudLocalDenyAmount,
+	}
+	if err := services.ValidateFraudThresholds(cfg.FraudFailOpenMaxAmount, localFraudRules); err != nil {
+		log.Fatalf("Invalid fraud thresholds: %v", err)
+	}
+	fraudScreener := services.NewFraudScreener(fraudClient, fraudPolicyRepo, cfg.FraudFailPolicy, cfg.FraudFailOpenMaxAmount, localFraudRules)
+	fraudPolicyHandler := handlers.NewFraudPolicyHandler(fraudScreener)
+
+	// Re-screen payments accepted while fraud-service was unavailable
+	rescreener := services.NewRescreener(unscreenedRepo, fraudClient, reviewRepo)
+	go rescreener.Run(context.Background(), cfg.FraudRescreenInterval)
+
+	velocityRules, err := services.ParseVelocityRules(cfg.VelocityRules)
+	if err != nil {
+		log.Fatalf("Invalid velocity rules: %v", err)
+	}
+	var velocityStore services.VelocityStore = repositories.NewMemoryVelocityStore()
+	if cfg.VelocityStore == "redis" {
+		velocityStore, err = repositories.NewRedisVelocityStore(cfg.RedisAddr)
+		if err != nil {
+			log.Fatalf("Failed to initialize Redis velocity store: %v", err)
+		}
+	}
+	velocityChecker := services.NewVelocityChecker(velocityStore, velocityRules)
+
+	limitSvc := services.NewMerchantLimitService(limitRepo, models.MerchantLimits{
+		MinAmount:     cfg.MerchantDefaultMinAmount,
+		MaxAmount:     cfg.MerchantDefaultMaxAmount,
+		DailyVolume:   cfg.MerchantDefaultDailyVolume,
+		MonthlyVolume: cfg.MerchantDefaultMonthlyVolume,
+	})
+	limitHandler := handlers.NewMerchantLimitHandler(limitSvc)
+
+	listSvc := services.NewListService(listRepo)
+	listHandler := handlers.NewListEntryHandler(listSvc)
+
+	geoResolver, err := repositories.NewGeoIPResolver(cfg.GeoIPDatabasePath)
+	if err != nil {
+		log.Fatalf("Failed to load GeoIP database: %v", err)
+	}
+	go geoResolver.Watch(context.Background(), cfg.GeoIPReloadInterval)
+	geoSvc := services.NewGeoService(geoResolver, countryRuleRepo)
+	geoHandler := handlers.NewGeoHandler(geoSvc)
 
-	checkoutSvc := services.NewCheckoutService(txClient, wlClient, feeClient, fraudClient, repo) // Pass the clients, fraud client and payment link repo here
+	invoiceRepo := repositories.NewInvoiceRepository(db)
+	invoiceSvc := services.NewInvoiceService(invoiceRepo, cfg.CheckoutBaseURL)
+	go invoiceSvc.RunOverdue(context.Background(), cfg.InvoiceOverdueInterval)
+	invoiceHandler := handlers.NewInvoiceHandler(invoiceSvc)
+
+	// Merchant webhooks, delivered from the webhook_deliveries queue
+	webhookSvc := services.NewWebhookService(repositories.NewWebhookRepository(db), secretBox, cfg.WebhookTimeout, services.WebhookRetryPolicy{
+		MaxAttempts:    cfg.WebhookMaxAttempts,
+		InitialBackoff: cfg.WebhookInitialBackoff,
+		MaxBackoff:     cfg.WebhookMaxBackoff,
+	})
+	go webhookSvc.RunDelivery(context.Background(), cfg.WebhookDeliveryInterval)
+	webhookHandler := handlers.NewWebhookHandler(webhookSvc)
+
+	checkoutSvc := services.NewCheckoutService(txClient, wlClient, feeClient, fraudScreener, velocityChecker, repo, reviewRepo, unscreenedRepo, checkoutRepo, limitSvc, listSvc, geoSvc, invoiceSvc, webhookSvc, cfg.CheckoutSessionTTL) // Pass the clients, fraud screener and payment link repo here
+	go checkoutSvc.RunSessionExpiry(context.Background(), cfg.SessionExpiryInterval)
 	checkoutHandler := handlers.NewCheckoutHandler(checkoutSvc)
 
-	app.Post("/payment-links", plHandler.Create)
-	app.Get("/payment-links", plHandler.List)
-	app.Get("/payment-links/:id", plHandler.Get)
+	brandingSvc := services.NewBrandingService(repositories.NewBrandingRepository(db))
+	brandingHandler := handlers.NewBrandingHandler(brandingSvc)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeySvc)
+
+	documentHandler := handlers.NewDocumentHandler(services.NewDocumentService(checkoutRepo, repo, invoiceRepo, brandingSvc, cfg.CheckoutBaseURL))
+	qrHandler := handlers.NewQRHandler(services.NewQRService(repo, checkoutRepo, brandingSvc, cfg.CheckoutBaseURL))
+
+	reviewSvc := services.NewReviewService(reviewRepo, txClient, wlClient, checkoutRepo, repo, invoiceSvc, webhookSvc)
+	reviewHandler := handlers.NewReviewHandler(reviewSvc)
+
+	// Merchant endpoints take an API key with the route's scope and act on the key's merchant
+	// only. Publishable keys can only create and pay sessions. Hosted-page reads of links and
+	// sessions stay public, as does paying a session or link; anonymous link reads get the
+	// public view, without the merchant's reference or stats. Receipts carry the customer's
+	// details, so only the owning merchant can download them.
+	auth := middleware.NewMerchantAuth(apiKeySvc)
+	adminAuth := middleware.RequireAdminToken(cfg.AdminAPIToken)
+	// Unverified merchants can't create links or take payments
+	requireKYC := middleware.NewKYCCheckMiddleware(cfg.MerchantServiceURL, cfg.KYCTimeout, cfg.KYCCacheTTL, cfg.KYCStaleTTL, cfg.KYCFailOpen).RequireApprovedKYC
+	if cfg.AdminAPIToken == "" {
+		fmt.Printf("Warning: ADMIN_API_TOKEN is not set; /admin and /reviews endpoints are disabled\n")
+	}
+
+	app.Get("/api-keys", auth.Require(models.ScopeKeysRead), apiKeyHandler.List)
+	app.Post("/api-keys", auth.Require(models.ScopeKeysWrite), apiKeyHandler.Create)
+	app.Post("/api-keys/:id/rotate", auth.Require(models.ScopeKeysWrite), apiKeyHandler.Rotate)
+	app.Delete("/api-keys/:id", auth.Require(models.ScopeKeysWrite), apiKeyHandler.Revoke)
+
+	app.Get("/webhooks/endpoints", auth.Require(models.ScopeWebhooksRead), webhookHandler.ListEndpoints)
+	app.Post("/webhooks/endpoints", auth.Require(models.ScopeWebhooksWrite), webhookHandler.CreateEndpoint)
+	app.Delete("/webhooks/endpoints/:id", auth.Require(models.ScopeWebhooksWrite), webhookHandler.DisableEndpoint)
+	app.Get("/webhooks/deliveries", auth.Require(models.ScopeWebhooksRead), webhookHandler.ListDeliveries)
+	app.Post("/webhooks/deliveries/:id/retry", auth.Require(models.ScopeWebhooksWrite), webhookHandler.RetryDelivery)
 
-	app.Post("/checkout/session", checkoutHandler.CreateSession)
+	app.Post("/payment-links", auth.Require(models.ScopeLinksWrite), requireKYC, plHandler.Create)
+	app.Get("/payment-links", auth.Require(models.ScopeLinksRead), plHandler.List)
+	app.Post("/payment-links/bulk", auth.Require(models.ScopeLinksWrite), requireKYC, plHandler.BulkCreate)
+	app.Get("/payment-links/by-slug/:slug", plHandler.GetBySlug)
+	app.Get("/payment-links/:id", auth.Optional(models.ScopeLinksRead), plHandler.Get)
+	app.Patch("/payment-links/:id", auth.Require(models.ScopeLinksWrite), plHandler.Update)
+	app.Delete("/payment-links/:id", auth.Require(models.ScopeLinksWrite), plHandler.Delete)
+	app.Post("/payment-links/:id/activate", auth.Require(models.ScopeLinksWrite), plHandler.Activate)
+	app.Post("/payment-links/:id/deactivate", auth.Require(models.ScopeLinksWrite), plHandler.Deactivate)
+	app.Get("/payment-links/:id/qr", qrHandler.PaymentLink)
+	app.Post("/payment-links/:id/views", plHandler.RecordView)
+	app.Get("/payment-links/:id/stats", auth.Require(models.ScopeLinksRead), plHandler.Stats)
+
+	// Invoices are paid through a single-use payment link created on finalize
+	app.Post("/invoices", auth.Require(models.ScopeInvoicesWrite), invoiceHandler.Create)
+	app.Get("/invoices", auth.Require(models.ScopeInvoicesRead), invoiceHandler.List)
+	app.Get("/invoices/:id", auth.Require(models.ScopeInvoicesRead), invoiceHandler.Get)
+	app.Get("/invoices/:id/pdf", auth.Require(models.ScopeInvoicesRead), documentHandler.Invoice)
+	app.Post("/invoices/:id/finalize", auth.Require(models.ScopeInvoicesWrite), requireKYC, invoiceHandler.Finalize)
+	app.Post("/invoices/:id/void", auth.Require(models.ScopeInvoicesWrite), invoiceHandler.Void)
+
+	// Browser checkout calls these cross-origin with a publishable key; the key's allowed
+	// origins are enforced by auth, so CORS itself admits any origin
+	app.Use("/checkout", cors.New(cors.Config{
+		AllowOrigins: "*",
+		AllowMethods: "GET,POST",
+		AllowHeaders: "Authorization, Content-Type",
+	}))
+	app.Post("/checkout/session", auth.Require(models.ScopeSessionsWrite), checkoutHandler.CreateSession)
 	app.Get("/checkout/session/:id", checkoutHandler.GetSession)
-	app.Post("/checkout/pay", checkoutHandler.Pay)
+	app.Get("/checkout/session/:id/qr", qrHandler.Session)
+	app.Post("/checkout/pay", auth.Optional(models.ScopePaymentsWrite), checkoutHandler.ResolveMerchant, requireKYC, checkoutHandler.Pay)
+	app.Get("/checkout/payments/:reference", auth.Require(models.ScopePaymentsRead), checkoutHandler.GetPayment)
+	app.Get("/checkout/payments/:reference/receipt.pdf", auth.Require(models.ScopePaymentsRead), documentHandler.Receipt)
+
+	// Manual review queue for fraud-flagged payments
+	app.Get("/reviews", adminAuth, reviewHandler.List)
+	app.Get("/reviews/:id", adminAuth, reviewHandler.Get)
+	app.Post("/reviews/:id/approve", adminAuth, reviewHandler.Approve)
+	app.Post("/reviews/:id/reject", adminAuth, reviewHandler.Reject)
+
+	app.Get("/admin/merchants/:id/fraud-policy", adminAuth, fraudPolicyHandler.Get)
+	app.Put("/admin/merchants/:id/fraud-policy", adminAuth, fraudPolicyHandler.Put)
+	app.Get("/admin/merchants/:id/limits", adminAuth, limitHandler.Get)
+	app.Put("/admin/merchants/:id/limits", adminAuth, limitHandler.Put)
+	app.Get("/admin/merchants/:id/branding", adminAuth, brandingHandler.Get)
+	app.Put("/admin/merchants/:id/branding", adminAuth, brandingHandler.Put)
+
+	// Block and allow lists for emails, IPs, customers and card BINs
+	app.Post("/admin/lists", adminAuth, listHandler.Create)
+	app.Get("/admin/lists", adminAuth, listHandler.List)
+	app.Get("/admin/lists/:id", adminAuth, listHandler.Get)
+	app.Patch("/admin/lists/:id", adminAuth, listHandler.Update)
+	app.Delete("/admin/lists/:id", adminAuth, listHandler.Delete)
+
+	app.Get("/admin/merchants/:id/countries", adminAuth, geoHandler.GetRules)
+	app.Put("/admin/merchants/:id/countries", adminAuth, geoHandler.PutRules)
+	app.Post("/admin/geoip/reload", adminAuth, geoHandler.Reload)
+
+	// Issues a merchant's first key; later keys can be managed with /api-keys
+	app.Get("/admin/merchants/:id/api-keys", adminAuth, apiKeyHandler.AdminList)
+	app.Post("/admin/merchants/:id/api-keys", adminAuth, apiKeyHandler.AdminCreate)
 }
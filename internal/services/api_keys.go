@@ -0,0 +1,347 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/kodra-pay/checkout-service/internal/dto"
+	"github.com/kodra-pay/checkout-service/internal/models"
+)
+
+var (
+	ErrInvalidAPIKey    = errors.New("invalid, revoked or expired API key")
+	ErrAPIKeyNotFound   = errors.New("API key not found")
+	ErrInvalidGrace     = errors.New("grace_period_seconds must be between 0 and 604800 (7 days)")
+	ErrAPIKeyNotRotated = errors.New("only active keys can be rotated")
+	ErrInvalidAPIKeyReq = errors.New("invalid API key request")
+	ErrScopeEscalation  = errors.New("a key can only issue or revoke keys with scopes it has itself")
+)
+
+const (
+	secretKeyPrefix       = "kp_sk_"
+	publishableKeyPrefix  = "kp_pk_"
+	apiKeyDisplayLength   = len(secretKeyPrefix) + 6
+	apiKeyDefaultGrace    = 24 * time.Hour
+	apiKeyMaxGrace        = 7 * 24 * time.Hour
+	apiKeyRandomByteCount = 24
+)
+
+// APIKeyStore persists API keys, which are only ever stored by hash
+type APIKeyStore interface {
+	Create(ctx context.Context, k *models.APIKey) error
+	GetByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	Lookup(ctx context.Context, id int) (*models.APIKey, error)
+	GetByID(ctx context.Context, merchantID, id int) (*models.APIKey, error)
+	ListByMerchant(ctx context.Context, merchantID int) ([]*models.APIKey, error)
+	Revoke(ctx context.Context, merchantID, id int) (*models.APIKey, error)
+	Rotate(ctx context.Context, old *models.APIKey, replacement *models.APIKey, retireAt time.Time) error
+	TouchLastUsed(ctx context.Context, id int) error
+}
+
+// NonceStore remembers signed-request nonces so each can only be used once
+type NonceStore interface {
+	Remember(ctx context.Context, nonce string, ttl time.Duration) (fresh bool, err error)
+}
+
+// APIKeyService issues, rotates, revokes and verifies merchant API keys and signed requests
+type APIKeyService struct {
+	repo    APIKeyStore
+	box     *SecretBox    // encrypts signing secrets of hmac keys
+	nonces  NonceStore    // replay protection for signed requests
+	maxSkew time.Duration // how far a signed request's timestamp may be from now
+}
+
+func NewAPIKeyService(repo APIKeyStore, box *SecretBox, nonces NonceStore, maxSkew time.Duration) *APIKeyService {
+	return &APIKeyService{repo: repo, box: box, nonces: nonces, maxSkew: maxSkew}
+}
+
+// Create issues a new key for the merchant. The response is the only time the secret is returned.
+// issuer is the key making the request, or nil for operators.
+func (s *APIKeyService) Create(ctx context.Context, merchantID int, req dto.APIKeyCreateRequest, issuer *models.APIKey) (dto.APIKeyResponse, error) {
+	template, err := apiKeyTemplate(merchantID, req)
+	if err != nil {
+		return dto.APIKeyResponse{}, err
+	}
+	if !canIssue(issuer, template) {
+		return dto.APIKeyResponse{}, ErrScopeEscalation
+	}
+	k, secret, err := s.issue(template)
+	if err != nil {
+		return dto.APIKeyResponse{}, err
+	}
+	if err := s.repo.Create(ctx, k); err != nil {
+		return dto.APIKeyResponse{}, fmt.Errorf("failed to create API key: %w", err)
+	}
+	resp := toAPIKeyResponse(k, time.Now())
+	resp.Secret = secret
+	return resp, nil
+}
+
+func (s *APIKeyService) List(ctx context.Context, merchantID int) (dto.APIKeyListResponse, error) {
+	keys, err := s.repo.ListByMerchant(ctx, merchantID)
+	if err != nil {
+		return dto.APIKeyListResponse{}, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	now := time.Now()
+	resp := dto.APIKeyListResponse{Keys: []dto.APIKeyResponse{}}
+	for _, k := range keys {
+		resp.Keys = append(resp.Keys, toAPIKeyResponse(k, now))
+	}
+	return resp, nil
+}
+
+// Rotate issues a replacement with the same name, type, scopes and origins, and retires the old key after the grace period
+func (s *APIKeyService) Rotate(ctx context.Context, merchantID, id int, req dto.APIKeyRotateRequest, issuer *models.APIKey) (dto.APIKeyResponse, error) {
+	grace := apiKeyDefaultGrace
+	if req.GracePeriodSeconds != nil {
+		grace = time.Duration(*req.GracePeriodSeconds) * time.Second
+		if grace < 0 || grace > apiKeyMaxGrace {
+			return dto.APIKeyResponse{}, ErrInvalidGrace
+		}
+	}
+	old, err := s.repo.GetByID(ctx, merchantID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return dto.APIKeyResponse{}, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return dto.APIKeyResponse{}, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	if !canIssue(issuer, old) {
+		return dto.APIKeyResponse{}, ErrScopeEscalation
+	}
+	replacement, secret, err := s.issue(old)
+	if err != nil {
+		return dto.APIKeyResponse{}, err
+	}
+	err = s.repo.Rotate(ctx, old, replacement, time.Now().Add(grace))
+	if errors.Is(err, sql.ErrNoRows) {
+		return dto.APIKeyResponse{}, ErrAPIKeyNotRotated
+	}
+	if err != nil {
+		return dto.APIKeyResponse{}, fmt.Errorf("failed to rotate API key: %w", err)
+	}
+	resp := toAPIKeyResponse(replacement, time.Now())
+	resp.Secret = secret
+	return resp, nil
+}
+
+// Revoke disables a key immediately. Like issuing, it is limited to keys whose scopes the
+// issuer holds itself; issuer is nil for operator and dashboard calls.
+func (s *APIKeyService) Revoke(ctx context.Context, merchantID, id int, issuer *models.APIKey) (dto.APIKeyResponse, error) {
+	target, err := s.repo.GetByID(ctx, merchantID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return dto.APIKeyResponse{}, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return dto.APIKeyResponse{}, fmt.Errorf("failed to get API key: %w", err)
+	}
+	if !canIssue(issuer, target) {
+		return dto.APIKeyResponse{}, ErrScopeEscalation
+	}
+	k, err := s.repo.Revoke(ctx, merchantID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return dto.APIKeyResponse{}, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return dto.APIKeyResponse{}, fmt.Errorf("failed to revoke API key: %w", err)
+	}
+	return toAPIKeyResponse(k, time.Now()), nil
+}
+
+// Authenticate resolves a presented secret to its key, recording the use
+func (s *APIKeyService) Authenticate(ctx context.Context, secret string) (*models.APIKey, error) {
+	if !strings.HasPrefix(secret, secretKeyPrefix) && !strings.HasPrefix(secret, publishableKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+	k, err := s.repo.GetByHash(ctx, hashAPIKey(secret))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up API key: %w", err)
+	}
+	if !k.Active(time.Now()) {
+		return nil, ErrInvalidAPIKey
+	}
+	if k.AuthMethod == models.APIKeyAuthHMAC {
+		return nil, ErrSignatureRequired
+	}
+	s.touch(ctx, k)
+	return k, nil
+}
+
+func (s *APIKeyService) touch(ctx context.Context, k *models.APIKey) {
+	if err := s.repo.TouchLastUsed(ctx, k.ID); err != nil {
+		fmt.Printf("Warning: failed to record use of API key %d: %v\n", k.ID, err)
+	}
+}
+
+// apiKeyTemplate validates a create request into the key's settings
+func apiKeyTemplate(merchantID int, req dto.APIKeyCreateRequest) (*models.APIKey, error) {
+	k := &models.APIKey{MerchantID: merchantID, Name: strings.TrimSpace(req.Name), Kind: req.Type, AuthMethod: req.AuthMethod}
+	switch req.AuthMethod {
+	case "":
+		k.AuthMethod = models.APIKeyAuthBearer
+	case models.APIKeyAuthBearer, models.APIKeyAuthHMAC:
+	default:
+		return nil, fmt.Errorf("%w: auth_method must be bearer or hmac", ErrInvalidAPIKeyReq)
+	}
+	switch req.Type {
+	case "", models.APIKeySecret:
+		k.Kind = models.APIKeySecret
+		if len(req.AllowedOrigins) > 0 {
+			return nil, fmt.Errorf("%w: allowed_origins only apply to publishable keys", ErrInvalidAPIKeyReq)
+		}
+		for _, scope := range req.Scopes {
+			if !containsString(models.AllScopes, scope) {
+				return nil, fmt.Errorf("%w: unknown scope %q; use one of %s", ErrInvalidAPIKeyReq, scope, strings.Join(models.AllScopes, ", "))
+			}
+			if !containsString(k.Scopes, scope) {
+				k.Scopes = append(k.Scopes, scope)
+			}
+		}
+	case models.APIKeyPublishable:
+		if k.AuthMethod == models.APIKeyAuthHMAC {
+			return nil, fmt.Errorf("%w: publishable keys live in browsers and cannot sign requests", ErrInvalidAPIKeyReq)
+		}
+		if len(req.Scopes) > 0 {
+			return nil, fmt.Errorf("%w: publishable keys always have the scopes %s", ErrInvalidAPIKeyReq, strings.Join(models.PublishableScopes, ", "))
+		}
+		if len(req.AllowedOrigins) == 0 {
+			return nil, fmt.Errorf("%w: publishable keys need at least one allowed origin", ErrInvalidAPIKeyReq)
+		}
+		for _, raw := range req.AllowedOrigins {
+			origin, err := normalizeOrigin(raw)
+			if err != nil {
+				return nil, err
+			}
+			if !containsString(k.AllowedOrigins, origin) {
+				k.AllowedOrigins = append(k.AllowedOrigins, origin)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("%w: type must be secret or publishable", ErrInvalidAPIKeyReq)
+	}
+	return k, nil
+}
+
+// canIssue reports whether issuer may create, rotate or revoke a key with k's scopes, so a
+// restricted key cannot mint its way to more access or lock out broader keys. A nil issuer is
+// an operator and may act on anything.
+func canIssue(issuer, k *models.APIKey) bool {
+	if issuer == nil {
+		return true
+	}
+	if k.Kind == models.APIKeySecret && k.Scopes == nil {
+		return issuer.Kind == models.APIKeySecret && issuer.Scopes == nil
+	}
+	for _, scope := range models.AllScopes {
+		if k.Allows(scope) && !issuer.Allows(scope) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeOrigin reduces an origin to scheme://host[:port] as browsers send it in the Origin header
+func normalizeOrigin(raw string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.User != nil {
+		return "", fmt.Errorf("%w: %q is not an origin like https://shop.example.com", ErrInvalidAPIKeyReq, raw)
+	}
+	return strings.ToLower(u.Scheme + "://" + u.Host), nil
+}
+
+// issue generates a key from template, encrypting the secret when the key signs requests
+func (s *APIKeyService) issue(template *models.APIKey) (*models.APIKey, string, error) {
+	k, secret, err := newAPIKey(template)
+	if err != nil {
+		return nil, "", err
+	}
+	if k.AuthMethod == models.APIKeyAuthHMAC {
+		if k.SecretCipher, err = s.box.Seal([]byte(secret)); err != nil {
+			return nil, "", fmt.Errorf("failed to encrypt signing secret: %w", err)
+		}
+	}
+	return k, secret, nil
+}
+
+// newAPIKey generates a random secret and a key record storing its hash, with template's
+// merchant, name, type, scopes, origins and auth method
+func newAPIKey(template *models.APIKey) (*models.APIKey, string, error) {
+	buf := make([]byte, apiKeyRandomByteCount)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	prefix := secretKeyPrefix
+	if template.Kind == models.APIKeyPublishable {
+		prefix = publishableKeyPrefix
+	}
+	secret := prefix + hex.EncodeToString(buf)
+	return &models.APIKey{
+		MerchantID:     template.MerchantID,
+		Name:           template.Name,
+		Kind:           template.Kind,
+		Prefix:         secret[:apiKeyDisplayLength],
+		Scopes:         template.Scopes,
+		AllowedOrigins: template.AllowedOrigins,
+		AuthMethod:     template.AuthMethod,
+		KeyHash:        hashAPIKey(secret),
+	}, secret, nil
+}
+
+// hashAPIKey is a plain SHA-256: keys carry 192 random bits, so a slow hash adds nothing and
+// a deterministic one lets keys be looked up by hash
+func hashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func toAPIKeyResponse(k *models.APIKey, now time.Time) dto.APIKeyResponse {
+	resp := dto.APIKeyResponse{
+		ID:         k.ID,
+		MerchantID: k.MerchantID,
+		Name:       k.Name,
+		Type:       k.Kind,
+		AuthMethod: k.AuthMethod,
+		Prefix:     k.Prefix,
+		Scopes:     []string{},
+		Status:     "active",
+		CreatedAt:  k.CreatedAt.Format(time.RFC3339),
+	}
+	for _, scope := range models.AllScopes {
+		if k.Allows(scope) {
+			resp.Scopes = append(resp.Scopes, scope)
+		}
+	}
+	if k.Kind == models.APIKeyPublishable {
+		resp.AllowedOrigins = k.AllowedOrigins
+	}
+	switch {
+	case k.RevokedAt != nil:
+		resp.Status = "revoked"
+	case k.ExpiresAt != nil && !now.Before(*k.ExpiresAt):
+		resp.Status = "expired"
+	case k.ExpiresAt != nil:
+		resp.Status = "expiring"
+	}
+	if k.ExpiresAt != nil {
+		resp.ExpiresAt = k.ExpiresAt.Format(time.RFC3339)
+	}
+	if k.RevokedAt != nil {
+		resp.RevokedAt = k.RevokedAt.Format(time.RFC3339)
+	}
+	if k.LastUsedAt != nil {
+		resp.LastUsedAt = k.LastUsedAt.Format(time.RFC3339)
+	}
+	return resp
+}
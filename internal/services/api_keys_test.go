@@ -0,0 +1,58 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kodra-pay/checkout-service/internal/models"
+	"github.com/kodra-pay/checkout-service/internal/repositories"
+)
+
+func TestAPIKeyServiceRevokeScopes(t *testing.T) {
+	unrestricted := &models.APIKey{ID: 1, MerchantID: 10, Kind: models.APIKeySecret}
+	keysOnly := &models.APIKey{ID: 2, MerchantID: 10, Kind: models.APIKeySecret, Scopes: []string{models.ScopeKeysWrite}}
+	linksAndKeys := &models.APIKey{ID: 3, MerchantID: 10, Kind: models.APIKeySecret, Scopes: []string{models.ScopeKeysWrite, models.ScopeLinksWrite}}
+
+	tests := []struct {
+		name    string
+		issuer  *models.APIKey
+		target  int
+		wantErr error
+	}{
+		{name: "operator revokes anything", issuer: nil, target: 1},
+		{name: "unrestricted key revokes unrestricted key", issuer: unrestricted, target: 1},
+		{name: "restricted key cannot revoke unrestricted key", issuer: keysOnly, target: 1, wantErr: ErrScopeEscalation},
+		{name: "restricted key cannot revoke broader key", issuer: keysOnly, target: 3, wantErr: ErrScopeEscalation},
+		{name: "restricted key revokes narrower key", issuer: linksAndKeys, target: 2},
+		{name: "unknown key", issuer: unrestricted, target: 99, wantErr: ErrAPIKeyNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeAPIKeyStore{keys: map[int]*models.APIKey{}}
+			for _, k := range []*models.APIKey{unrestricted, keysOnly, linksAndKeys} {
+				copied := *k
+				store.keys[k.ID] = &copied
+			}
+			svc := NewAPIKeyService(store, testSecretBox(t, 1), repositories.NewMemoryNonceStore(), 5*time.Minute)
+
+			_, err := svc.Revoke(context.Background(), 10, tt.target, tt.issuer)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("err = %v, want nil", err)
+				}
+				if store.keys[tt.target].RevokedAt == nil {
+					t.Fatalf("key %d was not revoked", tt.target)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if k, ok := store.keys[tt.target]; ok && k.RevokedAt != nil {
+				t.Fatalf("key %d was revoked", tt.target)
+			}
+		})
+	}
+}
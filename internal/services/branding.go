@@ -0,0 +1,99 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg" // logo decoding
+	_ "image/png"
+	"time"
+
+	"github.com/kodra-pay/checkout-service/internal/dto"
+	"github.com/kodra-pay/checkout-service/internal/models"
+	"github.com/kodra-pay/checkout-service/internal/repositories"
+)
+
+// maxLogoBytes bounds stored logos; they are drawn small on QR codes and documents
+const maxLogoBytes = 256 << 10
+
+var ErrInvalidLogo = errors.New("logo must be a base64-encoded PNG or JPEG of at most 256 KiB")
+
+// BrandingService stores merchant branding and serves logos to QR and document rendering
+type BrandingService struct {
+	repo *repositories.BrandingRepository
+}
+
+func NewBrandingService(repo *repositories.BrandingRepository) *BrandingService {
+	return &BrandingService{repo: repo}
+}
+
+func (s *BrandingService) Get(ctx context.Context, merchantID int) (dto.BrandingResponse, error) {
+	b, err := s.repo.GetByMerchant(ctx, merchantID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return dto.BrandingResponse{MerchantID: merchantID}, nil
+	}
+	if err != nil {
+		return dto.BrandingResponse{}, fmt.Errorf("failed to get branding: %w", err)
+	}
+	return toBrandingResponse(b), nil
+}
+
+func (s *BrandingService) Set(ctx context.Context, merchantID int, req dto.BrandingRequest) (dto.BrandingResponse, error) {
+	b := &models.MerchantBranding{MerchantID: merchantID, DisplayName: req.DisplayName}
+	if req.Logo != "" {
+		logo, err := base64.StdEncoding.DecodeString(req.Logo)
+		if err != nil || len(logo) > maxLogoBytes {
+			return dto.BrandingResponse{}, ErrInvalidLogo
+		}
+		_, format, err := image.DecodeConfig(bytes.NewReader(logo))
+		if err != nil || (format != "png" && format != "jpeg") {
+			return dto.BrandingResponse{}, ErrInvalidLogo
+		}
+		b.Logo = logo
+		b.LogoType = "image/" + format
+	}
+	if err := s.repo.Upsert(ctx, b); err != nil {
+		return dto.BrandingResponse{}, fmt.Errorf("failed to save branding: %w", err)
+	}
+	return toBrandingResponse(b), nil
+}
+
+// Logo returns the merchant's decoded logo, or nil when it has none or it cannot be loaded
+func (s *BrandingService) Logo(ctx context.Context, merchantID int) image.Image {
+	b := s.find(ctx, merchantID)
+	if b == nil || len(b.Logo) == 0 {
+		return nil
+	}
+	img, _, err := image.Decode(bytes.NewReader(b.Logo))
+	if err != nil {
+		fmt.Printf("Warning: stored logo for merchant %d is unreadable: %v\n", merchantID, err)
+		return nil
+	}
+	return img
+}
+
+// find returns the merchant's branding, or nil when it has none or it cannot be loaded
+func (s *BrandingService) find(ctx context.Context, merchantID int) *models.MerchantBranding {
+	b, err := s.repo.GetByMerchant(ctx, merchantID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			fmt.Printf("Warning: failed to load branding for merchant %d: %v\n", merchantID, err)
+		}
+		return nil
+	}
+	return b
+}
+
+func toBrandingResponse(b *models.MerchantBranding) dto.BrandingResponse {
+	return dto.BrandingResponse{
+		MerchantID:  b.MerchantID,
+		DisplayName: b.DisplayName,
+		HasLogo:     len(b.Logo) > 0,
+		LogoType:    b.LogoType,
+		UpdatedAt:   b.UpdatedAt.Format(time.RFC3339),
+	}
+}
@@ -2,9 +2,13 @@ package services
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"math"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/uuid" // Import uuid
 
@@ -13,51 +17,180 @@ import (
 	"github.com/kodra-pay/checkout-service/internal/models"
 )
 
+// Wallet credit outcomes reported in CheckoutPayResponse.WalletCredit
+const (
+	walletCreditCredited  = "credited"
+	walletCreditFailed    = "failed"
+	walletCreditSkipped   = "skipped"
+	walletCreditHeld      = "held"
+	walletCreditCancelled = "cancelled"
+	walletCreditReversed  = "reversed"
+)
+
 type CheckoutService struct {
 	transactionClient  clients.TransactionClient
 	walletLedgerClient clients.WalletLedgerClient
 	feeClient          clients.FeeClient
-	fraudClient        clients.FraudClient // Add FraudClient
+	fraudScreener      *FraudScreener
+	velocity           *VelocityChecker
 	paymentLinkRepo    PaymentLinkRepository
+	reviewQueue        ReviewQueue
+	unscreened         UnscreenedPaymentStore
+	checkoutRepo       CheckoutStore
+	limits             *MerchantLimitService
+	lists              *ListService
+	geo                *GeoService
+	invoices           InvoicePayer
+	events             EventPublisher
+	sessionTTL         time.Duration // how long a checkout session can be paid; 0 means sessions never expire
 }
 
+var (
+	// ErrInvalidSession is returned when a checkout session request is missing required fields
+	ErrInvalidSession  = errors.New("merchant_id, amount, and currency are required")
+	ErrPaymentNotFound = errors.New("payment not found")
+	// ErrSessionLinkMismatch is returned when a payment names a session and a payment link of different merchants
+	ErrSessionLinkMismatch = errors.New("checkout session and payment link belong to different merchants")
+)
+
 type PaymentLinkRepository interface {
 	GetByID(ctx context.Context, id int) (*models.PaymentLink, error)
+	ReserveUse(ctx context.Context, id int) error
+	ReleaseUse(ctx context.Context, id int) error
+	CompleteIfExhausted(ctx context.Context, id int) (bool, error)
+	RecordEvent(ctx context.Context, linkID int, eventType string, amount float64) error
+}
+
+// InvoicePayer marks the invoice behind a payment link paid once a payment on it settles, and
+// reopens it if that payment is refunded after review
+type InvoicePayer interface {
+	MarkPaidByPaymentLink(ctx context.Context, linkID int, reference string) error
+	ReopenByPaymentLink(ctx context.Context, linkID int, reference string) error
 }
 
-func NewCheckoutService(txClient clients.TransactionClient, wlClient clients.WalletLedgerClient, feeClient clients.FeeClient, fraudClient clients.FraudClient, plRepo PaymentLinkRepository) *CheckoutService {
+// EventPublisher queues events for delivery to the merchant's webhook endpoints
+type EventPublisher interface {
+	Publish(ctx context.Context, merchantID int, eventType string, data interface{})
+}
+
+// ReviewQueue receives fraud-flagged payments whose wallet credit is held for manual review
+type ReviewQueue interface {
+	Enqueue(ctx context.Context, pr *models.PaymentReview) error
+}
+
+// UnscreenedPaymentStore records payments accepted without a fraud-service verdict for re-screening
+type UnscreenedPaymentStore interface {
+	Create(ctx context.Context, up *models.UnscreenedPayment) error
+}
+
+// CheckoutStore persists checkout sessions and the payments taken through them
+type CheckoutStore interface {
+	CreateSession(ctx context.Context, cs *models.CheckoutSession) error
+	GetSession(ctx context.Context, id int) (*models.CheckoutSession, error)
+	ClaimSession(ctx context.Context, id int) error
+	ReleaseSession(ctx context.Context, id int) error
+	CompleteSession(ctx context.Context, id int) error
+	ExpireDueSessions(ctx context.Context) ([]*models.CheckoutSession, error)
+	RecordPayment(ctx context.Context, p *models.CheckoutPayment) error
+	UpdatePaymentStatus(ctx context.Context, reference, status string) error
+	GetPaymentByReference(ctx context.Context, reference string) (*models.CheckoutPayment, error)
+	CountCustomerPayments(ctx context.Context, customerID int, email string) (int, error)
+}
+
+func NewCheckoutService(txClient clients.TransactionClient, wlClient clients.WalletLedgerClient, feeClient clients.FeeClient, fraudScreener *FraudScreener, velocity *VelocityChecker, plRepo PaymentLinkRepository, reviewQueue ReviewQueue, unscreened UnscreenedPaymentStore, checkoutRepo CheckoutStore, limits *MerchantLimitService, lists *ListService, geo *GeoService, invoices InvoicePayer, events EventPublisher, sessionTTL time.Duration) *CheckoutService {
 	return &CheckoutService{
 		transactionClient:  txClient,
 		walletLedgerClient: wlClient,
 		feeClient:          feeClient,
-		fraudClient:        fraudClient, // Inject FraudClient
+		fraudScreener:      fraudScreener,
+		velocity:           velocity,
 		paymentLinkRepo:    plRepo,
+		reviewQueue:        reviewQueue,
+		unscreened:         unscreened,
+		checkoutRepo:       checkoutRepo,
+		limits:             limits,
+		lists:              lists,
+		geo:                geo,
+		invoices:           invoices,
+		events:             events,
+		sessionTTL:         sessionTTL,
+	}
+}
+
+func (s *CheckoutService) CreateSession(ctx context.Context, req dto.CheckoutSessionRequest) (dto.CheckoutSessionResponse, error) {
+	if req.MerchantID == 0 || req.Amount <= 0 || req.Currency == "" {
+		return dto.CheckoutSessionResponse{}, ErrInvalidSession
+	}
+	cs := &models.CheckoutSession{
+		MerchantID:    req.MerchantID,
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+		Description:   req.Description,
+		CustomerEmail: req.CustomerEmail,
+		CustomerID:    req.CustomerID,
+		Status:        models.SessionStatusPending,
+	}
+	if s.sessionTTL > 0 {
+		expiresAt := time.Now().Add(s.sessionTTL)
+		cs.ExpiresAt = &expiresAt
+	}
+	if err := s.checkoutRepo.CreateSession(ctx, cs); err != nil {
+		return dto.CheckoutSessionResponse{}, fmt.Errorf("failed to create checkout session: %w", err)
 	}
+	return toCheckoutSessionResponse(cs), nil
 }
 
-func (s *CheckoutService) CreateSession(_ context.Context, req dto.CheckoutSessionRequest) dto.CheckoutSessionResponse {
-	// In a real scenario, this would store session details and return a unique ID.
-	// For now, it's a placeholder. Since we are using int IDs, we can use a simple counter
-	// or, more realistically, this ID would come from a database insertion.
-	// For this placeholder, we'll return a static ID or 0.
-	return dto.CheckoutSessionResponse{
-		ID:       1, // Placeholder for an auto-generated int ID
-		Status:   "pending",
-		Amount:   req.Amount,
-		Currency: req.Currency,
+func (s *CheckoutService) GetSession(ctx context.Context, id int) (*dto.CheckoutSessionResponse, error) {
+	cs, err := s.checkoutRepo.GetSession(ctx, id)
+	if err != nil {
+		return nil, err
 	}
+	resp := toCheckoutSessionResponse(cs)
+	return &resp, nil
 }
 
-func (s *CheckoutService) GetSession(_ context.Context, id int) dto.CheckoutSessionResponse {
-	// In a real scenario, this would retrieve session details by ID.
-	// For now, it's a placeholder.
-	return dto.CheckoutSessionResponse{
-		ID:     id,
-		Status: "pending",
+// RunSessionExpiry marks sessions past their expiry as expired every interval until ctx is
+// cancelled, publishing session.expired for each
+func (s *CheckoutService) RunSessionExpiry(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sessions, err := s.checkoutRepo.ExpireDueSessions(ctx)
+			if err != nil {
+				fmt.Printf("Warning: failed to expire checkout sessions: %v\n", err)
+				continue
+			}
+			for _, cs := range sessions {
+				s.events.Publish(ctx, cs.MerchantID, models.EventSessionExpired, toCheckoutSessionResponse(cs))
+			}
+			if len(sessions) > 0 {
+				fmt.Printf("Info: expired %d checkout sessions\n", len(sessions))
+			}
+		}
 	}
 }
 
-func (s *CheckoutService) Pay(ctx context.Context, req dto.CheckoutPayRequest) (dto.CheckoutPayResponse, error) {
+func toCheckoutSessionResponse(cs *models.CheckoutSession) dto.CheckoutSessionResponse {
+	resp := dto.CheckoutSessionResponse{
+		ID:          cs.ID,
+		MerchantID:  cs.MerchantID,
+		Status:      cs.Status,
+		Amount:      cs.Amount,
+		Currency:    cs.Currency,
+		Description: cs.Description,
+		CreatedAt:   cs.CreatedAt.Format(time.RFC3339),
+	}
+	if cs.ExpiresAt != nil {
+		resp.ExpiresAt = cs.ExpiresAt.Format(time.RFC3339)
+	}
+	return resp
+}
+
+func (s *CheckoutService) Pay(ctx context.Context, req dto.CheckoutPayRequest) (resp dto.CheckoutPayResponse, err error) {
 	// Simulate payment processing (e.g., call a payment gateway)
 	// For this exercise, we assume payment is successful.
 
@@ -65,14 +198,69 @@ func (s *CheckoutService) Pay(ctx context.Context, req dto.CheckoutPayRequest) (
 	amount := req.Amount // currency units (e.g., NGN)
 	currency := req.Currency
 	description := req.Description
-	customerIDStr := strconv.Itoa(req.CustomerID) // Convert CustomerID to string for fraud service
+	customerID := req.CustomerID
+	customerEmail := req.CustomerEmail
+
+	var customFields map[string]interface{}
+
+	// If a checkout session is provided, the merchant fixed the payment details when creating it
+	var session *models.CheckoutSession
+	if req.SessionID != 0 {
+		var err error
+		session, err = s.checkoutRepo.GetSession(ctx, req.SessionID)
+		if err != nil {
+			return dto.CheckoutPayResponse{Status: "failed"}, fmt.Errorf("failed to get checkout session: %w", err)
+		}
+		if session.Status != models.SessionStatusPending {
+			return dto.CheckoutPayResponse{Status: "failed"}, fmt.Errorf("checkout session %d is %s", session.ID, session.Status)
+		}
+		// The expiry job may not have flipped the status yet, so check the time as well
+		if session.IsExpired(time.Now()) {
+			return dto.CheckoutPayResponse{Status: "failed"}, fmt.Errorf("checkout session %d has expired", session.ID)
+		}
+		merchantID = session.MerchantID
+		amount = session.Amount
+		currency = session.Currency
+		if session.Description != "" {
+			description = session.Description
+		}
+		if customerID == 0 {
+			customerID = session.CustomerID
+		}
+		if customerEmail == "" {
+			customerEmail = session.CustomerEmail
+		}
+	}
 
 	// If payment link ID is provided, fetch payment link details
 	if req.PaymentLinkID != 0 {
-		paymentLink, err := s.paymentLinkRepo.GetByID(ctx, req.PaymentLinkID)
+		// Assign rather than declare err: the analytics defer below reads the named result
+		var paymentLink *models.PaymentLink
+		paymentLink, err = s.paymentLinkRepo.GetByID(ctx, req.PaymentLinkID)
 		if err != nil {
 			return dto.CheckoutPayResponse{Status: "failed"}, fmt.Errorf("failed to get payment link: %w", err)
 		}
+		// The expiry job may not have flipped the status yet, so check the time as well
+		if paymentLink.IsExpired(time.Now()) {
+			return dto.CheckoutPayResponse{Status: "failed"}, fmt.Errorf("payment link %d has expired", paymentLink.ID)
+		}
+		if paymentLink.Status != models.PaymentLinkActive {
+			return dto.CheckoutPayResponse{Status: "failed"}, fmt.Errorf("payment link %d is %s", paymentLink.ID, paymentLink.Status)
+		}
+		if session != nil && session.MerchantID != paymentLink.MerchantID {
+			return dto.CheckoutPayResponse{Status: "failed"}, ErrSessionLinkMismatch
+		}
+
+		// Link analytics: every pay call on a live link is an attempt; the outcome is recorded on return
+		s.recordLinkEvent(ctx, paymentLink.ID, models.LinkEventAttempt, 0)
+		defer func() {
+			switch {
+			case err == nil:
+				s.recordLinkEvent(ctx, paymentLink.ID, models.LinkEventSuccess, resp.Amount)
+			case resp.Status == "denied_by_fraud":
+				s.recordLinkEvent(ctx, paymentLink.ID, models.LinkEventFraudDenied, 0)
+			}
+		}()
 
 		// Use payment link values where appropriate
 		merchantID = paymentLink.MerchantID
@@ -81,17 +269,25 @@ func (s *CheckoutService) Pay(ctx context.Context, req dto.CheckoutPayRequest) (
 		// For open links, honor the client-provided amount when present; fall back to link amount only if none was supplied.
 		if paymentLink.Mode == "fixed" {
 			if paymentLink.Amount != nil {
-				amount = *paymentLink.Amount
+				amount = float64(*paymentLink.Amount)
 			}
 		} else {
 			if amount == 0 && paymentLink.Amount != nil {
-				amount = *paymentLink.Amount
+				amount = float64(*paymentLink.Amount)
+			}
+			if amount > 0 && !paymentLink.AmountInBounds(amount) {
+				return dto.CheckoutPayResponse{Status: "failed"}, fmt.Errorf("amount %.2f is outside the allowed range for payment link %d", amount, paymentLink.ID)
 			}
 		}
 
 		if paymentLink.Description != "" {
 			description = paymentLink.Description
 		}
+
+		customFields, err = validateCustomFieldValues(paymentLink.CustomFields, req.CustomFields)
+		if err != nil {
+			return dto.CheckoutPayResponse{Status: "failed"}, err
+		}
 	}
 
 	// Validate required fields
@@ -99,15 +295,90 @@ func (s *CheckoutService) Pay(ctx context.Context, req dto.CheckoutPayRequest) (
 		return dto.CheckoutPayResponse{Status: "failed"}, fmt.Errorf("merchant_id, amount, and currency are required")
 	}
 
-	customerID := req.CustomerID
-	if customerID == 0 {
-		// If customerID is 0, we can potentially use customerEmail for wallet lookup
-		// However, for consistency with int IDs, we'll assume 0 means no specific ID.
+	// Once a transaction exists the payer has been charged, so a later error must not hand the
+	// session or link use back, nor report the payment as failed
+	charged := false
+
+	// From here on the payment is attributable to a merchant, who is told about failures
+	defer func() {
+		if err == nil || charged {
+			return
+		}
+		event := dto.PaymentEvent{
+			CheckoutPaymentResponse: dto.CheckoutPaymentResponse{
+				TransactionReference: resp.TransactionReference,
+				Status:               resp.Status,
+				MerchantID:           merchantID,
+				CustomerID:           customerID,
+				CustomerEmail:        customerEmail,
+				Amount:               amount,
+				Currency:             currency,
+				PaymentMethod:        req.PaymentMethod,
+				CustomFields:         customFields,
+				CreatedAt:            time.Now().UTC().Format(time.RFC3339),
+			},
+			Fraud:         resp.Fraud,
+			FailureReason: err.Error(),
+		}
+		if session != nil {
+			event.SessionID = &session.ID
+		}
+		if req.PaymentLinkID != 0 {
+			event.PaymentLinkID = &req.PaymentLinkID
+		}
+		s.events.Publish(ctx, merchantID, models.EventPaymentFailed, event)
+	}()
+
+	// Merchant risk limits are checked before any transaction is created
+	if err := s.limits.Enforce(ctx, merchantID, currency, amount); err != nil {
+		var limitErr *LimitError
+		if errors.As(err, &limitErr) {
+			return dto.CheckoutPayResponse{Status: "rejected_by_limits"}, err
+		}
+		return dto.CheckoutPayResponse{Status: "failed"}, err
 	}
+
 	if description == "" {
 		description = "Payment Link Transaction"
 	}
 
+	// Claim one of the link's uses up front so concurrent payers cannot both take the last one.
+	// The claim is given back if this payment fails before a transaction is created.
+	if req.PaymentLinkID != 0 {
+		if err := s.paymentLinkRepo.ReserveUse(ctx, req.PaymentLinkID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return dto.CheckoutPayResponse{Status: "failed"}, fmt.Errorf("payment link %d has no uses remaining", req.PaymentLinkID)
+			}
+			return dto.CheckoutPayResponse{Status: "failed"}, fmt.Errorf("failed to reserve payment link use: %w", err)
+		}
+		defer func() {
+			if err == nil || charged {
+				return
+			}
+			if releaseErr := s.paymentLinkRepo.ReleaseUse(context.WithoutCancel(ctx), req.PaymentLinkID); releaseErr != nil {
+				fmt.Printf("Warning: failed to release use of payment link %d: %v\n", req.PaymentLinkID, releaseErr)
+			}
+		}()
+	}
+
+	// Claim the session the same way, so two payers racing on it cannot both be charged
+	if session != nil {
+		if err := s.checkoutRepo.ClaimSession(ctx, session.ID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return dto.CheckoutPayResponse{Status: "failed"}, fmt.Errorf("checkout session %d is no longer payable", session.ID)
+			}
+			return dto.CheckoutPayResponse{Status: "failed"}, fmt.Errorf("failed to claim checkout session: %w", err)
+		}
+		defer func() {
+			if err == nil || charged {
+				return
+			}
+			if releaseErr := s.checkoutRepo.ReleaseSession(context.WithoutCancel(ctx), session.ID); releaseErr != nil {
+				fmt.Printf("Warning: failed to release checkout session %d: %v\n", session.ID, releaseErr)
+			}
+		}()
+	}
+
 	// Generate a robust transaction reference if not provided or just "2"
 	transactionReference := req.Reference
 	if transactionReference == "" || transactionReference == strconv.Itoa(req.PaymentLinkID) { // If it's empty or just the payment link ID
@@ -118,31 +389,107 @@ func (s *CheckoutService) Pay(ctx context.Context, req dto.CheckoutPayRequest) (
 		}
 	}
 
+	// === BLOCK/ALLOW LISTS ===
+	// A blocklist hit denies outright without consulting the fraud service
+	lists := s.lists.Screen(ctx, merchantID, listSubject{
+		CustomerID: customerID,
+		Email:      customerEmail,
+		IP:         req.Origin,
+		CardBIN:    req.CardBIN,
+	})
+	if len(lists.Blocked) > 0 {
+		return dto.CheckoutPayResponse{
+			Status:               "denied_by_fraud",
+			TransactionReference: transactionReference,
+			Fraud: &dto.FraudOutcome{
+				Decision: "deny",
+				Reasons:  lists.Reasons(),
+				Source:   fraudSourceBlocklist,
+			},
+		}, fmt.Errorf("transaction denied by blocklist: %v", lists.Reasons())
+	}
+
+	// === COUNTRY RESTRICTIONS ===
+	country, countryDenial := s.geo.Check(ctx, merchantID, req.Origin)
+	if countryDenial != "" {
+		return dto.CheckoutPayResponse{
+			Status:               "denied_by_fraud",
+			TransactionReference: transactionReference,
+			Fraud: &dto.FraudOutcome{
+				Decision: "deny",
+				Reasons:  []string{countryDenial},
+				Source:   fraudSourceCountry,
+			},
+		}, fmt.Errorf("transaction denied: %s", countryDenial)
+	}
+
+	// === VELOCITY CHECK ===
+	// Local limits catch card-testing bursts faster than the remote fraud service reacts.
+	// Allowlisted customers are trusted and skip them.
+	var velocity velocityResult
+	if !lists.Allowed {
+		velocity = s.velocity.Check(ctx, velocitySubject{
+			CustomerID:    customerID,
+			Email:         customerEmail,
+			Origin:        req.Origin,
+			PaymentLinkID: req.PaymentLinkID,
+			Amount:        amount,
+			Currency:      currency,
+		})
+	}
+	if velocity.Action == "deny" {
+		return dto.CheckoutPayResponse{
+			Status:               "denied_by_fraud",
+			TransactionReference: transactionReference,
+			Fraud: &dto.FraudOutcome{
+				Decision: "deny",
+				Reasons:  velocity.Reasons,
+				Source:   fraudSourceVelocity,
+			},
+		}, fmt.Errorf("transaction denied by velocity limits: %v", velocity.Reasons)
+	}
+
 	// === FRAUD CHECK ===
 	fraudReq := dto.FraudCheckRequest{
 		TransactionReference: transactionReference, // Use the generated/prefixed reference
 		Amount:               amount,               // already in currency units
 		Currency:             currency,
-		CustomerID:           customerIDStr,
+		CustomerID:           strconv.Itoa(customerID), // Convert CustomerID to string for fraud service
 		MerchantID:           strconv.Itoa(merchantID),
 		Origin:               req.Origin, // Use req.Origin which is passed from handler
+		Country:              country,
 		PaymentMethod:        req.PaymentMethod,
-		// CustomData:           nil, // Add any custom data if needed
+		CustomData:           s.fraudSignals(ctx, req, session, customerID, customerEmail),
 	}
 
-	fraudDecision, err := s.fraudClient.CheckTransaction(ctx, fraudReq)
+	screen, err := s.fraudScreener.Screen(ctx, fraudReq, merchantID)
 	if err != nil {
-		return dto.CheckoutPayResponse{Status: "failed"}, fmt.Errorf("fraud check failed: %w", err)
+		return dto.CheckoutPayResponse{Status: "failed"}, err
+	}
+	fraudDecision := screen.Decision
+	if velocity.Action == "flag" {
+		if fraudDecision.Decision != "deny" {
+			fraudDecision.Decision = "flag"
+		}
+		fraudDecision.Reasons = append(fraudDecision.Reasons, velocity.Reasons...)
+	}
+	fraudOutcome := &dto.FraudOutcome{
+		Decision:   fraudDecision.Decision,
+		Score:      fraudDecision.OverallScore,
+		Reasons:    fraudDecision.Reasons,
+		Source:     screen.Source,
+		Unscreened: screen.Unscreened,
 	}
 
 	if fraudDecision.Decision == "deny" {
 		// Use req.Reference (string) here
-		return dto.CheckoutPayResponse{Status: "denied_by_fraud", TransactionReference: transactionReference}, fmt.Errorf("transaction denied by fraud rules: %v", fraudDecision.Reasons)
+		return dto.CheckoutPayResponse{Status: "denied_by_fraud", TransactionReference: transactionReference, Fraud: fraudOutcome}, fmt.Errorf("transaction denied by fraud rules: %v", fraudDecision.Reasons)
 	}
 	// === END FRAUD CHECK ===
 
 	// 1. Quote fees (best-effort; fall back to zero on error)
 	var feeAmount float64
+	var feeBreakdown *dto.FeeBreakdown
 	if s.feeClient != nil {
 		quote, err := s.feeClient.Quote(ctx, dto.FeeQuoteRequest{
 			Amount:   float64(amount),
@@ -153,13 +500,26 @@ func (s *CheckoutService) Pay(ctx context.Context, req dto.CheckoutPayRequest) (
 			fmt.Printf("Warning: fee quote failed: %v\n", err)
 		} else {
 			feeAmount = quote.TotalFee
+			feeBreakdown = &dto.FeeBreakdown{
+				Total:   quote.TotalFee,
+				Rate:    quote.Rate,
+				Flat:    quote.Flat,
+				Capped:  quote.Capped,
+				Cap:     quote.Cap,
+				Channel: quote.Channel,
+			}
 		}
 	}
 
+	netCredit := amount - feeAmount
+	if netCredit < 0 {
+		netCredit = 0
+	}
+
 	// 2. Create Transaction in Transaction Service (gross amount)
 	transactionReq := dto.TransactionCreateRequest{
 		MerchantID:    merchantID,
-		CustomerEmail: req.CustomerEmail,
+		CustomerEmail: customerEmail,
 		CustomerName:  req.CustomerName,
 		CustomerID:    customerID,
 		Amount:        amount,
@@ -179,58 +539,306 @@ func (s *CheckoutService) Pay(ctx context.Context, req dto.CheckoutPayRequest) (
 	if err != nil {
 		return dto.CheckoutPayResponse{Status: "failed"}, fmt.Errorf("failed to create transaction: %w", err)
 	}
+	charged = true
 
-	// 3. Update Wallet Balance in Wallet-Ledger Service (optional for payment links)
-	// This is primarily for customer wallet management, not required for payment processing
-	// We only attempt wallet operations if a valid customer ID is provided.
-	if customerID != 0 {
-		// First, try to get the customer's wallet
-		wallet, err := s.walletLedgerClient.GetWalletByUserIDAndCurrency(ctx, customerID, currency)
-		if err != nil {
-			// If wallet not found, try to create one
-			createWalletReq := dto.CreateWalletRequest{
-				UserID:   customerID,
-				Currency: currency,
-			}
-			newWallet, createErr := s.walletLedgerClient.CreateWallet(ctx, createWalletReq)
-			if createErr != nil {
-				// Wallet ledger service unavailable - log but don't fail the transaction
-				fmt.Printf("Warning: failed to get or create wallet for customer %d: %v\n", customerID, createErr)
-				// Transaction was already created successfully, return success
-				return dto.CheckoutPayResponse{
-					TransactionReference: txResp.Reference,
-					Status:               "paid",
-				}, nil
+	resp = dto.CheckoutPayResponse{
+		TransactionReference: txResp.Reference,
+		Status:               "paid",
+		Amount:               amount,
+		Currency:             currency,
+		Fee:                  feeBreakdown,
+		NetAmount:            netCredit,
+		Fraud:                fraudOutcome,
+		WalletCredit:         walletCreditSkipped,
+		SessionID:            req.SessionID,
+		PaymentLinkID:        req.PaymentLinkID,
+		CustomFields:         customFields,
+	}
+
+	// Flagged payments are held: the wallet is not credited until a reviewer approves them
+	reviewPending := false // the review row still has to be written
+	if fraudDecision.Decision == "flag" {
+		resp.Status = "pending_review"
+		resp.WalletCredit = walletCreditHeld
+		review := &models.PaymentReview{
+			TransactionReference: txResp.Reference,
+			MerchantID:           merchantID,
+			CustomerID:           customerID,
+			CustomerEmail:        customerEmail,
+			Amount:               amount,
+			FeeAmount:            feeAmount,
+			NetAmount:            netCredit,
+			Currency:             currency,
+			PaymentMethod:        req.PaymentMethod,
+			FraudScore:           fraudDecision.OverallScore,
+			FraudReasons:         fraudDecision.Reasons,
+			Status:               models.ReviewStatusPending,
+			WalletCredit:         walletCreditHeld,
+		}
+		if err := s.reviewQueue.Enqueue(ctx, review); err != nil {
+			// Hand the payment to the rescreener, which retries the enqueue, so the held credit
+			// doesn't sit outside the review queue
+			fmt.Printf("Error: failed to enqueue flagged transaction %s for review, leaving it to the rescreener: %v\n", txResp.Reference, err)
+			reviewPending = true
+		}
+	} else if customerID != 0 {
+		// 3. Update Wallet Balance in Wallet-Ledger Service (optional for payment links)
+		// This is primarily for customer wallet management, not required for payment processing
+		// We only attempt wallet operations if a valid customer ID is provided.
+		resp.WalletCredit = creditWallet(ctx, s.walletLedgerClient, customerID, currency, netCredit, feeAmount, txResp.Reference)
+	} else {
+		fmt.Printf("Info: Skipping wallet operations for customer 0 as no valid customer ID was provided.\n")
+	}
+
+	payment := &models.CheckoutPayment{
+		TransactionReference: txResp.Reference,
+		MerchantID:           merchantID,
+		CustomerID:           customerID,
+		CustomerEmail:        customerEmail,
+		Amount:               amount,
+		FeeAmount:            feeAmount,
+		NetAmount:            netCredit,
+		Currency:             currency,
+		PaymentMethod:        req.PaymentMethod,
+		Status:               resp.Status,
+		FraudDecision:        fraudDecision.Decision,
+		FraudScore:           fraudDecision.OverallScore,
+		Origin:               req.Origin,
+		Country:              country,
+		CustomFields:         customFields,
+	}
+	if session != nil {
+		payment.SessionID = &session.ID
+	}
+	if req.PaymentLinkID != 0 {
+		payment.PaymentLinkID = &req.PaymentLinkID
+	}
+	if err := s.checkoutRepo.RecordPayment(ctx, payment); err != nil {
+		fmt.Printf("Warning: failed to record payment %s: %v\n", txResp.Reference, err)
+	}
+	eventType := models.EventPaymentSucceeded
+	if resp.Status == "pending_review" {
+		eventType = models.EventPaymentPendingReview
+	}
+	s.events.Publish(ctx, merchantID, eventType, dto.PaymentEvent{
+		CheckoutPaymentResponse: toCheckoutPaymentResponse(payment),
+		Fraud:                   fraudOutcome,
+	})
+	// The bookkeeping below still runs if the session can't be completed; the error is returned at the end
+	var sessionErr error
+	if session != nil {
+		if err := s.checkoutRepo.CompleteSession(ctx, session.ID); err != nil {
+			sessionErr = fmt.Errorf("payment %s was taken but checkout session %d could not be completed: %w", txResp.Reference, session.ID, err)
+		}
+	}
+	if req.PaymentLinkID != 0 {
+		if completed, err := s.paymentLinkRepo.CompleteIfExhausted(ctx, req.PaymentLinkID); err != nil {
+			fmt.Printf("Warning: failed to complete payment link %d: %v\n", req.PaymentLinkID, err)
+		} else if completed {
+			s.publishLinkExhausted(ctx, req.PaymentLinkID)
+		}
+		// A payment held for review only settles the invoice once it is approved
+		if resp.Status == "paid" {
+			if err := s.invoices.MarkPaidByPaymentLink(ctx, req.PaymentLinkID, txResp.Reference); err != nil {
+				fmt.Printf("Warning: failed to mark invoice paid for payment link %d: %v\n", req.PaymentLinkID, err)
 			}
-			wallet = newWallet
 		}
+	}
 
-		// Credit the wallet
-		netCredit := amount - feeAmount
-		if netCredit < 0 {
-			netCredit = 0
+	// Keep payments accepted under a fail policy so they can be re-screened once fraud-service is
+	// back, and flagged payments that didn't make it into the review queue
+	if screen.Unscreened || reviewPending {
+		failPolicy := screen.Policy
+		if !screen.Unscreened {
+			failPolicy = failPolicyReviewRetry
 		}
+		up := &models.UnscreenedPayment{
+			TransactionReference: txResp.Reference,
+			MerchantID:           merchantID,
+			CustomerID:           customerID,
+			CustomerEmail:        customerEmail,
+			Amount:               amount,
+			FeeAmount:            feeAmount,
+			NetAmount:            netCredit,
+			Currency:             currency,
+			PaymentMethod:        req.PaymentMethod,
+			Origin:               req.Origin,
+			Country:              fraudReq.Country,
+			FraudSignals:         fraudReq.CustomData,
+			FailPolicy:           failPolicy,
+			LocalDecision:        fraudDecision.Decision,
+			FraudScore:           fraudDecision.OverallScore,
+			FraudReasons:         fraudDecision.Reasons,
+			WalletCredit:         resp.WalletCredit,
+			Status:               models.UnscreenedPending,
+		}
+		if err := s.unscreened.Create(ctx, up); err != nil {
+			fmt.Printf("Error: failed to record unscreened transaction %s: %v\n", txResp.Reference, err)
+		}
+	}
+
+	if sessionErr != nil {
+		return resp, sessionErr
+	}
+	return resp, nil
+}
+
+// GetPayment looks up one of merchantID's payments by its transaction reference
+func (s *CheckoutService) GetPayment(ctx context.Context, reference string, merchantID int) (*dto.CheckoutPaymentResponse, error) {
+	p, err := s.checkoutRepo.GetPaymentByReference(ctx, reference)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && p.MerchantID != merchantID) {
+		return nil, ErrPaymentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+	resp := toCheckoutPaymentResponse(p)
+	return &resp, nil
+}
 
-		netCreditKobo := int64(math.Round(netCredit * 100))
+func toCheckoutPaymentResponse(p *models.CheckoutPayment) dto.CheckoutPaymentResponse {
+	return dto.CheckoutPaymentResponse{
+		TransactionReference: p.TransactionReference,
+		Status:               p.Status,
+		MerchantID:           p.MerchantID,
+		SessionID:            p.SessionID,
+		PaymentLinkID:        p.PaymentLinkID,
+		CustomerID:           p.CustomerID,
+		CustomerEmail:        p.CustomerEmail,
+		Amount:               p.Amount,
+		FeeAmount:            p.FeeAmount,
+		NetAmount:            p.NetAmount,
+		Currency:             p.Currency,
+		PaymentMethod:        p.PaymentMethod,
+		FraudDecision:        p.FraudDecision,
+		Country:              p.Country,
+		CustomFields:         p.CustomFields,
+		CreatedAt:            p.CreatedAt.Format(time.RFC3339),
+	}
+}
 
-		updateBalanceReq := dto.UpdateBalanceRequest{
-			Amount:      netCreditKobo,
-			Reference:   txResp.Reference, // Link to the transaction (string)
-			Description: fmt.Sprintf("Credit for transaction %s (fee: %.2f)", txResp.Reference, feeAmount),
-			Type:        "credit",
+// PayMerchant resolves the merchant a pay request would charge for, the same way Pay does:
+// a payment link's merchant wins over a session's, which wins over merchant_id in the body
+func (s *CheckoutService) PayMerchant(ctx context.Context, req dto.CheckoutPayRequest) (int, error) {
+	sessionMerchant := 0
+	if req.SessionID != 0 {
+		session, err := s.checkoutRepo.GetSession(ctx, req.SessionID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get checkout session: %w", err)
 		}
+		sessionMerchant = session.MerchantID
+	}
+	if req.PaymentLinkID != 0 {
+		pl, err := s.paymentLinkRepo.GetByID(ctx, req.PaymentLinkID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get payment link: %w", err)
+		}
+		if sessionMerchant != 0 && sessionMerchant != pl.MerchantID {
+			return 0, ErrSessionLinkMismatch
+		}
+		return pl.MerchantID, nil
+	}
+	if sessionMerchant != 0 {
+		return sessionMerchant, nil
+	}
+	return req.MerchantID, nil
+}
+
+// publishLinkExhausted tells the merchant a payment link has taken its last use
+func (s *CheckoutService) publishLinkExhausted(ctx context.Context, linkID int) {
+	pl, err := s.paymentLinkRepo.GetByID(ctx, linkID)
+	if err != nil {
+		fmt.Printf("Warning: failed to load exhausted payment link %d: %v\n", linkID, err)
+		return
+	}
+	s.events.Publish(ctx, pl.MerchantID, models.EventPaymentLinkExhausted, dto.PaymentLinkEvent{
+		ID:          pl.ID,
+		MerchantID:  pl.MerchantID,
+		Reference:   pl.Reference,
+		Description: pl.Description,
+		Status:      pl.Status,
+		MaxUses:     pl.MaxUses,
+		UseCount:    pl.UseCount,
+	})
+}
 
-		_, err = s.walletLedgerClient.UpdateWalletBalance(ctx, wallet.ID, updateBalanceReq)
+// recordLinkEvent logs a payment link analytics event; failures never affect the payment
+func (s *CheckoutService) recordLinkEvent(ctx context.Context, linkID int, eventType string, amount float64) {
+	if err := s.paymentLinkRepo.RecordEvent(context.WithoutCancel(ctx), linkID, eventType, amount); err != nil {
+		fmt.Printf("Warning: failed to record %s event for payment link %d: %v\n", eventType, linkID, err)
+	}
+}
+
+// fraudSignals collects the device, email and session context forwarded to fraud-service.
+// Signals that are unknown for this payment are left out rather than sent empty.
+func (s *CheckoutService) fraudSignals(ctx context.Context, req dto.CheckoutPayRequest, session *models.CheckoutSession, customerID int, customerEmail string) map[string]interface{} {
+	signals := map[string]interface{}{}
+	if req.UserAgent != "" {
+		signals["user_agent"] = req.UserAgent
+	}
+	if req.AcceptLanguage != "" {
+		signals["accept_language"] = req.AcceptLanguage
+	}
+	if req.DeviceFingerprint != "" {
+		signals["device_fingerprint"] = req.DeviceFingerprint
+	}
+	if customerEmail != "" {
+		signals["customer_email"] = customerEmail
+		if _, domain, ok := strings.Cut(customerEmail, "@"); ok {
+			signals["email_domain"] = strings.ToLower(domain)
+		}
+	}
+	if req.PaymentLinkID != 0 {
+		signals["payment_link_id"] = req.PaymentLinkID
+	}
+	if session != nil {
+		signals["session_id"] = session.ID
+		signals["seconds_since_session_created"] = int(time.Since(session.CreatedAt).Seconds())
+	}
+	if customerID != 0 || customerEmail != "" {
+		count, err := s.checkoutRepo.CountCustomerPayments(ctx, customerID, customerEmail)
 		if err != nil {
-			// Log the error but don't fail the transaction
-			fmt.Printf("Warning: failed to update wallet balance: %v\n", err)
+			fmt.Printf("Warning: failed to count prior payments for fraud check: %v\n", err)
+		} else {
+			signals["prior_payment_count"] = count
 		}
-	} else {
-		fmt.Printf("Info: Skipping wallet operations for customer 0 as no valid customer ID was provided.\n")
 	}
+	return signals
+}
 
-	return dto.CheckoutPayResponse{
-		TransactionReference: txResp.Reference,
-		Status:               "paid",
-	}, nil
+// creditWallet credits the customer's wallet with the net amount of a payment,
+// creating the wallet if needed. Failures are logged rather than returned since
+// the transaction has already been recorded; the returned status is reported
+// back to the caller.
+func creditWallet(ctx context.Context, wl clients.WalletLedgerClient, customerID int, currency string, netCredit, feeAmount float64, reference string) string {
+	// First, try to get the customer's wallet
+	wallet, err := wl.GetWalletByUserIDAndCurrency(ctx, customerID, currency)
+	if err != nil {
+		// If wallet not found, try to create one
+		createWalletReq := dto.CreateWalletRequest{
+			UserID:   customerID,
+			Currency: currency,
+		}
+		newWallet, createErr := wl.CreateWallet(ctx, createWalletReq)
+		if createErr != nil {
+			// Wallet ledger service unavailable - log but don't fail the transaction
+			fmt.Printf("Warning: failed to get or create wallet for customer %d: %v\n", customerID, createErr)
+			return walletCreditFailed
+		}
+		wallet = newWallet
+	}
+
+	updateBalanceReq := dto.UpdateBalanceRequest{
+		Amount:      int64(math.Round(netCredit * 100)),
+		Reference:   reference, // Link to the transaction (string)
+		Description: fmt.Sprintf("Credit for transaction %s (fee: %.2f)", reference, feeAmount),
+		Type:        "credit",
+	}
+
+	if _, err := wl.UpdateWalletBalance(ctx, wallet.ID, updateBalanceReq); err != nil {
+		// Log the error but don't fail the transaction
+		fmt.Printf("Warning: failed to update wallet balance: %v\n", err)
+		return walletCreditFailed
+	}
+	return walletCreditCredited
 }
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/kodra-pay/checkout-service/internal/dto"
+	"github.com/kodra-pay/checkout-service/internal/models"
+)
+
+// maxCustomFields keeps the hosted payment form short enough to complete
+const maxCustomFields = 20
+
+var (
+	ErrInvalidCustomFields = errors.New("invalid custom field values")
+	customFieldKeyPattern  = regexp.MustCompile(`^[a-z][a-z0-9_]{0,39}$`)
+)
+
+// validateCustomFieldDefinitions checks the fields a merchant defines on a link
+func validateCustomFieldDefinitions(fields []models.CustomField) error {
+	if len(fields) > maxCustomFields {
+		return fmt.Errorf("%w: at most %d custom fields", ErrInvalidPaymentLink, maxCustomFields)
+	}
+	seen := map[string]bool{}
+	for _, f := range fields {
+		if !customFieldKeyPattern.MatchString(f.Key) {
+			return fmt.Errorf("%w: custom field key %q must be 1-40 of a-z, 0-9 and _, starting with a letter", ErrInvalidPaymentLink, f.Key)
+		}
+		if seen[f.Key] {
+			return fmt.Errorf("%w: custom field key %q is used twice", ErrInvalidPaymentLink, f.Key)
+		}
+		seen[f.Key] = true
+		if strings.TrimSpace(f.Label) == "" {
+			return fmt.Errorf("%w: custom field %q needs a label", ErrInvalidPaymentLink, f.Key)
+		}
+		switch f.Type {
+		case models.CustomFieldText, models.CustomFieldNumber, models.CustomFieldCheckbox:
+		case models.CustomFieldDropdown:
+			if len(f.Options) == 0 {
+				return fmt.Errorf("%w: dropdown field %q needs options", ErrInvalidPaymentLink, f.Key)
+			}
+		default:
+			return fmt.Errorf("%w: custom field %q has unknown type %q", ErrInvalidPaymentLink, f.Key, f.Type)
+		}
+		if f.Pattern != "" {
+			if f.Type != models.CustomFieldText && f.Type != models.CustomFieldNumber {
+				return fmt.Errorf("%w: pattern applies to text and number fields only", ErrInvalidPaymentLink)
+			}
+			if _, err := regexp.Compile(f.Pattern); err != nil {
+				return fmt.Errorf("%w: custom field %q has an invalid pattern: %v", ErrInvalidPaymentLink, f.Key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateCustomFieldValues checks a payer's answers against the link's fields and returns them
+// normalised: numbers as float64, checkboxes as bool and everything else as trimmed strings.
+// Keys the link doesn't define are rejected.
+func validateCustomFieldValues(fields []models.CustomField, values map[string]interface{}) (map[string]interface{}, error) {
+	defined := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		defined[f.Key] = true
+	}
+	for key := range values {
+		if !defined[key] {
+			return nil, fmt.Errorf("%w: %q is not a field on this link", ErrInvalidCustomFields, key)
+		}
+	}
+
+	out := make(map[string]interface{}, len(fields))
+	for _, f := range fields {
+		raw, present := values[f.Key]
+		if s, ok := raw.(string); ok && strings.TrimSpace(s) == "" {
+			present = false
+		}
+		if !present || raw == nil {
+			if f.Required {
+				return nil, fmt.Errorf("%w: %s is required", ErrInvalidCustomFields, f.Label)
+			}
+			continue
+		}
+
+		var text string
+		switch v := raw.(type) {
+		case string:
+			text = strings.TrimSpace(v)
+		case float64:
+			text = strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			text = strconv.FormatBool(v)
+		default:
+			return nil, fmt.Errorf("%w: %s must be a single value", ErrInvalidCustomFields, f.Label)
+		}
+		switch f.Type {
+		case models.CustomFieldNumber:
+			n, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s must be a number", ErrInvalidCustomFields, f.Label)
+			}
+			out[f.Key] = n
+		case models.CustomFieldCheckbox:
+			b, err := strconv.ParseBool(text)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s must be true or false", ErrInvalidCustomFields, f.Label)
+			}
+			if f.Required && !b {
+				return nil, fmt.Errorf("%w: %s must be ticked", ErrInvalidCustomFields, f.Label)
+			}
+			out[f.Key] = b
+		case models.CustomFieldDropdown:
+			if !containsString(f.Options, text) {
+				return nil, fmt.Errorf("%w: %s must be one of %s", ErrInvalidCustomFields, f.Label, strings.Join(f.Options, ", "))
+			}
+			out[f.Key] = text
+		default:
+			out[f.Key] = text
+		}
+		if f.Pattern != "" && !regexp.MustCompile(f.Pattern).MatchString(text) {
+			return nil, fmt.Errorf("%w: %s is not in the expected format", ErrInvalidCustomFields, f.Label)
+		}
+	}
+	return out, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func toModelCustomFields(fields []dto.CustomField) []models.CustomField {
+	out := make([]models.CustomField, 0, len(fields))
+	for _, f := range fields {
+		out = append(out, models.CustomField{
+			Key:      strings.TrimSpace(f.Key),
+			Label:    strings.TrimSpace(f.Label),
+			Type:     f.Type,
+			Required: f.Required,
+			Pattern:  f.Pattern,
+			Options:  f.Options,
+		})
+	}
+	return out
+}
+
+func toDTOCustomFields(fields []models.CustomField) []dto.CustomField {
+	out := make([]dto.CustomField, 0, len(fields))
+	for _, f := range fields {
+		out = append(out, dto.CustomField{
+			Key:      f.Key,
+			Label:    f.Label,
+			Type:     f.Type,
+			Required: f.Required,
+			Pattern:  f.Pattern,
+			Options:  f.Options,
+		})
+	}
+	return out
+}
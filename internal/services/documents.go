@@ -0,0 +1,382 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+
+	"github.com/kodra-pay/checkout-service/internal/models"
+	"github.com/kodra-pay/checkout-service/internal/repositories"
+)
+
+// ErrReceiptUnavailable is returned for payments that have not settled, e.g. ones held for review
+var ErrReceiptUnavailable = errors.New("a receipt is only available once the payment has been paid")
+
+// Page geometry in millimetres (A4 portrait)
+const (
+	docMargin     = 18.0
+	docWidth      = 210.0 - 2*docMargin
+	docLogoWidth  = 40.0
+	docLogoHeight = 20.0
+)
+
+// DocumentService renders payment receipts and invoices as PDFs, in-process, with the
+// merchant's branding
+type DocumentService struct {
+	payments CheckoutStore
+	links    PaymentLinkRepository
+	invoices *repositories.InvoiceRepository
+	branding *BrandingService
+	baseURL  string
+}
+
+func NewDocumentService(payments CheckoutStore, links PaymentLinkRepository, invoices *repositories.InvoiceRepository, branding *BrandingService, baseURL string) *DocumentService {
+	return &DocumentService{payments: payments, links: links, invoices: invoices, branding: branding, baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// docLine is one row of the items table; amounts are in currency units
+type docLine struct {
+	Description string
+	Quantity    int64
+	UnitAmount  float64
+	Amount      float64
+}
+
+// docTotal is one row of the totals block under the items table
+type docTotal struct {
+	Label  string
+	Amount float64
+	Bold   bool
+}
+
+// Receipt renders proof of payment for a paid checkout payment. Payments made through an
+// invoice's link list the invoice's line items and taxes; others show a single line.
+// Payments belonging to another merchant are reported as not found.
+func (s *DocumentService) Receipt(ctx context.Context, reference string, merchantID int) ([]byte, error) {
+	p, err := s.payments.GetPaymentByReference(ctx, reference)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && p.MerchantID != merchantID) {
+		return nil, ErrPaymentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+	if p.Status != "paid" {
+		return nil, ErrReceiptUnavailable
+	}
+
+	description := "Payment"
+	var link *models.PaymentLink
+	var invoice *models.Invoice
+	if p.PaymentLinkID != nil {
+		if link, err = s.links.GetByID(ctx, *p.PaymentLinkID); err != nil {
+			fmt.Printf("Warning: failed to load payment link %d for receipt %s: %v\n", *p.PaymentLinkID, reference, err)
+			link = nil
+		} else if link.Description != "" {
+			description = link.Description
+		}
+		if invoice, err = s.invoices.GetByPaymentLink(ctx, *p.PaymentLinkID); err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				fmt.Printf("Warning: failed to load invoice for receipt %s: %v\n", reference, err)
+			}
+			invoice = nil
+		}
+	} else if p.SessionID != nil {
+		if cs, err := s.payments.GetSession(ctx, *p.SessionID); err == nil && cs.Description != "" {
+			description = cs.Description
+		}
+	}
+
+	doc := s.newDocument(ctx, p.MerchantID, "RECEIPT")
+	details := [][2]string{
+		{"Reference", p.TransactionReference},
+		{"Date paid", p.CreatedAt.UTC().Format("2 Jan 2006 15:04 MST")},
+		{"Status", "Paid"},
+	}
+	if p.CustomerEmail != "" {
+		details = append(details, [2]string{"Paid by", p.CustomerEmail})
+	}
+	if p.PaymentMethod != "" {
+		details = append(details, [2]string{"Payment method", p.PaymentMethod})
+	}
+	if invoice != nil {
+		details = append(details, [2]string{"Invoice", invoice.DisplayNumber()})
+	}
+	details = append(details, customFieldDetails(link, p.CustomFields)...)
+	doc.details(details)
+
+	var lines []docLine
+	var totals []docTotal
+	if invoice != nil {
+		lines, totals = invoiceLines(invoice)
+	} else {
+		lines = []docLine{{Description: description, Quantity: 1, UnitAmount: p.Amount, Amount: p.Amount}}
+	}
+	totals = append(totals,
+		docTotal{Label: "Amount paid", Amount: p.Amount, Bold: true},
+		docTotal{Label: "Processing fee (borne by merchant)", Amount: p.FeeAmount},
+		docTotal{Label: "Merchant receives", Amount: p.NetAmount},
+	)
+	doc.items(p.Currency, lines, totals)
+	doc.note("Thank you for your payment. Quote the reference above in any enquiry about this payment.")
+	return doc.output()
+}
+
+// Invoice renders one of merchantID's invoices in any status; unpaid invoices carry the link
+// to pay them online
+func (s *DocumentService) Invoice(ctx context.Context, id, merchantID int) ([]byte, error) {
+	inv, err := s.invoices.GetByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && inv.MerchantID != merchantID) {
+		return nil, ErrInvoiceNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invoice: %w", err)
+	}
+
+	doc := s.newDocument(ctx, inv.MerchantID, "INVOICE")
+	number := inv.DisplayNumber()
+	if number == "" {
+		number = "Draft"
+	}
+	issued := inv.CreatedAt
+	if inv.FinalizedAt != nil {
+		issued = *inv.FinalizedAt
+	}
+	billTo := inv.CustomerName
+	if inv.CustomerEmail != "" {
+		billTo += " <" + inv.CustomerEmail + ">"
+	}
+	details := [][2]string{
+		{"Invoice number", number},
+		{"Issued", issued.UTC().Format("2 Jan 2006")},
+		{"Due", inv.DueDate.Format("2 Jan 2006")},
+		{"Bill to", billTo},
+		{"Status", strings.ToUpper(inv.Status[:1]) + inv.Status[1:]},
+	}
+	if inv.PaidAt != nil {
+		details = append(details, [2]string{"Paid", inv.PaidAt.UTC().Format("2 Jan 2006 15:04 MST")})
+	}
+	if inv.TransactionReference != "" {
+		details = append(details, [2]string{"Payment reference", inv.TransactionReference})
+	}
+	doc.details(details)
+
+	lines, totals := invoiceLines(inv)
+	doc.items(inv.Currency, lines, totals)
+	if inv.Memo != "" {
+		doc.note(inv.Memo)
+	}
+	if inv.PaymentLinkID != nil && s.baseURL != "" && (inv.Status == models.InvoiceSent || inv.Status == models.InvoiceOverdue) {
+		doc.note("Pay online: " + paymentLinkURL(s.baseURL, &models.PaymentLink{ID: *inv.PaymentLinkID}))
+	}
+	return doc.output()
+}
+
+func invoiceLines(inv *models.Invoice) ([]docLine, []docTotal) {
+	lines := make([]docLine, 0, len(inv.LineItems))
+	for _, item := range inv.LineItems {
+		lines = append(lines, docLine{
+			Description: item.Description,
+			Quantity:    item.Quantity,
+			UnitAmount:  float64(item.UnitAmount),
+			Amount:      float64(item.Amount),
+		})
+	}
+	totals := []docTotal{{Label: "Subtotal", Amount: float64(inv.Subtotal)}}
+	for _, tax := range inv.TaxLines {
+		rate := strconv.FormatFloat(tax.Rate, 'f', -1, 64)
+		totals = append(totals, docTotal{Label: fmt.Sprintf("%s (%s%%)", tax.Name, rate), Amount: float64(tax.Amount)})
+	}
+	totals = append(totals, docTotal{Label: "Total", Amount: float64(inv.Total), Bold: true})
+	return lines, totals
+}
+
+// customFieldDetails lists the payer's answers, labelled from the link's field definitions
+// when they are available, in a stable order
+func customFieldDetails(link *models.PaymentLink, values map[string]interface{}) [][2]string {
+	labels := map[string]string{}
+	if link != nil {
+		for _, f := range link.CustomFields {
+			labels[f.Key] = f.Label
+		}
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var rows [][2]string
+	for _, k := range keys {
+		label := labels[k]
+		if label == "" {
+			label = k
+		}
+		value := fmt.Sprint(values[k])
+		if b, ok := values[k].(bool); ok {
+			value = map[bool]string{true: "Yes", false: "No"}[b]
+		}
+		rows = append(rows, [2]string{label, value})
+	}
+	return rows
+}
+
+// document wraps an fpdf page with the layout shared by receipts and invoices
+type document struct {
+	pdf *fpdf.Fpdf
+	tr  func(string) string
+}
+
+// newDocument starts a page headed by the merchant's logo and name and the document title
+func (s *DocumentService) newDocument(ctx context.Context, merchantID int, title string) *document {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(docMargin, docMargin, docMargin)
+	pdf.SetAutoPageBreak(true, docMargin)
+	pdf.SetCreator("Kodra Pay checkout", false)
+	pdf.AddPage()
+	d := &document{pdf: pdf, tr: pdf.UnicodeTranslatorFromDescriptor("")}
+
+	name := fmt.Sprintf("Merchant #%d", merchantID)
+	top := docMargin
+	textY := top
+	if b := s.branding.find(ctx, merchantID); b != nil {
+		if b.DisplayName != "" {
+			name = b.DisplayName
+		}
+		if h := d.logo(b); h > 0 {
+			textY = top + h + 4
+		}
+	}
+	pdf.SetTitle(title+" - "+name, true)
+
+	pdf.SetFont("Helvetica", "B", 22)
+	pdf.SetTextColor(40, 40, 40)
+	pdf.SetXY(docMargin, top)
+	pdf.CellFormat(docWidth, 10, title, "", 0, "R", false, 0, "")
+
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.SetXY(docMargin, textY)
+	pdf.CellFormat(docWidth, 7, d.tr(name), "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+	d.rule()
+	return d
+}
+
+// logo draws the merchant's logo in the top left, scaled to fit, and returns its height.
+// Logos fpdf cannot read (e.g. interlaced PNGs) are skipped.
+func (d *document) logo(b *models.MerchantBranding) float64 {
+	if len(b.Logo) == 0 {
+		return 0
+	}
+	imageType := "PNG"
+	if b.LogoType == "image/jpeg" {
+		imageType = "JPG"
+	}
+	name := fmt.Sprintf("logo-%d", b.MerchantID)
+	opts := fpdf.ImageOptions{ImageType: imageType}
+	info := d.pdf.RegisterImageOptionsReader(name, opts, bytes.NewReader(b.Logo))
+	if d.pdf.Err() || info == nil || info.Width() <= 0 || info.Height() <= 0 {
+		fmt.Printf("Warning: logo of merchant %d cannot be embedded in a PDF: %v\n", b.MerchantID, d.pdf.Error())
+		d.pdf.ClearError()
+		return 0
+	}
+	scale := math.Min(docLogoWidth/info.Width(), docLogoHeight/info.Height())
+	w, h := info.Width()*scale, info.Height()*scale
+	d.pdf.ImageOptions(name, docMargin, docMargin, w, h, false, opts, 0, "")
+	return h
+}
+
+func (d *document) rule() {
+	y := d.pdf.GetY()
+	d.pdf.SetDrawColor(200, 200, 200)
+	d.pdf.Line(docMargin, y, docMargin+docWidth, y)
+	d.pdf.Ln(4)
+}
+
+// details prints label/value pairs, one per row
+func (d *document) details(rows [][2]string) {
+	for _, row := range rows {
+		d.pdf.SetFont("Helvetica", "", 10)
+		d.pdf.SetTextColor(110, 110, 110)
+		d.pdf.CellFormat(45, 6, d.tr(row[0]), "", 0, "L", false, 0, "")
+		d.pdf.SetTextColor(40, 40, 40)
+		d.pdf.MultiCell(docWidth-45, 6, d.tr(row[1]), "", "L", false)
+	}
+	d.pdf.Ln(4)
+}
+
+// items prints the line items table followed by the right-aligned totals
+func (d *document) items(currency string, lines []docLine, totals []docTotal) {
+	widths := []float64{docWidth - 85, 15, 35, 35}
+	d.pdf.SetFont("Helvetica", "B", 10)
+	d.pdf.SetFillColor(242, 242, 242)
+	d.pdf.SetTextColor(40, 40, 40)
+	for i, heading := range []string{"Description", "Qty", "Unit price", "Amount"} {
+		align := "R"
+		if i == 0 {
+			align = "L"
+		}
+		d.pdf.CellFormat(widths[i], 8, heading, "B", 0, align, true, 0, "")
+	}
+	d.pdf.Ln(-1)
+
+	d.pdf.SetFont("Helvetica", "", 10)
+	for _, line := range lines {
+		d.pdf.CellFormat(widths[0], 7, d.tr(line.Description), "", 0, "L", false, 0, "")
+		d.pdf.CellFormat(widths[1], 7, strconv.FormatInt(line.Quantity, 10), "", 0, "R", false, 0, "")
+		d.pdf.CellFormat(widths[2], 7, formatMoney(currency, line.UnitAmount), "", 0, "R", false, 0, "")
+		d.pdf.CellFormat(widths[3], 7, formatMoney(currency, line.Amount), "", 1, "R", false, 0, "")
+	}
+	d.pdf.Ln(2)
+	d.rule()
+
+	for _, t := range totals {
+		style := ""
+		if t.Bold {
+			style = "B"
+		}
+		d.pdf.SetFont("Helvetica", style, 10)
+		d.pdf.CellFormat(docWidth-35, 7, d.tr(t.Label), "", 0, "R", false, 0, "")
+		d.pdf.CellFormat(35, 7, formatMoney(currency, t.Amount), "", 1, "R", false, 0, "")
+	}
+	d.pdf.Ln(6)
+}
+
+func (d *document) note(text string) {
+	d.pdf.SetFont("Helvetica", "", 9)
+	d.pdf.SetTextColor(110, 110, 110)
+	d.pdf.MultiCell(docWidth, 5, d.tr(text), "", "L", false)
+	d.pdf.Ln(2)
+}
+
+func (d *document) output() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := d.pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to render PDF: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// formatMoney renders an amount as e.g. "NGN 1,234,567.50"
+func formatMoney(currency string, amount float64) string {
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	s := strconv.FormatFloat(amount, 'f', 2, 64)
+	whole, frac := s[:len(s)-3], s[len(s)-3:]
+	var b strings.Builder
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(r)
+	}
+	return strings.TrimSpace(currency + " " + sign + b.String() + frac)
+}
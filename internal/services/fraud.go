@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kodra-pay/checkout-service/internal/clients"
+	"github.com/kodra-pay/checkout-service/internal/dto"
+	"github.com/kodra-pay/checkout-service/internal/models"
+	"github.com/kodra-pay/checkout-service/internal/repositories"
+)
+
+// Where a fraud decision came from
+const (
+	fraudSourceService   = "fraud_service"
+	fraudSourceLocal     = "local_rules"
+	fraudSourceFailOpen  = "fail_open"
+	fraudSourceVelocity  = "velocity"
+	fraudSourceBlocklist = "blocklist"
+	fraudSourceCountry   = "country_restriction"
+)
+
+var ErrInvalidFraudPolicy = errors.New("mode must be one of closed, open or local and max_amount must not be negative")
+
+// fraudScreenResult is the verdict Pay acts on, whether it came from fraud-service or a fail policy
+type fraudScreenResult struct {
+	Decision   dto.FraudDecision
+	Source     string
+	Unscreened bool // true when fraud-service never saw the payment
+	Policy     string
+}
+
+// LocalFraudRules is the in-process evaluator used when a merchant's policy falls back to local rules
+type LocalFraudRules struct {
+	FlagAmount float64 // currency units; 0 disables the rule
+	DenyAmount float64 // currency units; 0 disables the rule
+}
+
+// ValidateFraudThresholds checks the fail-open ceiling and local rule amounts fit together: the
+// flag amount must be below the deny amount, and a ceiling below the flag amount would fail
+// every payment the flag rule could catch closed, so the rule would never fire
+func ValidateFraudThresholds(failOpenMaxAmount float64, local LocalFraudRules) error {
+	if local.FlagAmount > 0 && local.DenyAmount > 0 && local.FlagAmount >= local.DenyAmount {
+		return fmt.Errorf("local flag amount %.2f must be below the deny amount %.2f", local.FlagAmount, local.DenyAmount)
+	}
+	if failOpenMaxAmount > 0 && local.FlagAmount > 0 && failOpenMaxAmount < local.FlagAmount {
+		return fmt.Errorf("fail-open max amount %.2f must be at least the local flag amount %.2f", failOpenMaxAmount, local.FlagAmount)
+	}
+	return nil
+}
+
+func (r LocalFraudRules) Evaluate(req dto.FraudCheckRequest) dto.FraudDecision {
+	switch {
+	case r.DenyAmount > 0 && req.Amount >= r.DenyAmount:
+		return dto.FraudDecision{
+			OverallScore: 90,
+			Decision:     "deny",
+			Reasons:      []string{fmt.Sprintf("local rules: amount %.2f at or above deny threshold %.2f", req.Amount, r.DenyAmount)},
+		}
+	case r.FlagAmount > 0 && req.Amount >= r.FlagAmount:
+		return dto.FraudDecision{
+			OverallScore: 60,
+			Decision:     "flag",
+			Reasons:      []string{fmt.Sprintf("local rules: amount %.2f at or above review threshold %.2f", req.Amount, r.FlagAmount)},
+		}
+	case req.Origin == "":
+		return dto.FraudDecision{
+			OverallScore: 50,
+			Decision:     "flag",
+			Reasons:      []string{"local rules: payment has no client origin"},
+		}
+	}
+	return dto.FraudDecision{
+		OverallScore: 10,
+		Decision:     "approve",
+		Reasons:      []string{"local rules: no rule matched"},
+	}
+}
+
+// FraudScreener calls fraud-service and applies the merchant's fail policy when it is unavailable
+type FraudScreener struct {
+	client        clients.FraudClient
+	policies      *repositories.FraudPolicyRepository
+	defaultPolicy models.MerchantFraudPolicy
+	local         LocalFraudRules
+}
+
+func NewFraudScreener(client clients.FraudClient, policies *repositories.FraudPolicyRepository, defaultMode string, defaultMaxAmount float64, local LocalFraudRules) *FraudScreener {
+	if !validFraudFailMode(defaultMode) {
+		fmt.Printf("Warning: unknown fraud fail policy %q, defaulting to %s\n", defaultMode, models.FraudFailClosed)
+		defaultMode = models.FraudFailClosed
+	}
+	return &FraudScreener{
+		client:   client,
+		policies: policies,
+		defaultPolicy: models.MerchantFraudPolicy{
+			Mode:      defaultMode,
+			MaxAmount: defaultMaxAmount,
+		},
+		local: local,
+	}
+}
+
+// Screen returns fraud-service's decision, or the fail policy's decision when fraud-service errors.
+// An error is returned only when the policy is to fail closed.
+func (f *FraudScreener) Screen(ctx context.Context, req dto.FraudCheckRequest, merchantID int) (fraudScreenResult, error) {
+	decision, err := f.client.CheckTransaction(ctx, req)
+	if err == nil {
+		return fraudScreenResult{Decision: decision, Source: fraudSourceService}, nil
+	}
+
+	policy := f.policyFor(ctx, merchantID)
+	if policy.Mode == models.FraudFailClosed || (policy.MaxAmount > 0 && req.Amount > policy.MaxAmount) {
+		return fraudScreenResult{}, fmt.Errorf("fraud check failed: %w", err)
+	}
+	fmt.Printf("Warning: fraud service unavailable, applying %s policy to %s: %v\n", policy.Mode, req.TransactionReference, err)
+
+	result := fraudScreenResult{Unscreened: true, Policy: policy.Mode}
+	if policy.Mode == models.FraudFailLocal {
+		result.Decision = f.local.Evaluate(req)
+		result.Source = fraudSourceLocal
+	} else {
+		result.Decision = dto.FraudDecision{
+			Decision: "approve",
+			Reasons:  []string{"fraud service unavailable; accepted under fail-open policy"},
+		}
+		result.Source = fraudSourceFailOpen
+	}
+	return result, nil
+}
+
+func (f *FraudScreener) policyFor(ctx context.Context, merchantID int) models.MerchantFraudPolicy {
+	p, err := f.policies.GetByMerchant(ctx, merchantID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			fmt.Printf("Warning: failed to load fraud policy for merchant %d, using default: %v\n", merchantID, err)
+		}
+		return f.defaultPolicy
+	}
+	return *p
+}
+
+// GetPolicy returns the policy in force for a merchant and whether it is the service default
+func (f *FraudScreener) GetPolicy(ctx context.Context, merchantID int) (dto.FraudPolicyResponse, error) {
+	p, err := f.policies.GetByMerchant(ctx, merchantID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return dto.FraudPolicyResponse{
+			MerchantID: merchantID,
+			Mode:       f.defaultPolicy.Mode,
+			MaxAmount:  f.defaultPolicy.MaxAmount,
+			IsDefault:  true,
+		}, nil
+	}
+	if err != nil {
+		return dto.FraudPolicyResponse{}, fmt.Errorf("failed to get fraud policy: %w", err)
+	}
+	return toFraudPolicyResponse(p), nil
+}
+
+func (f *FraudScreener) SetPolicy(ctx context.Context, merchantID int, req dto.FraudPolicyRequest) (dto.FraudPolicyResponse, error) {
+	if !validFraudFailMode(req.Mode) || req.MaxAmount < 0 {
+		return dto.FraudPolicyResponse{}, ErrInvalidFraudPolicy
+	}
+	p := &models.MerchantFraudPolicy{
+		MerchantID: merchantID,
+		Mode:       req.Mode,
+		MaxAmount:  req.MaxAmount,
+	}
+	if err := f.policies.Upsert(ctx, p); err != nil {
+		return dto.FraudPolicyResponse{}, fmt.Errorf("failed to save fraud policy: %w", err)
+	}
+	return toFraudPolicyResponse(p), nil
+}
+
+func validFraudFailMode(mode string) bool {
+	switch mode {
+	case models.FraudFailClosed, models.FraudFailOpen, models.FraudFailLocal:
+		return true
+	}
+	return false
+}
+
+func toFraudPolicyResponse(p *models.MerchantFraudPolicy) dto.FraudPolicyResponse {
+	return dto.FraudPolicyResponse{
+		MerchantID: p.MerchantID,
+		Mode:       p.Mode,
+		MaxAmount:  p.MaxAmount,
+		UpdatedAt:  p.UpdatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kodra-pay/checkout-service/internal/dto"
+	"github.com/kodra-pay/checkout-service/internal/models"
+	"github.com/kodra-pay/checkout-service/internal/repositories"
+)
+
+var ErrInvalidCountryCode = errors.New("countries must be ISO 3166-1 alpha-2 codes")
+
+// GeoService resolves client countries and enforces merchant country restrictions
+type GeoService struct {
+	resolver *repositories.GeoIPResolver
+	repo     *repositories.CountryRuleRepository
+}
+
+func NewGeoService(resolver *repositories.GeoIPResolver, repo *repositories.CountryRuleRepository) *GeoService {
+	return &GeoService{resolver: resolver, repo: repo}
+}
+
+// Check resolves the client's country and returns a denial reason when the merchant doesn't accept it.
+// A failure to load the merchant's rules is logged and the payment allowed.
+func (s *GeoService) Check(ctx context.Context, merchantID int, ip string) (country, denyReason string) {
+	country = s.resolver.Country(ip)
+
+	rules, err := s.repo.GetByMerchant(ctx, merchantID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			fmt.Printf("Warning: failed to load country rules for merchant %d: %v\n", merchantID, err)
+		}
+		return country, ""
+	}
+
+	if country == "" {
+		if rules.BlockUnknown {
+			return country, "country restriction: client country could not be determined"
+		}
+		return country, ""
+	}
+	for _, c := range rules.BlockedCountries {
+		if c == country {
+			return country, fmt.Sprintf("country restriction: payments from %s are blocked", country)
+		}
+	}
+	if len(rules.AllowedCountries) > 0 {
+		for _, c := range rules.AllowedCountries {
+			if c == country {
+				return country, ""
+			}
+		}
+		return country, fmt.Sprintf("country restriction: payments from %s are not allowed", country)
+	}
+	return country, ""
+}
+
+// Reload re-reads the GeoIP database from disk
+func (s *GeoService) Reload() error {
+	return s.resolver.Reload()
+}
+
+func (s *GeoService) GetRules(ctx context.Context, merchantID int) (dto.CountryRulesResponse, error) {
+	rules, err := s.repo.GetByMerchant(ctx, merchantID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return dto.CountryRulesResponse{MerchantID: merchantID, AllowedCountries: []string{}, BlockedCountries: []string{}}, nil
+	}
+	if err != nil {
+		return dto.CountryRulesResponse{}, fmt.Errorf("failed to get country rules: %w", err)
+	}
+	return toCountryRulesResponse(rules), nil
+}
+
+func (s *GeoService) SetRules(ctx context.Context, merchantID int, req dto.CountryRulesRequest) (dto.CountryRulesResponse, error) {
+	allowed, ok := normalizeCountries(req.AllowedCountries)
+	if !ok {
+		return dto.CountryRulesResponse{}, ErrInvalidCountryCode
+	}
+	blocked, ok := normalizeCountries(req.BlockedCountries)
+	if !ok {
+		return dto.CountryRulesResponse{}, ErrInvalidCountryCode
+	}
+	rules := &models.MerchantCountryRules{
+		MerchantID:       merchantID,
+		AllowedCountries: allowed,
+		BlockedCountries: blocked,
+		BlockUnknown:     req.BlockUnknown,
+	}
+	if err := s.repo.Upsert(ctx, rules); err != nil {
+		return dto.CountryRulesResponse{}, fmt.Errorf("failed to save country rules: %w", err)
+	}
+	return toCountryRulesResponse(rules), nil
+}
+
+func normalizeCountries(codes []string) ([]string, bool) {
+	out := []string{}
+	for _, c := range codes {
+		c = strings.ToUpper(strings.TrimSpace(c))
+		if len(c) != 2 || c[0] < 'A' || c[0] > 'Z' || c[1] < 'A' || c[1] > 'Z' {
+			return nil, false
+		}
+		out = append(out, c)
+	}
+	return out, true
+}
+
+func toCountryRulesResponse(rules *models.MerchantCountryRules) dto.CountryRulesResponse {
+	return dto.CountryRulesResponse{
+		MerchantID:       rules.MerchantID,
+		AllowedCountries: rules.AllowedCountries,
+		BlockedCountries: rules.BlockedCountries,
+		BlockUnknown:     rules.BlockUnknown,
+		UpdatedAt:        rules.UpdatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,249 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/kodra-pay/checkout-service/internal/dto"
+	"github.com/kodra-pay/checkout-service/internal/models"
+	"github.com/kodra-pay/checkout-service/internal/repositories"
+)
+
+var (
+	ErrInvoiceNotFound    = errors.New("invoice not found")
+	ErrInvalidInvoice     = errors.New("invalid invoice")
+	ErrInvoiceNotDraft    = errors.New("only draft invoices can be finalized")
+	ErrInvoiceNotVoidable = errors.New("paid and void invoices cannot be voided")
+)
+
+// InvoiceService manages invoices and the single-use payment links they are paid through
+type InvoiceService struct {
+	repo    *repositories.InvoiceRepository
+	baseURL string // hosted checkout origin used to build payment URLs
+}
+
+func NewInvoiceService(repo *repositories.InvoiceRepository, baseURL string) *InvoiceService {
+	return &InvoiceService{repo: repo, baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// Create validates the request, computes line, tax and invoice totals and stores a draft
+func (s *InvoiceService) Create(ctx context.Context, req dto.InvoiceCreateRequest) (dto.InvoiceResponse, error) {
+	if req.MerchantID <= 0 || strings.TrimSpace(req.CustomerName) == "" || req.Currency == "" {
+		return dto.InvoiceResponse{}, fmt.Errorf("%w: merchant_id, customer_name and currency are required", ErrInvalidInvoice)
+	}
+	dueDate, err := time.Parse("2006-01-02", req.DueDate)
+	if err != nil {
+		return dto.InvoiceResponse{}, fmt.Errorf("%w: due_date must be YYYY-MM-DD", ErrInvalidInvoice)
+	}
+	if len(req.LineItems) == 0 {
+		return dto.InvoiceResponse{}, fmt.Errorf("%w: at least one line item is required", ErrInvalidInvoice)
+	}
+
+	inv := &models.Invoice{
+		MerchantID:    req.MerchantID,
+		CustomerName:  strings.TrimSpace(req.CustomerName),
+		CustomerEmail: strings.TrimSpace(req.CustomerEmail),
+		Currency:      req.Currency,
+		Memo:          req.Memo,
+		DueDate:       dueDate,
+		Status:        models.InvoiceDraft,
+	}
+	for i, item := range req.LineItems {
+		if strings.TrimSpace(item.Description) == "" || item.Quantity <= 0 || item.UnitAmount <= 0 {
+			return dto.InvoiceResponse{}, fmt.Errorf("%w: line item %d needs a description, a positive quantity and a positive unit_amount", ErrInvalidInvoice, i+1)
+		}
+		amount := item.Quantity * item.UnitAmount
+		inv.LineItems = append(inv.LineItems, models.InvoiceLineItem{
+			Description: strings.TrimSpace(item.Description),
+			Quantity:    item.Quantity,
+			UnitAmount:  item.UnitAmount,
+			Amount:      amount,
+		})
+		inv.Subtotal += amount
+	}
+	inv.TaxLines = []models.InvoiceTaxLine{}
+	for _, tax := range req.TaxLines {
+		if strings.TrimSpace(tax.Name) == "" || tax.Rate <= 0 || tax.Rate > 100 {
+			return dto.InvoiceResponse{}, fmt.Errorf("%w: tax lines need a name and a rate between 0 and 100", ErrInvalidInvoice)
+		}
+		amount := int64(math.Floor(float64(inv.Subtotal)*tax.Rate/100 + 0.5))
+		inv.TaxLines = append(inv.TaxLines, models.InvoiceTaxLine{Name: strings.TrimSpace(tax.Name), Rate: tax.Rate, Amount: amount})
+		inv.TaxTotal += amount
+	}
+	inv.Total = inv.Subtotal + inv.TaxTotal
+
+	if err := s.repo.Create(ctx, inv); err != nil {
+		return dto.InvoiceResponse{}, fmt.Errorf("failed to create invoice: %w", err)
+	}
+	return s.toResponse(inv), nil
+}
+
+func (s *InvoiceService) Get(ctx context.Context, id int) (dto.InvoiceResponse, error) {
+	inv, err := s.get(ctx, id)
+	if err != nil {
+		return dto.InvoiceResponse{}, err
+	}
+	return s.toResponse(inv), nil
+}
+
+func (s *InvoiceService) List(ctx context.Context, merchantID int, status string, limit int) (dto.InvoiceListResponse, error) {
+	invoices, err := s.repo.List(ctx, merchantID, status, limit)
+	if err != nil {
+		return dto.InvoiceListResponse{}, fmt.Errorf("failed to list invoices: %w", err)
+	}
+	resp := dto.InvoiceListResponse{Invoices: []dto.InvoiceResponse{}}
+	for _, inv := range invoices {
+		resp.Invoices = append(resp.Invoices, s.toResponse(inv))
+	}
+	return resp, nil
+}
+
+// Finalize assigns the next invoice number and creates the fixed, single-use payment link
+// the customer pays through. The invoice moves from draft to sent.
+func (s *InvoiceService) Finalize(ctx context.Context, id int) (dto.InvoiceResponse, error) {
+	inv, err := s.get(ctx, id)
+	if err != nil {
+		return dto.InvoiceResponse{}, err
+	}
+	if inv.Status != models.InvoiceDraft {
+		return dto.InvoiceResponse{}, ErrInvoiceNotDraft
+	}
+
+	total := inv.Total
+	singleUse := 1
+	link := &models.PaymentLink{
+		MerchantID: inv.MerchantID,
+		Mode:       "fixed",
+		Amount:     &total,
+		Currency:   inv.Currency,
+		Status:     models.PaymentLinkActive,
+		MaxUses:    &singleUse,
+	}
+	err = s.repo.Finalize(ctx, inv, link)
+	if errors.Is(err, sql.ErrNoRows) {
+		return dto.InvoiceResponse{}, ErrInvoiceNotDraft
+	}
+	if err != nil {
+		if conflict := uniqueViolation(err); conflict != nil {
+			return dto.InvoiceResponse{}, fmt.Errorf("failed to create invoice payment link: %w", conflict)
+		}
+		return dto.InvoiceResponse{}, fmt.Errorf("failed to finalize invoice: %w", err)
+	}
+	return s.toResponse(inv), nil
+}
+
+// Void cancels an unpaid invoice; its payment link stops accepting payments
+func (s *InvoiceService) Void(ctx context.Context, id int) (dto.InvoiceResponse, error) {
+	if _, err := s.get(ctx, id); err != nil {
+		return dto.InvoiceResponse{}, err
+	}
+	inv, err := s.repo.Void(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return dto.InvoiceResponse{}, ErrInvoiceNotVoidable
+	}
+	if err != nil {
+		return dto.InvoiceResponse{}, fmt.Errorf("failed to void invoice: %w", err)
+	}
+	return s.toResponse(inv), nil
+}
+
+// MarkPaidByPaymentLink is called by checkout after a payment on a link succeeds, and by the
+// review queue once a flagged payment on one is approved
+func (s *InvoiceService) MarkPaidByPaymentLink(ctx context.Context, linkID int, reference string) error {
+	_, err := s.repo.MarkPaidByPaymentLink(ctx, linkID, reference)
+	return err
+}
+
+// ReopenByPaymentLink is called by the review queue when the payment that settled an invoice is rejected
+func (s *InvoiceService) ReopenByPaymentLink(ctx context.Context, linkID int, reference string) error {
+	_, err := s.repo.ReopenByPaymentLink(ctx, linkID, reference)
+	return err
+}
+
+// RunOverdue marks sent invoices past their due date as overdue every interval until ctx is cancelled
+func (s *InvoiceService) RunOverdue(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.repo.MarkOverdue(ctx)
+			if err != nil {
+				fmt.Printf("Warning: failed to mark overdue invoices: %v\n", err)
+			} else if n > 0 {
+				fmt.Printf("Info: marked %d invoices overdue\n", n)
+			}
+		}
+	}
+}
+
+// Authorize returns ErrInvoiceNotFound unless the invoice belongs to merchantID
+func (s *InvoiceService) Authorize(ctx context.Context, id, merchantID int) error {
+	inv, err := s.get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if inv.MerchantID != merchantID {
+		return ErrInvoiceNotFound
+	}
+	return nil
+}
+
+func (s *InvoiceService) get(ctx context.Context, id int) (*models.Invoice, error) {
+	inv, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvoiceNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invoice: %w", err)
+	}
+	return inv, nil
+}
+
+func (s *InvoiceService) toResponse(inv *models.Invoice) dto.InvoiceResponse {
+	resp := dto.InvoiceResponse{
+		ID:                   inv.ID,
+		MerchantID:           inv.MerchantID,
+		Number:               inv.DisplayNumber(),
+		CustomerName:         inv.CustomerName,
+		CustomerEmail:        inv.CustomerEmail,
+		Currency:             inv.Currency,
+		LineItems:            make([]dto.InvoiceLineItem, 0, len(inv.LineItems)),
+		TaxLines:             make([]dto.InvoiceTaxLine, 0, len(inv.TaxLines)),
+		Subtotal:             inv.Subtotal,
+		TaxTotal:             inv.TaxTotal,
+		Total:                inv.Total,
+		Memo:                 inv.Memo,
+		DueDate:              inv.DueDate.Format("2006-01-02"),
+		Status:               inv.Status,
+		PaymentLinkID:        inv.PaymentLinkID,
+		TransactionReference: inv.TransactionReference,
+		CreatedAt:            inv.CreatedAt.Format(time.RFC3339),
+	}
+	for _, item := range inv.LineItems {
+		resp.LineItems = append(resp.LineItems, dto.InvoiceLineItem(item))
+	}
+	for _, tax := range inv.TaxLines {
+		resp.TaxLines = append(resp.TaxLines, dto.InvoiceTaxLine(tax))
+	}
+	if inv.PaymentLinkID != nil && s.baseURL != "" {
+		resp.PaymentURL = paymentLinkURL(s.baseURL, &models.PaymentLink{ID: *inv.PaymentLinkID})
+	}
+	if inv.FinalizedAt != nil {
+		resp.FinalizedAt = inv.FinalizedAt.Format(time.RFC3339)
+	}
+	if inv.PaidAt != nil {
+		resp.PaidAt = inv.PaidAt.Format(time.RFC3339)
+	}
+	if inv.VoidedAt != nil {
+		resp.VoidedAt = inv.VoidedAt.Format(time.RFC3339)
+	}
+	return resp
+}
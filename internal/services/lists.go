@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/kodra-pay/checkout-service/internal/dto"
+	"github.com/kodra-pay/checkout-service/internal/models"
+	"github.com/kodra-pay/checkout-service/internal/repositories"
+)
+
+var (
+	ErrListEntryNotFound  = errors.New("list entry not found")
+	ErrDuplicateListEntry = errors.New("an entry for this value already exists in this list")
+	ErrInvalidListEntry   = errors.New("list_type must be block or allow, and value must be a valid email, ip, customer id or 6-8 digit card_bin for its kind")
+)
+
+// listSubject is what a payment is screened on
+type listSubject struct {
+	CustomerID int
+	Email      string
+	IP         string
+	CardBIN    string
+}
+
+// listScreenResult holds the blocklist entries a payment hit and whether it is allowlisted
+type listScreenResult struct {
+	Blocked []*models.ListEntry
+	Allowed bool
+}
+
+// ListService manages block and allow lists and screens payments against them
+type ListService struct {
+	repo *repositories.ListEntryRepository
+}
+
+func NewListService(repo *repositories.ListEntryRepository) *ListService {
+	return &ListService{repo: repo}
+}
+
+func (s *ListService) Create(ctx context.Context, req dto.ListEntryCreateRequest) (dto.ListEntryResponse, error) {
+	value, ok := normalizeListValue(req.Kind, req.Value)
+	if !ok || (req.ListType != models.ListBlock && req.ListType != models.ListAllow) {
+		return dto.ListEntryResponse{}, ErrInvalidListEntry
+	}
+	e := &models.ListEntry{
+		MerchantID: req.MerchantID,
+		ListType:   req.ListType,
+		Kind:       req.Kind,
+		Value:      value,
+		Reason:     req.Reason,
+		CreatedBy:  req.CreatedBy,
+		ExpiresAt:  req.ExpiresAt,
+	}
+	if err := s.repo.Create(ctx, e); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return dto.ListEntryResponse{}, ErrDuplicateListEntry
+		}
+		return dto.ListEntryResponse{}, fmt.Errorf("failed to create list entry: %w", err)
+	}
+	return toListEntryResponse(e), nil
+}
+
+func (s *ListService) Get(ctx context.Context, id int) (dto.ListEntryResponse, error) {
+	e, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return dto.ListEntryResponse{}, ErrListEntryNotFound
+	}
+	if err != nil {
+		return dto.ListEntryResponse{}, fmt.Errorf("failed to get list entry: %w", err)
+	}
+	return toListEntryResponse(e), nil
+}
+
+func (s *ListService) List(ctx context.Context, f repositories.ListEntryFilter) (dto.ListEntryListResponse, error) {
+	entries, err := s.repo.List(ctx, f)
+	if err != nil {
+		return dto.ListEntryListResponse{}, fmt.Errorf("failed to list entries: %w", err)
+	}
+	resp := dto.ListEntryListResponse{Entries: []dto.ListEntryResponse{}}
+	for _, e := range entries {
+		resp.Entries = append(resp.Entries, toListEntryResponse(e))
+	}
+	return resp, nil
+}
+
+func (s *ListService) Update(ctx context.Context, id int, req dto.ListEntryUpdateRequest) (dto.ListEntryResponse, error) {
+	e, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return dto.ListEntryResponse{}, ErrListEntryNotFound
+	}
+	if err != nil {
+		return dto.ListEntryResponse{}, fmt.Errorf("failed to get list entry: %w", err)
+	}
+
+	reason, expiresAt := e.Reason, e.ExpiresAt
+	if req.Reason != nil {
+		reason = *req.Reason
+	}
+	if req.NoExpiry {
+		expiresAt = nil
+	} else if req.ExpiresAt != nil {
+		expiresAt = req.ExpiresAt
+	}
+	updated, err := s.repo.Update(ctx, id, reason, expiresAt)
+	if err != nil {
+		return dto.ListEntryResponse{}, fmt.Errorf("failed to update list entry: %w", err)
+	}
+	return toListEntryResponse(updated), nil
+}
+
+func (s *ListService) Delete(ctx context.Context, id int) error {
+	err := s.repo.Delete(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrListEntryNotFound
+	}
+	return err
+}
+
+// Screen checks a payment against the merchant's and global lists. Lookup errors are logged
+// and treated as no match so a database hiccup doesn't block checkout.
+func (s *ListService) Screen(ctx context.Context, merchantID int, subject listSubject) listScreenResult {
+	var kinds, values []string
+	add := func(kind, raw string) {
+		if v, ok := normalizeListValue(kind, raw); ok {
+			kinds = append(kinds, kind)
+			values = append(values, v)
+		}
+	}
+	if subject.CustomerID != 0 {
+		add(models.ListKindCustomer, strconv.Itoa(subject.CustomerID))
+	}
+	add(models.ListKindEmail, subject.Email)
+	add(models.ListKindIP, subject.IP)
+	// Entries may hold a 6-digit BIN or a full 8-digit one
+	if len(subject.CardBIN) >= 6 {
+		add(models.ListKindCardBIN, subject.CardBIN[:6])
+		if len(subject.CardBIN) >= 8 {
+			add(models.ListKindCardBIN, subject.CardBIN[:8])
+		}
+	}
+
+	var result listScreenResult
+	if len(kinds) == 0 {
+		return result
+	}
+
+	blocked, err := s.repo.Match(ctx, merchantID, models.ListBlock, kinds, values)
+	if err != nil {
+		fmt.Printf("Warning: blocklist lookup failed: %v\n", err)
+	}
+	result.Blocked = blocked
+
+	allowed, err := s.repo.Match(ctx, merchantID, models.ListAllow, kinds, values)
+	if err != nil {
+		fmt.Printf("Warning: allowlist lookup failed: %v\n", err)
+	}
+	result.Allowed = len(allowed) > 0
+	return result
+}
+
+// Reasons describes the blocklist hits for the payment response
+func (r listScreenResult) Reasons() []string {
+	var reasons []string
+	for _, e := range r.Blocked {
+		reason := fmt.Sprintf("blocklist: %s %s", e.Kind, e.Value)
+		if e.Reason != "" {
+			reason += " (" + e.Reason + ")"
+		}
+		reasons = append(reasons, reason)
+	}
+	return reasons
+}
+
+// normalizeListValue canonicalises a value so list lookups are exact matches
+func normalizeListValue(kind, value string) (string, bool) {
+	value = strings.TrimSpace(value)
+	switch kind {
+	case models.ListKindEmail:
+		value = strings.ToLower(value)
+		return value, strings.Contains(value, "@")
+	case models.ListKindIP:
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return "", false
+		}
+		return ip.String(), true
+	case models.ListKindCustomer:
+		id, err := strconv.Atoi(value)
+		return strconv.Itoa(id), err == nil && id > 0
+	case models.ListKindCardBIN:
+		if len(value) < 6 || len(value) > 8 {
+			return "", false
+		}
+		for _, r := range value {
+			if r < '0' || r > '9' {
+				return "", false
+			}
+		}
+		return value, true
+	}
+	return "", false
+}
+
+func toListEntryResponse(e *models.ListEntry) dto.ListEntryResponse {
+	resp := dto.ListEntryResponse{
+		ID:         e.ID,
+		MerchantID: e.MerchantID,
+		ListType:   e.ListType,
+		Kind:       e.Kind,
+		Value:      e.Value,
+		Reason:     e.Reason,
+		CreatedBy:  e.CreatedBy,
+		CreatedAt:  e.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:  e.UpdatedAt.Format(time.RFC3339),
+	}
+	if e.ExpiresAt != nil {
+		resp.ExpiresAt = e.ExpiresAt.Format(time.RFC3339)
+	}
+	return resp
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kodra-pay/checkout-service/internal/dto"
+	"github.com/kodra-pay/checkout-service/internal/models"
+)
+
+var (
+	ErrInvalidMerchantLimits = errors.New("limits must not be negative, min_amount must not exceed max_amount, and currencies must be unique")
+	ErrLimitsUnavailable     = errors.New("merchant limits could not be checked")
+)
+
+// LimitError reports which merchant limit a payment breached
+type LimitError struct {
+	Limit   string // min_amount, max_amount, daily_volume, monthly_volume
+	Message string
+}
+
+func (e *LimitError) Error() string {
+	return e.Message
+}
+
+// MerchantLimitStore persists merchant limits and reports the volume they are checked against
+type MerchantLimitStore interface {
+	GetByMerchant(ctx context.Context, merchantID int) (*models.MerchantLimits, error)
+	Upsert(ctx context.Context, l *models.MerchantLimits) error
+	VolumeSince(ctx context.Context, merchantID int, currency string, since time.Time) (float64, error)
+}
+
+// MerchantLimitService stores merchant risk limits and enforces them on payments
+type MerchantLimitService struct {
+	repo     MerchantLimitStore
+	defaults models.MerchantLimits
+}
+
+// NewMerchantLimitService takes the limits applied to merchants that have none configured.
+// Zero values leave those merchants uncapped.
+func NewMerchantLimitService(repo MerchantLimitStore, defaults models.MerchantLimits) *MerchantLimitService {
+	return &MerchantLimitService{repo: repo, defaults: defaults}
+}
+
+func (s *MerchantLimitService) Get(ctx context.Context, merchantID int) (dto.MerchantLimitsResponse, error) {
+	l, isDefault, err := s.limitsFor(ctx, merchantID)
+	if err != nil {
+		return dto.MerchantLimitsResponse{}, err
+	}
+	resp := toMerchantLimitsResponse(l)
+	resp.MerchantID = merchantID
+	resp.IsDefault = isDefault
+	return resp, nil
+}
+
+func (s *MerchantLimitService) Set(ctx context.Context, merchantID int, req dto.MerchantLimitsRequest) (dto.MerchantLimitsResponse, error) {
+	l := &models.MerchantLimits{
+		MerchantID:    merchantID,
+		MinAmount:     req.MinAmount,
+		MaxAmount:     req.MaxAmount,
+		DailyVolume:   req.DailyVolume,
+		MonthlyVolume: req.MonthlyVolume,
+	}
+	if !validLimits(req.MinAmount, req.MaxAmount, req.DailyVolume, req.MonthlyVolume) {
+		return dto.MerchantLimitsResponse{}, ErrInvalidMerchantLimits
+	}
+	seen := map[string]bool{}
+	for _, cl := range req.Currencies {
+		currency := strings.ToUpper(strings.TrimSpace(cl.Currency))
+		if currency == "" || seen[currency] || !validLimits(cl.MinAmount, cl.MaxAmount, cl.DailyVolume, cl.MonthlyVolume) {
+			return dto.MerchantLimitsResponse{}, ErrInvalidMerchantLimits
+		}
+		seen[currency] = true
+		l.Currencies = append(l.Currencies, models.CurrencyLimit{
+			Currency:      currency,
+			MinAmount:     cl.MinAmount,
+			MaxAmount:     cl.MaxAmount,
+			DailyVolume:   cl.DailyVolume,
+			MonthlyVolume: cl.MonthlyVolume,
+		})
+	}
+	if err := s.repo.Upsert(ctx, l); err != nil {
+		return dto.MerchantLimitsResponse{}, fmt.Errorf("failed to save merchant limits: %w", err)
+	}
+	return toMerchantLimitsResponse(l), nil
+}
+
+// Enforce returns a *LimitError when a payment of amount would breach the merchant's limits for
+// currency, and an error wrapping ErrLimitsUnavailable when the limits or volume can't be read.
+//
+// The volume limits are soft: they are checked against payments already recorded, so concurrent
+// payments can each pass and together overshoot the limit, as can payments whose RecordPayment
+// failed.
+func (s *MerchantLimitService) Enforce(ctx context.Context, merchantID int, currency string, amount float64) error {
+	l, _, err := s.limitsFor(ctx, merchantID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrLimitsUnavailable, err)
+	}
+	eff := effectiveLimits(l, currency)
+
+	if eff.MinAmount > 0 && amount < eff.MinAmount {
+		return &LimitError{Limit: "min_amount", Message: fmt.Sprintf("amount %.2f %s is below the merchant minimum of %.2f", amount, currency, eff.MinAmount)}
+	}
+	if eff.MaxAmount > 0 && amount > eff.MaxAmount {
+		return &LimitError{Limit: "max_amount", Message: fmt.Sprintf("amount %.2f %s exceeds the merchant maximum of %.2f", amount, currency, eff.MaxAmount)}
+	}
+
+	now := time.Now().UTC()
+	if eff.DailyVolume > 0 {
+		dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		volume, err := s.repo.VolumeSince(ctx, merchantID, currency, dayStart)
+		if err != nil {
+			return fmt.Errorf("%w: failed to get daily volume: %v", ErrLimitsUnavailable, err)
+		}
+		if volume+amount > eff.DailyVolume {
+			return &LimitError{Limit: "daily_volume", Message: fmt.Sprintf("payment would exceed the merchant daily %s volume limit of %.2f", currency, eff.DailyVolume)}
+		}
+	}
+	if eff.MonthlyVolume > 0 {
+		monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		volume, err := s.repo.VolumeSince(ctx, merchantID, currency, monthStart)
+		if err != nil {
+			return fmt.Errorf("%w: failed to get monthly volume: %v", ErrLimitsUnavailable, err)
+		}
+		if volume+amount > eff.MonthlyVolume {
+			return &LimitError{Limit: "monthly_volume", Message: fmt.Sprintf("payment would exceed the merchant monthly %s volume limit of %.2f", currency, eff.MonthlyVolume)}
+		}
+	}
+	return nil
+}
+
+func (s *MerchantLimitService) limitsFor(ctx context.Context, merchantID int) (*models.MerchantLimits, bool, error) {
+	l, err := s.repo.GetByMerchant(ctx, merchantID)
+	if errors.Is(err, sql.ErrNoRows) {
+		defaults := s.defaults
+		defaults.MerchantID = merchantID
+		return &defaults, true, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get merchant limits: %w", err)
+	}
+	return l, false, nil
+}
+
+// effectiveLimits overlays the non-zero values of a currency override on the merchant-wide limits
+func effectiveLimits(l *models.MerchantLimits, currency string) models.CurrencyLimit {
+	eff := models.CurrencyLimit{
+		Currency:      currency,
+		MinAmount:     l.MinAmount,
+		MaxAmount:     l.MaxAmount,
+		DailyVolume:   l.DailyVolume,
+		MonthlyVolume: l.MonthlyVolume,
+	}
+	for _, cl := range l.Currencies {
+		if !strings.EqualFold(cl.Currency, currency) {
+			continue
+		}
+		if cl.MinAmount > 0 {
+			eff.MinAmount = cl.MinAmount
+		}
+		if cl.MaxAmount > 0 {
+			eff.MaxAmount = cl.MaxAmount
+		}
+		if cl.DailyVolume > 0 {
+			eff.DailyVolume = cl.DailyVolume
+		}
+		if cl.MonthlyVolume > 0 {
+			eff.MonthlyVolume = cl.MonthlyVolume
+		}
+	}
+	return eff
+}
+
+func validLimits(minAmount, maxAmount, daily, monthly float64) bool {
+	if minAmount < 0 || maxAmount < 0 || daily < 0 || monthly < 0 {
+		return false
+	}
+	return maxAmount == 0 || minAmount <= maxAmount
+}
+
+func toMerchantLimitsResponse(l *models.MerchantLimits) dto.MerchantLimitsResponse {
+	resp := dto.MerchantLimitsResponse{
+		MerchantID:    l.MerchantID,
+		MinAmount:     l.MinAmount,
+		MaxAmount:     l.MaxAmount,
+		DailyVolume:   l.DailyVolume,
+		MonthlyVolume: l.MonthlyVolume,
+		Currencies:    []dto.CurrencyLimit{},
+	}
+	if !l.UpdatedAt.IsZero() {
+		resp.UpdatedAt = l.UpdatedAt.Format(time.RFC3339)
+	}
+	for _, cl := range l.Currencies {
+		resp.Currencies = append(resp.Currencies, dto.CurrencyLimit{
+			Currency:      cl.Currency,
+			MinAmount:     cl.MinAmount,
+			MaxAmount:     cl.MaxAmount,
+			DailyVolume:   cl.DailyVolume,
+			MonthlyVolume: cl.MonthlyVolume,
+		})
+	}
+	return resp
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kodra-pay/checkout-service/internal/models"
+)
+
+// fakeLimitStore serves fixed limits and volume; limits nil means the merchant has none
+type fakeLimitStore struct {
+	limits    *models.MerchantLimits
+	volume    map[string]float64 // by upper-case currency
+	getErr    error
+	volumeErr error
+}
+
+func (f *fakeLimitStore) GetByMerchant(ctx context.Context, merchantID int) (*models.MerchantLimits, error) {
+	if f.getErr != nil {
+		return nil, f.getErr
+	}
+	if f.limits == nil {
+		return nil, sql.ErrNoRows
+	}
+	return f.limits, nil
+}
+
+func (f *fakeLimitStore) Upsert(ctx context.Context, l *models.MerchantLimits) error {
+	f.limits = l
+	return nil
+}
+
+func (f *fakeLimitStore) VolumeSince(ctx context.Context, merchantID int, currency string, since time.Time) (float64, error) {
+	if f.volumeErr != nil {
+		return 0, f.volumeErr
+	}
+	return f.volume[strings.ToUpper(currency)], nil
+}
+
+func TestMerchantLimitServiceEnforce(t *testing.T) {
+	limits := &models.MerchantLimits{
+		MinAmount:   100,
+		MaxAmount:   10000,
+		DailyVolume: 50000,
+		Currencies:  []models.CurrencyLimit{{Currency: "USD", MaxAmount: 500}},
+	}
+	dbErr := errors.New("connection refused")
+	tests := []struct {
+		name      string
+		store     *fakeLimitStore
+		defaults  models.MerchantLimits
+		currency  string
+		amount    float64
+		wantLimit string // breached limit, "" when the payment passes
+		wantErr   error  // non-limit error expected instead
+	}{
+		{name: "within limits", store: &fakeLimitStore{limits: limits}, currency: "NGN", amount: 5000},
+		{name: "below minimum", store: &fakeLimitStore{limits: limits}, currency: "NGN", amount: 50, wantLimit: "min_amount"},
+		{name: "above maximum", store: &fakeLimitStore{limits: limits}, currency: "NGN", amount: 20000, wantLimit: "max_amount"},
+		{name: "currency override", store: &fakeLimitStore{limits: limits}, currency: "usd", amount: 600, wantLimit: "max_amount"},
+		{
+			name:      "daily volume reached",
+			store:     &fakeLimitStore{limits: limits, volume: map[string]float64{"NGN": 48000}},
+			currency:  "NGN",
+			amount:    5000,
+			wantLimit: "daily_volume",
+		},
+		{
+			name:     "volume in another currency ignored",
+			store:    &fakeLimitStore{limits: limits, volume: map[string]float64{"GHS": 48000}},
+			currency: "NGN",
+			amount:   5000,
+		},
+		{name: "zero defaults leave merchant uncapped", store: &fakeLimitStore{}, currency: "NGN", amount: 1e9},
+		{
+			name:      "defaults apply without a limits row",
+			store:     &fakeLimitStore{},
+			defaults:  models.MerchantLimits{MaxAmount: 1000},
+			currency:  "NGN",
+			amount:    2000,
+			wantLimit: "max_amount",
+		},
+		{name: "limits lookup fails", store: &fakeLimitStore{getErr: dbErr}, currency: "NGN", amount: 5000, wantErr: ErrLimitsUnavailable},
+		{name: "volume lookup fails", store: &fakeLimitStore{limits: limits, volumeErr: dbErr}, currency: "NGN", amount: 5000, wantErr: ErrLimitsUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewMerchantLimitService(tt.store, tt.defaults)
+			err := svc.Enforce(context.Background(), 1, tt.currency, tt.amount)
+
+			var limitErr *LimitError
+			isLimit := errors.As(err, &limitErr)
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) || isLimit {
+					t.Fatalf("err = %v, want %v and no limit error", err, tt.wantErr)
+				}
+			case tt.wantLimit == "":
+				if err != nil {
+					t.Fatalf("err = %v, want nil", err)
+				}
+			default:
+				if !isLimit || limitErr.Limit != tt.wantLimit {
+					t.Fatalf("err = %v, want %s limit error", err, tt.wantLimit)
+				}
+			}
+		})
+	}
+}
@@ -2,63 +2,196 @@ package services
 
 import (
 	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/lib/pq"
+
 	"github.com/kodra-pay/checkout-service/internal/dto"
 	"github.com/kodra-pay/checkout-service/internal/models"
 	"github.com/kodra-pay/checkout-service/internal/repositories"
 )
 
+var (
+	ErrPaymentLinkNotFound    = errors.New("payment link not found")
+	ErrPaymentLinkAmountFixed = errors.New("amount can only be changed on open payment links")
+	ErrInvalidPaymentLink     = errors.New("invalid payment link")
+	ErrPaymentLinkExpired     = errors.New("payment link has expired; extend expires_at before activating it")
+	ErrPaymentLinkExhausted   = errors.New("payment link has no uses remaining; raise max_uses before activating it")
+	ErrDuplicateReference     = errors.New("a payment link with this reference already exists for the merchant")
+	ErrSlugTaken              = errors.New("slug is already in use")
+	ErrInvalidListQuery       = errors.New("invalid payment link query")
+	ErrInvalidSlug            = errors.New("slug must be 3-64 characters of a-z, 0-9 and hyphens, and cannot start or end with a hyphen")
+	ErrPaymentLinkInvoiced    = errors.New("payment link belongs to an invoice; manage it through the invoice")
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,62}[a-z0-9]$`)
+
 type PaymentLinkService struct {
-	repo *repositories.PaymentLinkRepository
+	repo      *repositories.PaymentLinkRepository
+	baseURL   string // hosted checkout origin used to build link URLs
+	bulkLimit int    // most links accepted by BulkCreate
 }
 
-func NewPaymentLinkService(repo *repositories.PaymentLinkRepository) *PaymentLinkService {
-	return &PaymentLinkService{repo: repo}
+func NewPaymentLinkService(repo *repositories.PaymentLinkRepository, baseURL string, bulkLimit int) *PaymentLinkService {
+	return &PaymentLinkService{repo: repo, baseURL: strings.TrimRight(baseURL, "/"), bulkLimit: bulkLimit}
 }
 
 func (s *PaymentLinkService) Create(ctx context.Context, req dto.PaymentLinkCreateRequest) (dto.PaymentLinkResponse, error) {
+	pl, err := newPaymentLink(req)
+	if err != nil {
+		return dto.PaymentLinkResponse{}, err
+	}
+	if err := s.repo.Create(ctx, pl); err != nil {
+		if conflict := uniqueViolation(err); conflict != nil {
+			return dto.PaymentLinkResponse{}, conflict
+		}
+		return dto.PaymentLinkResponse{}, fmt.Errorf("failed to create payment link in repository: %w", err)
+	}
+
+	return s.toResponse(pl), nil
+}
+
+// newPaymentLink validates a create request and builds the link it describes
+func newPaymentLink(req dto.PaymentLinkCreateRequest) (*models.PaymentLink, error) {
+	if req.MerchantID <= 0 || req.Currency == "" {
+		return nil, fmt.Errorf("%w: merchant_id and currency are required", ErrInvalidPaymentLink)
+	}
+	if req.Mode != "fixed" && req.Mode != "open" {
+		return nil, fmt.Errorf("%w: mode must be fixed or open", ErrInvalidPaymentLink)
+	}
+	if req.Amount != nil && *req.Amount <= 0 {
+		return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidPaymentLink)
+	}
+	if req.Mode == "fixed" && req.Amount == nil {
+		return nil, fmt.Errorf("%w: fixed links need an amount", ErrInvalidPaymentLink)
+	}
 	pl := &models.PaymentLink{
 		MerchantID:  req.MerchantID,
 		Mode:        req.Mode,
 		Amount:      req.Amount,
 		Currency:    req.Currency,
 		Description: req.Description,
-		Status:      "active",
+		Reference:   strings.TrimSpace(req.Reference),
+		Status:      models.PaymentLinkActive,
 	}
-	if err := s.repo.Create(ctx, pl); err != nil {
-		return dto.PaymentLinkResponse{}, fmt.Errorf("failed to create payment link in repository: %w", err)
+	if req.Slug != "" {
+		slug, err := normalizeSlug(req.Slug)
+		if err != nil {
+			return nil, err
+		}
+		pl.Slug = &slug
+	}
+	switch {
+	case req.ExpiresAt != nil && req.TTLSeconds != 0:
+		return nil, fmt.Errorf("%w: set expires_at or ttl_seconds, not both", ErrInvalidPaymentLink)
+	case req.TTLSeconds < 0:
+		return nil, fmt.Errorf("%w: ttl_seconds must be positive", ErrInvalidPaymentLink)
+	case req.TTLSeconds > 0:
+		expiresAt := time.Now().Add(time.Duration(req.TTLSeconds) * time.Second)
+		pl.ExpiresAt = &expiresAt
+	case req.ExpiresAt != nil:
+		if !req.ExpiresAt.After(time.Now()) {
+			return nil, fmt.Errorf("%w: expires_at must be in the future", ErrInvalidPaymentLink)
+		}
+		pl.ExpiresAt = req.ExpiresAt
+	}
+	if req.MaxUses != nil {
+		if *req.MaxUses <= 0 {
+			return nil, fmt.Errorf("%w: max_uses must be positive", ErrInvalidPaymentLink)
+		}
+		pl.MaxUses = req.MaxUses
+	}
+	pl.MinAmount = req.MinAmount
+	pl.MaxAmount = req.MaxAmount
+	pl.SuggestedAmounts = req.SuggestedAmounts
+	if err := validateAmountBounds(pl); err != nil {
+		return nil, err
 	}
+	pl.CustomFields = toModelCustomFields(req.CustomFields)
+	if err := validateCustomFieldDefinitions(pl.CustomFields); err != nil {
+		return nil, err
+	}
+	return pl, nil
+}
 
-	return dto.PaymentLinkResponse{
-		ID:          pl.ID,
-		MerchantID:  pl.MerchantID,
-		Mode:        pl.Mode,
-		Amount:      pl.Amount,
-		Currency:    pl.Currency,
-		Description: pl.Description,
-		Status:      pl.Status,
-		CreatedAt:   pl.CreatedAt.Format(time.RFC3339),
-	}, nil
+// listCursor is the opaque next_cursor handed to clients; it pins the sort it was issued for
+type listCursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d"`
+	Value string `json:"v"`
+	ID    int    `json:"id"`
 }
 
-func (s *PaymentLinkService) ListByMerchant(ctx context.Context, merchantID int) dto.PaymentLinkListResponse { // merchantID changed to int
-	links, _ := s.repo.ListByMerchant(ctx, merchantID, 50)
-	resp := dto.PaymentLinkListResponse{}
+// List returns one page of a merchant's links. cursor is the next_cursor of the previous page,
+// or empty for the first page; it must be used with the same sort and order it was issued for.
+func (s *PaymentLinkService) List(ctx context.Context, f repositories.PaymentLinkFilter, cursor string) (dto.PaymentLinkListResponse, error) {
+	if f.MerchantID <= 0 {
+		return dto.PaymentLinkListResponse{}, fmt.Errorf("%w: merchant_id is required", ErrInvalidListQuery)
+	}
+	if f.Sort == "" {
+		f.Sort = "created_at"
+	}
+	if _, ok := repositories.PaymentLinkSorts[f.Sort]; !ok {
+		return dto.PaymentLinkListResponse{}, fmt.Errorf("%w: sort must be created_at, updated_at, amount or collected_amount", ErrInvalidListQuery)
+	}
+	if cursor != "" {
+		var c listCursor
+		raw, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil || json.Unmarshal(raw, &c) != nil || c.Sort != f.Sort || c.Desc != f.Desc {
+			return dto.PaymentLinkListResponse{}, fmt.Errorf("%w: cursor is invalid or was issued for a different sort", ErrInvalidListQuery)
+		}
+		f.After = &repositories.PaymentLinkCursor{Value: c.Value, ID: c.ID}
+	}
+
+	// Fetch one extra row to learn whether another page follows
+	pageSize := f.Limit
+	f.Limit = pageSize + 1
+	links, err := s.repo.List(ctx, f)
+	if err != nil {
+		return dto.PaymentLinkListResponse{}, fmt.Errorf("failed to list payment links: %w", err)
+	}
+	total, err := s.repo.Count(ctx, f)
+	if err != nil {
+		return dto.PaymentLinkListResponse{}, fmt.Errorf("failed to count payment links: %w", err)
+	}
+
+	resp := dto.PaymentLinkListResponse{Links: []dto.PaymentLinkResponse{}, TotalCount: total}
+	if len(links) > pageSize {
+		links = links[:pageSize]
+		last := links[len(links)-1]
+		raw, _ := json.Marshal(listCursor{Sort: f.Sort, Desc: f.Desc, Value: sortValue(last, f.Sort), ID: last.ID})
+		resp.NextCursor = base64.RawURLEncoding.EncodeToString(raw)
+	}
 	for _, pl := range links {
-		resp.Links = append(resp.Links, dto.PaymentLinkResponse{
-			ID:          pl.ID,
-			MerchantID:  pl.MerchantID,
-			Mode:        pl.Mode,
-			Amount:      pl.Amount,
-			Currency:    pl.Currency,
-			Description: pl.Description,
-			Status:      pl.Status,
-			CreatedAt:   pl.CreatedAt.Format(time.RFC3339),
-		})
+		resp.Links = append(resp.Links, s.toResponse(pl))
+	}
+	return resp, nil
+}
+
+// sortValue renders a link's sort column the way the repository casts cursor values back
+func sortValue(pl *models.PaymentLink, sort string) string {
+	switch sort {
+	case "updated_at":
+		return pl.UpdatedAt.Format(time.RFC3339Nano)
+	case "amount":
+		if pl.Amount == nil {
+			return "0"
+		}
+		return strconv.FormatInt(*pl.Amount, 10)
+	case "collected_amount":
+		return strconv.FormatFloat(pl.CollectedAmount, 'f', -1, 64)
+	default:
+		return pl.CreatedAt.Format(time.RFC3339Nano)
 	}
-	return resp
 }
 
 func (s *PaymentLinkService) Get(ctx context.Context, id int) (*dto.PaymentLinkResponse, error) {
@@ -66,15 +199,409 @@ func (s *PaymentLinkService) Get(ctx context.Context, id int) (*dto.PaymentLinkR
 	if err != nil {
 		return nil, err
 	}
-	resp := &dto.PaymentLinkResponse{
-		ID:          pl.ID,
-		MerchantID:  pl.MerchantID,
-		Mode:        pl.Mode,
-		Amount:      pl.Amount,
-		Currency:    pl.Currency,
-		Description: pl.Description,
-		Status:      pl.Status,
-		CreatedAt:   pl.CreatedAt.Format(time.RFC3339),
+	resp := s.toResponse(pl)
+	return &resp, nil
+}
+
+// GetPublic returns the public view of a link, as used by the hosted /pay/{id} page
+func (s *PaymentLinkService) GetPublic(ctx context.Context, id int) (*dto.PublicPaymentLinkResponse, error) {
+	pl, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	resp := s.toPublicResponse(pl)
+	return &resp, nil
+}
+
+// GetBySlug resolves a vanity slug to the public view of its link, as used by the hosted /pay/{slug} page
+func (s *PaymentLinkService) GetBySlug(ctx context.Context, slug string) (*dto.PublicPaymentLinkResponse, error) {
+	pl, err := s.repo.GetBySlug(ctx, strings.ToLower(slug))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPaymentLinkNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment link: %w", err)
+	}
+	resp := s.toPublicResponse(pl)
+	return &resp, nil
+}
+
+// RecordView counts a hosted page view of the link
+func (s *PaymentLinkService) RecordView(ctx context.Context, id int) error {
+	if _, err := s.get(ctx, id); err != nil {
+		return err
+	}
+	if err := s.repo.RecordEvent(ctx, id, models.LinkEventView, 0); err != nil {
+		return fmt.Errorf("failed to record payment link view: %w", err)
+	}
+	return nil
+}
+
+// Stats returns all-time counters and daily buckets for the last days days, including empty days
+func (s *PaymentLinkService) Stats(ctx context.Context, id int, days int) (*dto.PaymentLinkStatsResponse, error) {
+	if days <= 0 || days > 366 {
+		return nil, fmt.Errorf("%w: days must be between 1 and 366", ErrInvalidPaymentLink)
+	}
+	pl, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	since := today.AddDate(0, 0, -(days - 1))
+	buckets, err := s.repo.DailyStats(ctx, id, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment link stats: %w", err)
+	}
+	byDay := make(map[string]models.PaymentLinkDailyStats, len(buckets))
+	for _, b := range buckets {
+		byDay[b.Day.Format("2006-01-02")] = b
+	}
+
+	resp := &dto.PaymentLinkStatsResponse{
+		PaymentLinkID: id,
+		Totals:        toPaymentLinkCounters(pl),
+		Daily:         make([]dto.PaymentLinkDailyStats, 0, days),
+	}
+	for day := since; !day.After(today); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		b := byDay[date]
+		resp.Daily = append(resp.Daily, dto.PaymentLinkDailyStats{
+			Date:            date,
+			Views:           b.Views,
+			Attempts:        b.Attempts,
+			Successes:       b.Successes,
+			FraudDenials:    b.FraudDenials,
+			CollectedAmount: b.CollectedAmount,
+			ConversionRate:  rate(b.Successes, b.Views),
+		})
 	}
 	return resp, nil
 }
+
+// Update edits a link's description, expiry, slug, usage limit, custom fields and, for open links,
+// its amount and bounds
+func (s *PaymentLinkService) Update(ctx context.Context, id int, req dto.PaymentLinkUpdateRequest) (*dto.PaymentLinkResponse, error) {
+	pl, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkNotInvoiced(ctx, id); err != nil {
+		return nil, err
+	}
+
+	if req.Description != nil {
+		pl.Description = *req.Description
+	}
+	if req.Amount != nil {
+		if pl.Mode == "fixed" {
+			return nil, ErrPaymentLinkAmountFixed
+		}
+		if *req.Amount <= 0 {
+			return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidPaymentLink)
+		}
+		pl.Amount = req.Amount
+	}
+	if req.ClearExpiry {
+		pl.ExpiresAt = nil
+	} else if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(time.Now()) {
+			return nil, fmt.Errorf("%w: expires_at must be in the future", ErrInvalidPaymentLink)
+		}
+		pl.ExpiresAt = req.ExpiresAt
+	}
+	if req.Slug != nil {
+		if *req.Slug == "" {
+			pl.Slug = nil
+		} else {
+			slug, err := normalizeSlug(*req.Slug)
+			if err != nil {
+				return nil, err
+			}
+			pl.Slug = &slug
+		}
+	}
+	if req.MaxUses != nil {
+		if *req.MaxUses <= 0 || *req.MaxUses < pl.UseCount {
+			return nil, fmt.Errorf("%w: max_uses must be positive and at least the %d uses already taken", ErrInvalidPaymentLink, pl.UseCount)
+		}
+		pl.MaxUses = req.MaxUses
+	}
+	if req.MinAmount != nil {
+		pl.MinAmount = nonZeroAmount(*req.MinAmount)
+	}
+	if req.MaxAmount != nil {
+		pl.MaxAmount = nonZeroAmount(*req.MaxAmount)
+	}
+	if req.SuggestedAmounts != nil {
+		pl.SuggestedAmounts = *req.SuggestedAmounts
+	}
+	if err := validateAmountBounds(pl); err != nil {
+		return nil, err
+	}
+	if req.CustomFields != nil {
+		pl.CustomFields = toModelCustomFields(*req.CustomFields)
+		if err := validateCustomFieldDefinitions(pl.CustomFields); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.Update(ctx, pl); err != nil {
+		if conflict := uniqueViolation(err); conflict != nil {
+			return nil, conflict
+		}
+		return nil, fmt.Errorf("failed to update payment link: %w", err)
+	}
+	resp := s.toResponse(pl)
+	return &resp, nil
+}
+
+func (s *PaymentLinkService) Activate(ctx context.Context, id int) (*dto.PaymentLinkResponse, error) {
+	pl, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkNotInvoiced(ctx, id); err != nil {
+		return nil, err
+	}
+	if pl.IsExpired(time.Now()) {
+		return nil, ErrPaymentLinkExpired
+	}
+	if remaining := pl.RemainingUses(); remaining != nil && *remaining == 0 {
+		return nil, ErrPaymentLinkExhausted
+	}
+	return s.setStatus(ctx, id, models.PaymentLinkActive)
+}
+
+func (s *PaymentLinkService) Deactivate(ctx context.Context, id int) (*dto.PaymentLinkResponse, error) {
+	if err := s.checkNotInvoiced(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.setStatus(ctx, id, models.PaymentLinkInactive)
+}
+
+// Delete soft-deletes a link so it can no longer be viewed or paid
+func (s *PaymentLinkService) Delete(ctx context.Context, id int) error {
+	if err := s.checkNotInvoiced(ctx, id); err != nil {
+		return err
+	}
+	err := s.repo.SoftDelete(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrPaymentLinkNotFound
+	}
+	return err
+}
+
+// RunExpiry marks links past their expiry as expired every interval until ctx is cancelled
+func (s *PaymentLinkService) RunExpiry(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.repo.ExpireDue(ctx)
+			if err != nil {
+				fmt.Printf("Warning: failed to expire payment links: %v\n", err)
+			} else if n > 0 {
+				fmt.Printf("Info: expired %d payment links\n", n)
+			}
+		}
+	}
+}
+
+func (s *PaymentLinkService) setStatus(ctx context.Context, id int, status string) (*dto.PaymentLinkResponse, error) {
+	err := s.repo.SetStatus(ctx, id, status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPaymentLinkNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update payment link status: %w", err)
+	}
+	pl, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	resp := s.toResponse(pl)
+	return &resp, nil
+}
+
+// Authorize returns ErrPaymentLinkNotFound unless the link belongs to merchantID, so other
+// merchants' links look the same as missing ones
+func (s *PaymentLinkService) Authorize(ctx context.Context, id, merchantID int) error {
+	pl, err := s.get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if pl.MerchantID != merchantID {
+		return ErrPaymentLinkNotFound
+	}
+	return nil
+}
+
+// checkNotInvoiced refuses changes to links issued for an invoice; InvoiceService changes their
+// status itself when the invoice is finalized or voided
+func (s *PaymentLinkService) checkNotInvoiced(ctx context.Context, id int) error {
+	invoiced, err := s.repo.BacksInvoice(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to check payment link %d for an invoice: %w", id, err)
+	}
+	if invoiced {
+		return ErrPaymentLinkInvoiced
+	}
+	return nil
+}
+
+func (s *PaymentLinkService) get(ctx context.Context, id int) (*models.PaymentLink, error) {
+	pl, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPaymentLinkNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment link: %w", err)
+	}
+	return pl, nil
+}
+
+// validateAmountBounds checks an open link's min/max amounts and presets against each other
+// and against its default amount. Fixed links cannot carry bounds.
+func validateAmountBounds(pl *models.PaymentLink) error {
+	hasBounds := pl.MinAmount != nil || pl.MaxAmount != nil || len(pl.SuggestedAmounts) > 0
+	if !hasBounds {
+		return nil
+	}
+	if pl.Mode != "open" {
+		return fmt.Errorf("%w: min_amount, max_amount and suggested_amounts apply to open links only", ErrInvalidPaymentLink)
+	}
+	if pl.MinAmount != nil && *pl.MinAmount <= 0 {
+		return fmt.Errorf("%w: min_amount must be positive", ErrInvalidPaymentLink)
+	}
+	if pl.MaxAmount != nil && *pl.MaxAmount <= 0 {
+		return fmt.Errorf("%w: max_amount must be positive", ErrInvalidPaymentLink)
+	}
+	if pl.MinAmount != nil && pl.MaxAmount != nil && *pl.MinAmount > *pl.MaxAmount {
+		return fmt.Errorf("%w: min_amount cannot exceed max_amount", ErrInvalidPaymentLink)
+	}
+	if pl.Amount != nil && !pl.AmountInBounds(float64(*pl.Amount)) {
+		return fmt.Errorf("%w: amount %d is outside min_amount/max_amount", ErrInvalidPaymentLink, *pl.Amount)
+	}
+	for _, suggested := range pl.SuggestedAmounts {
+		if suggested <= 0 || !pl.AmountInBounds(float64(suggested)) {
+			return fmt.Errorf("%w: suggested amount %d must be positive and within min_amount/max_amount", ErrInvalidPaymentLink, suggested)
+		}
+	}
+	return nil
+}
+
+func normalizeSlug(slug string) (string, error) {
+	slug = strings.ToLower(strings.TrimSpace(slug))
+	if !slugPattern.MatchString(slug) || strings.Contains(slug, "--") {
+		return "", ErrInvalidSlug
+	}
+	return slug, nil
+}
+
+// uniqueViolation maps a unique-index error on reference or slug to its sentinel, or returns nil
+func uniqueViolation(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != "23505" {
+		return nil
+	}
+	if pqErr.Constraint == "idx_payment_links_slug" {
+		return ErrSlugTaken
+	}
+	return ErrDuplicateReference
+}
+
+func nonZeroAmount(amount int64) *int64 {
+	if amount == 0 {
+		return nil
+	}
+	return &amount
+}
+
+func (s *PaymentLinkService) toResponse(pl *models.PaymentLink) dto.PaymentLinkResponse {
+	resp := dto.PaymentLinkResponse{
+		ID:               pl.ID,
+		MerchantID:       pl.MerchantID,
+		Mode:             pl.Mode,
+		Amount:           pl.Amount,
+		Currency:         pl.Currency,
+		Description:      pl.Description,
+		Reference:        pl.Reference,
+		Status:           pl.Status,
+		MaxUses:          pl.MaxUses,
+		UseCount:         pl.UseCount,
+		RemainingUses:    pl.RemainingUses(),
+		MinAmount:        pl.MinAmount,
+		MaxAmount:        pl.MaxAmount,
+		SuggestedAmounts: pl.SuggestedAmounts,
+		CustomFields:     toDTOCustomFields(pl.CustomFields),
+		Stats:            toPaymentLinkCounters(pl),
+		CreatedAt:        pl.CreatedAt.Format(time.RFC3339),
+	}
+	if pl.ExpiresAt != nil {
+		resp.ExpiresAt = pl.ExpiresAt.Format(time.RFC3339)
+	}
+	if pl.Slug != nil {
+		resp.Slug = *pl.Slug
+	}
+	if s.baseURL != "" {
+		resp.URL = paymentLinkURL(s.baseURL, pl)
+	}
+	return resp
+}
+
+func (s *PaymentLinkService) toPublicResponse(pl *models.PaymentLink) dto.PublicPaymentLinkResponse {
+	resp := dto.PublicPaymentLinkResponse{
+		ID:               pl.ID,
+		MerchantID:       pl.MerchantID,
+		Mode:             pl.Mode,
+		Amount:           pl.Amount,
+		Currency:         pl.Currency,
+		Description:      pl.Description,
+		Status:           pl.Status,
+		MinAmount:        pl.MinAmount,
+		MaxAmount:        pl.MaxAmount,
+		SuggestedAmounts: pl.SuggestedAmounts,
+		CustomFields:     toDTOCustomFields(pl.CustomFields),
+	}
+	if pl.ExpiresAt != nil {
+		resp.ExpiresAt = pl.ExpiresAt.Format(time.RFC3339)
+	}
+	if pl.Slug != nil {
+		resp.Slug = *pl.Slug
+	}
+	if s.baseURL != "" {
+		resp.URL = paymentLinkURL(s.baseURL, pl)
+	}
+	return resp
+}
+
+func toPaymentLinkCounters(pl *models.PaymentLink) dto.PaymentLinkCounters {
+	return dto.PaymentLinkCounters{
+		Views:           pl.ViewCount,
+		Attempts:        pl.AttemptCount,
+		Successes:       pl.SuccessCount,
+		FraudDenials:    pl.FraudDenialCount,
+		CollectedAmount: pl.CollectedAmount,
+		ConversionRate:  rate(pl.SuccessCount, pl.ViewCount),
+		AttemptSuccess:  rate(pl.SuccessCount, pl.AttemptCount),
+	}
+}
+
+// rate is num/den rounded to four places, or 0 when den is 0
+func rate(num, den int) float64 {
+	if den == 0 {
+		return 0
+	}
+	return math.Round(float64(num)/float64(den)*10000) / 10000
+}
+
+// paymentLinkURL is the hosted page for a link: /pay/{slug} when it has a vanity slug, else /pay/{id}
+func paymentLinkURL(baseURL string, pl *models.PaymentLink) string {
+	if pl.Slug != nil {
+		return baseURL + "/pay/" + *pl.Slug
+	}
+	return baseURL + "/pay/" + strconv.Itoa(pl.ID)
+}
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kodra-pay/checkout-service/internal/dto"
+	"github.com/kodra-pay/checkout-service/internal/models"
+)
+
+var (
+	ErrBulkTooLarge = errors.New("too many payment links in one upload")
+	ErrBulkEmpty    = errors.New("upload contains no payment links")
+	ErrInvalidCSV   = errors.New("invalid CSV upload")
+)
+
+// bulkCSVColumns are the accepted CSV header names; suggested_amounts is a ;-separated list
+var bulkCSVColumns = map[string]bool{
+	"merchant_id": true, "mode": true, "amount": true, "currency": true, "description": true,
+	"reference": true, "slug": true, "expires_at": true, "ttl_seconds": true, "max_uses": true,
+	"min_amount": true, "max_amount": true, "suggested_amounts": true,
+}
+
+// BulkRow is one parsed upload row; Err is set when the row could not be read
+type BulkRow struct {
+	Req dto.PaymentLinkCreateRequest
+	Err error
+}
+
+// ParseBulkCSV reads a header row followed by one link per line. Unknown columns are rejected so
+// a misspelt header doesn't silently drop a field.
+func ParseBulkCSV(r io.Reader) ([]BulkRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, ErrBulkEmpty
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCSV, err)
+	}
+	for i, col := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(col))
+		if !bulkCSVColumns[header[i]] {
+			return nil, fmt.Errorf("%w: unknown column %q", ErrInvalidCSV, col)
+		}
+	}
+
+	var rows []BulkRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCSV, err)
+		}
+		fields := make(map[string]string, len(header))
+		for i, col := range header {
+			fields[col] = strings.TrimSpace(record[i])
+		}
+		req, err := bulkRequestFromCSV(fields)
+		rows = append(rows, BulkRow{Req: req, Err: err})
+	}
+	return rows, nil
+}
+
+func bulkRequestFromCSV(f map[string]string) (dto.PaymentLinkCreateRequest, error) {
+	req := dto.PaymentLinkCreateRequest{
+		Mode:        f["mode"],
+		Currency:    f["currency"],
+		Description: f["description"],
+		Reference:   f["reference"],
+		Slug:        f["slug"],
+	}
+	var err error
+	parseInt := func(col string) *int64 {
+		if f[col] == "" || err != nil {
+			return nil
+		}
+		v, perr := strconv.ParseInt(f[col], 10, 64)
+		if perr != nil {
+			err = fmt.Errorf("%w: %s must be a whole number", ErrInvalidPaymentLink, col)
+			return nil
+		}
+		return &v
+	}
+	if v := parseInt("merchant_id"); v != nil {
+		req.MerchantID = int(*v)
+	}
+	req.Amount = parseInt("amount")
+	req.MinAmount = parseInt("min_amount")
+	req.MaxAmount = parseInt("max_amount")
+	if v := parseInt("ttl_seconds"); v != nil {
+		req.TTLSeconds = *v
+	}
+	if v := parseInt("max_uses"); v != nil {
+		maxUses := int(*v)
+		req.MaxUses = &maxUses
+	}
+	if f["suggested_amounts"] != "" && err == nil {
+		for _, part := range strings.Split(f["suggested_amounts"], ";") {
+			v, perr := strconv.ParseInt(strings.TrimSpace(part), 10, 64)
+			if perr != nil {
+				return req, fmt.Errorf("%w: suggested_amounts must be whole numbers separated by ;", ErrInvalidPaymentLink)
+			}
+			req.SuggestedAmounts = append(req.SuggestedAmounts, v)
+		}
+	}
+	if f["expires_at"] != "" && err == nil {
+		t, perr := time.Parse(time.RFC3339, f["expires_at"])
+		if perr != nil {
+			return req, fmt.Errorf("%w: expires_at must be RFC 3339", ErrInvalidPaymentLink)
+		}
+		req.ExpiresAt = &t
+	}
+	return req, err
+}
+
+// BulkCreate validates every row and, unless dryRun is set or a row is invalid, creates all
+// of them in one transaction. Row errors are reported with 1-based row numbers and nothing
+// is created when any row fails. Every link belongs to merchantID; rows naming another
+// merchant are rejected.
+func (s *PaymentLinkService) BulkCreate(ctx context.Context, rows []BulkRow, merchantID int, dryRun bool) (dto.PaymentLinkBulkResponse, error) {
+	if len(rows) == 0 {
+		return dto.PaymentLinkBulkResponse{}, ErrBulkEmpty
+	}
+	if len(rows) > s.bulkLimit {
+		return dto.PaymentLinkBulkResponse{}, fmt.Errorf("%w: %d rows, the limit is %d", ErrBulkTooLarge, len(rows), s.bulkLimit)
+	}
+
+	resp := dto.PaymentLinkBulkResponse{DryRun: dryRun, Links: []dto.PaymentLinkResponse{}, Errors: []dto.PaymentLinkBulkRowError{}}
+	links := make([]*models.PaymentLink, 0, len(rows))
+	references := map[string]int{}
+	slugs := map[string]int{}
+	for i, row := range rows {
+		rowErr := func(err error) {
+			resp.Errors = append(resp.Errors, dto.PaymentLinkBulkRowError{Row: i + 1, Reference: row.Req.Reference, Error: err.Error()})
+		}
+		if row.Err != nil {
+			rowErr(row.Err)
+			continue
+		}
+		if row.Req.MerchantID != 0 && row.Req.MerchantID != merchantID {
+			rowErr(fmt.Errorf("%w: merchant_id %d is not the authenticated merchant", ErrInvalidPaymentLink, row.Req.MerchantID))
+			continue
+		}
+		row.Req.MerchantID = merchantID
+		pl, err := newPaymentLink(row.Req)
+		if err != nil {
+			rowErr(err)
+			continue
+		}
+		// Duplicates within the upload would only surface as a failed insert; report them up front
+		if pl.Reference != "" {
+			key := strconv.Itoa(pl.MerchantID) + "/" + pl.Reference
+			if first, ok := references[key]; ok {
+				rowErr(fmt.Errorf("%w (also used on row %d)", ErrDuplicateReference, first))
+				continue
+			}
+			references[key] = i + 1
+		}
+		if pl.Slug != nil {
+			if first, ok := slugs[*pl.Slug]; ok {
+				rowErr(fmt.Errorf("%w (also used on row %d)", ErrSlugTaken, first))
+				continue
+			}
+			slugs[*pl.Slug] = i + 1
+		}
+		links = append(links, pl)
+	}
+	resp.Valid = len(links)
+	if len(resp.Errors) > 0 || dryRun {
+		return resp, nil
+	}
+
+	if failed, err := s.repo.CreateBatch(ctx, links); err != nil {
+		if conflict := uniqueViolation(err); conflict != nil && failed >= 0 {
+			resp.Errors = append(resp.Errors, dto.PaymentLinkBulkRowError{Row: failed + 1, Reference: links[failed].Reference, Error: conflict.Error()})
+			return resp, nil
+		}
+		return dto.PaymentLinkBulkResponse{}, fmt.Errorf("failed to create payment links: %w", err)
+	}
+	resp.Created = len(links)
+	for _, pl := range links {
+		resp.Links = append(resp.Links, s.toResponse(pl))
+	}
+	return resp, nil
+}
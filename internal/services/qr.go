@@ -0,0 +1,240 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strconv"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+
+	"github.com/kodra-pay/checkout-service/internal/repositories"
+)
+
+var (
+	ErrInvalidQROptions  = errors.New("format must be png or svg, size 64-2048, margin 0-16 and ecc one of L, M, Q, H")
+	ErrQRLogoNeedsECC    = errors.New("embedding a logo needs ecc Q or H so the covered modules can be recovered")
+	ErrNoCheckoutBaseURL = errors.New("CHECKOUT_BASE_URL is not configured, so there is no hosted page to encode")
+	ErrSessionNotFound   = errors.New("checkout session not found")
+)
+
+// qrLogoFraction is the share of the symbol's width a logo may cover; well within what ecc Q and H recover
+const qrLogoFraction = 0.22
+
+// QROptions controls how a QR code is rendered
+type QROptions struct {
+	Format string // png or svg
+	Size   int    // output width and height in pixels
+	Margin int    // quiet zone in modules
+	ECC    string // L, M, Q or H; empty picks M, or H when a logo is embedded
+	Logo   bool   // embed the merchant's logo in the centre
+}
+
+var qrLevels = map[string]qrcode.RecoveryLevel{
+	"L": qrcode.Low,
+	"M": qrcode.Medium,
+	"Q": qrcode.High,
+	"H": qrcode.Highest,
+}
+
+// QRService renders QR codes of hosted payment page URLs for printing at counters
+type QRService struct {
+	links    *repositories.PaymentLinkRepository
+	sessions CheckoutStore
+	branding *BrandingService
+	baseURL  string
+}
+
+func NewQRService(links *repositories.PaymentLinkRepository, sessions CheckoutStore, branding *BrandingService, baseURL string) *QRService {
+	return &QRService{links: links, sessions: sessions, branding: branding, baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// PaymentLinkQR encodes the link's hosted URL, using its vanity slug when it has one.
+// It returns the image and its content type.
+func (s *QRService) PaymentLinkQR(ctx context.Context, id int, opts QROptions) ([]byte, string, error) {
+	if s.baseURL == "" {
+		return nil, "", ErrNoCheckoutBaseURL
+	}
+	pl, err := s.links.GetByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, "", ErrPaymentLinkNotFound
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get payment link: %w", err)
+	}
+	return s.render(ctx, paymentLinkURL(s.baseURL, pl), pl.MerchantID, opts)
+}
+
+// SessionQR encodes the hosted checkout URL of a session
+func (s *QRService) SessionQR(ctx context.Context, id int, opts QROptions) ([]byte, string, error) {
+	if s.baseURL == "" {
+		return nil, "", ErrNoCheckoutBaseURL
+	}
+	cs, err := s.sessions.GetSession(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, "", ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get checkout session: %w", err)
+	}
+	return s.render(ctx, s.baseURL+"/checkout/"+strconv.Itoa(cs.ID), cs.MerchantID, opts)
+}
+
+func (s *QRService) render(ctx context.Context, content string, merchantID int, opts QROptions) ([]byte, string, error) {
+	if opts.Format != "png" && opts.Format != "svg" || opts.Size < 64 || opts.Size > 2048 || opts.Margin < 0 || opts.Margin > 16 {
+		return nil, "", ErrInvalidQROptions
+	}
+	ecc := strings.ToUpper(opts.ECC)
+	if ecc == "" {
+		ecc = "M"
+		if opts.Logo {
+			ecc = "H"
+		}
+	}
+	level, ok := qrLevels[ecc]
+	if !ok {
+		return nil, "", ErrInvalidQROptions
+	}
+	if opts.Logo && level < qrcode.High {
+		return nil, "", ErrQRLogoNeedsECC
+	}
+
+	code, err := qrcode.New(content, level)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode QR code: %w", err)
+	}
+	code.DisableBorder = true
+	modules := code.Bitmap()
+
+	// A merchant without a logo still gets a plain code rather than an error
+	var logo image.Image
+	if opts.Logo {
+		logo = s.branding.Logo(ctx, merchantID)
+	}
+
+	if opts.Format == "svg" {
+		return renderQRSVG(modules, opts, logo), "image/svg+xml", nil
+	}
+	out, err := renderQRPNG(modules, opts, logo)
+	if err != nil {
+		return nil, "", err
+	}
+	return out, "image/png", nil
+}
+
+// renderQRPNG draws the module grid at exactly opts.Size pixels, sampling modules per pixel
+func renderQRPNG(modules [][]bool, opts QROptions, logo image.Image) ([]byte, error) {
+	n := len(modules)
+	total := n + 2*opts.Margin
+	img := image.NewRGBA(image.Rect(0, 0, opts.Size, opts.Size))
+	for py := 0; py < opts.Size; py++ {
+		my := py*total/opts.Size - opts.Margin
+		for px := 0; px < opts.Size; px++ {
+			mx := px*total/opts.Size - opts.Margin
+			c := color.RGBA{255, 255, 255, 255}
+			if mx >= 0 && my >= 0 && mx < n && my < n && modules[my][mx] {
+				c = color.RGBA{0, 0, 0, 255}
+			}
+			img.SetRGBA(px, py, c)
+		}
+	}
+
+	if logo != nil {
+		box := int(float64(n*opts.Size/total) * qrLogoFraction)
+		offset := (opts.Size - box) / 2
+		pad := box / 10
+		for y := offset - pad; y < offset+box+pad; y++ {
+			for x := offset - pad; x < offset+box+pad; x++ {
+				img.SetRGBA(x, y, color.RGBA{255, 255, 255, 255})
+			}
+		}
+		drawScaled(img, logo, image.Rect(offset, offset, offset+box, offset+box))
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode QR png: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// renderQRSVG emits one path of horizontal module runs in a viewBox measured in modules
+func renderQRSVG(modules [][]bool, opts QROptions, logo image.Image) []byte {
+	n := len(modules)
+	total := n + 2*opts.Margin
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		opts.Size, opts.Size, total, total)
+	b.WriteString(`<rect width="100%" height="100%" fill="#fff"/><path fill="#000" d="`)
+	for y, row := range modules {
+		for x := 0; x < n; {
+			if !row[x] {
+				x++
+				continue
+			}
+			run := 1
+			for x+run < n && row[x+run] {
+				run++
+			}
+			fmt.Fprintf(&b, "M%d %dh%dv1h-%dz", x+opts.Margin, y+opts.Margin, run, run)
+			x += run
+		}
+	}
+	b.WriteString(`"/>`)
+
+	if logo != nil {
+		var logoPNG bytes.Buffer
+		if err := png.Encode(&logoPNG, logo); err == nil {
+			box := float64(n) * qrLogoFraction
+			offset := (float64(total) - box) / 2
+			pad := box / 10
+			fmt.Fprintf(&b, `<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f" fill="#fff"/>`,
+				offset-pad, offset-pad, box+2*pad, box+2*pad)
+			fmt.Fprintf(&b, `<image x="%.2f" y="%.2f" width="%.2f" height="%.2f" preserveAspectRatio="xMidYMid meet" href="data:image/png;base64,%s"/>`,
+				offset, offset, box, box, base64.StdEncoding.EncodeToString(logoPNG.Bytes()))
+		}
+	}
+	b.WriteString(`</svg>`)
+	return []byte(b.String())
+}
+
+// drawScaled draws src into dst's rect with nearest-neighbour sampling, keeping src's aspect ratio
+func drawScaled(dst *image.RGBA, src image.Image, rect image.Rectangle) {
+	sb := src.Bounds()
+	if sb.Dx() == 0 || sb.Dy() == 0 {
+		return
+	}
+	w, h := rect.Dx(), rect.Dy()
+	if sb.Dx()*h > sb.Dy()*w {
+		h = w * sb.Dy() / sb.Dx()
+	} else {
+		w = h * sb.Dx() / sb.Dy()
+	}
+	x0 := rect.Min.X + (rect.Dx()-w)/2
+	y0 := rect.Min.Y + (rect.Dy()-h)/2
+	for y := 0; y < h; y++ {
+		sy := sb.Min.Y + y*sb.Dy()/h
+		for x := 0; x < w; x++ {
+			sx := sb.Min.X + x*sb.Dx()/w
+			r, g, bl, a := src.At(sx, sy).RGBA()
+			if a == 0 {
+				continue
+			}
+			// Blend over the white backing box
+			inv := 0xffff - a
+			dst.SetRGBA(x0+x, y0+y, color.RGBA{
+				R: uint8((r + inv) >> 8),
+				G: uint8((g + inv) >> 8),
+				B: uint8((bl + inv) >> 8),
+				A: 255,
+			})
+		}
+	}
+}
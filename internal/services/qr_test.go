@@ -0,0 +1,63 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestQRServiceRenderOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     QROptions
+		wantType string
+		wantErr  error
+	}{
+		{name: "png", opts: QROptions{Format: "png", Size: 256, Margin: 4}, wantType: "image/png"},
+		{name: "svg", opts: QROptions{Format: "svg", Size: 256, Margin: 4, ECC: "q"}, wantType: "image/svg+xml"},
+		{name: "smallest size", opts: QROptions{Format: "png", Size: 64}, wantType: "image/png"},
+		{name: "largest margin", opts: QROptions{Format: "svg", Size: 2048, Margin: 16}, wantType: "image/svg+xml"},
+		{name: "unknown format", opts: QROptions{Format: "gif", Size: 256}, wantErr: ErrInvalidQROptions},
+		{name: "too small", opts: QROptions{Format: "png", Size: 63}, wantErr: ErrInvalidQROptions},
+		{name: "too large", opts: QROptions{Format: "png", Size: 2049}, wantErr: ErrInvalidQROptions},
+		{name: "negative margin", opts: QROptions{Format: "png", Size: 256, Margin: -1}, wantErr: ErrInvalidQROptions},
+		{name: "margin too wide", opts: QROptions{Format: "png", Size: 256, Margin: 17}, wantErr: ErrInvalidQROptions},
+		{name: "unknown ecc", opts: QROptions{Format: "png", Size: 256, ECC: "X"}, wantErr: ErrInvalidQROptions},
+		{name: "logo with low ecc", opts: QROptions{Format: "png", Size: 256, ECC: "L", Logo: true}, wantErr: ErrQRLogoNeedsECC},
+		{name: "logo with medium ecc", opts: QROptions{Format: "svg", Size: 256, ECC: "M", Logo: true}, wantErr: ErrQRLogoNeedsECC},
+	}
+	s := &QRService{baseURL: "https://pay.example.com"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, contentType, err := s.render(context.Background(), "https://pay.example.com/pay/abc", 1, tt.opts)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("err = %v", err)
+			}
+			if contentType != tt.wantType {
+				t.Fatalf("content type = %s, want %s", contentType, tt.wantType)
+			}
+			if contentType == "image/svg+xml" {
+				if !strings.HasPrefix(string(out), "<svg") {
+					t.Fatalf("output is not an svg document")
+				}
+				return
+			}
+			img, err := png.Decode(bytes.NewReader(out))
+			if err != nil {
+				t.Fatalf("decode png: %v", err)
+			}
+			if b := img.Bounds(); b.Dx() != tt.opts.Size || b.Dy() != tt.opts.Size {
+				t.Errorf("png is %dx%d, want %dx%d", b.Dx(), b.Dy(), tt.opts.Size, tt.opts.Size)
+			}
+		})
+	}
+}
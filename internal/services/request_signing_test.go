@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/kodra-pay/checkout-service/internal/models"
+	"github.com/kodra-pay/checkout-service/internal/repositories"
+)
+
+// fakeAPIKeyStore serves keys by id; methods the tests don't reach are left to the nil interface
+type fakeAPIKeyStore struct {
+	APIKeyStore
+	keys map[int]*models.APIKey
+}
+
+func (f *fakeAPIKeyStore) Lookup(ctx context.Context, id int) (*models.APIKey, error) {
+	k, ok := f.keys[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return k, nil
+}
+
+func (f *fakeAPIKeyStore) GetByID(ctx context.Context, merchantID, id int) (*models.APIKey, error) {
+	k, ok := f.keys[id]
+	if !ok || k.MerchantID != merchantID {
+		return nil, sql.ErrNoRows
+	}
+	return k, nil
+}
+
+func (f *fakeAPIKeyStore) Revoke(ctx context.Context, merchantID, id int) (*models.APIKey, error) {
+	k, err := f.GetByID(ctx, merchantID, id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	k.RevokedAt = &now
+	return k, nil
+}
+
+func (f *fakeAPIKeyStore) TouchLastUsed(ctx context.Context, id int) error {
+	return nil
+}
+
+func TestSigningString(t *testing.T) {
+	got := SigningString("post", "/checkout/pay?x=1", "1700000000", "nonce-0123456789", []byte(`{"amount":100}`))
+	sum := sha256.Sum256([]byte(`{"amount":100}`))
+	want := "POST\n/checkout/pay?x=1\n1700000000\nnonce-0123456789\n" + hex.EncodeToString(sum[:])
+	if got != want {
+		t.Fatalf("SigningString = %q, want %q", got, want)
+	}
+}
+
+func TestAuthenticateSigned(t *testing.T) {
+	const secret = "kp_sk_signingsecret"
+	box := testSecretBox(t, 1)
+	cipher, err := box.Seal([]byte(secret))
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	revokedAt := time.Now().Add(-time.Hour)
+	store := &fakeAPIKeyStore{keys: map[int]*models.APIKey{
+		1: {ID: 1, MerchantID: 10, AuthMethod: models.APIKeyAuthHMAC, SecretCipher: cipher},
+		2: {ID: 2, MerchantID: 10, AuthMethod: models.APIKeyAuthBearer},
+		3: {ID: 3, MerchantID: 10, AuthMethod: models.APIKeyAuthHMAC, SecretCipher: cipher, RevokedAt: &revokedAt},
+	}}
+	svc := NewAPIKeyService(store, box, repositories.NewMemoryNonceStore(), 5*time.Minute)
+
+	body := []byte(`{"amount":100}`)
+	nonceSeq := 0
+	// signed builds a correctly signed request for key id, then applies change
+	signed := func(id int, at time.Time, change func(*SignedRequest)) SignedRequest {
+		nonceSeq++
+		r := SignedRequest{
+			KeyID:     strconv.Itoa(id),
+			Timestamp: strconv.FormatInt(at.Unix(), 10),
+			Nonce:     "nonce-" + strconv.Itoa(1000000000+nonceSeq),
+			Method:    "POST",
+			Path:      "/checkout/pay",
+			Body:      body,
+		}
+		h := hmac.New(sha256.New, []byte(secret))
+		h.Write([]byte(SigningString(r.Method, r.Path, r.Timestamp, r.Nonce, r.Body)))
+		r.Signature = "v1=" + hex.EncodeToString(h.Sum(nil))
+		if change != nil {
+			change(&r)
+		}
+		return r
+	}
+
+	now := time.Now()
+	tests := []struct {
+		name    string
+		req     SignedRequest
+		wantErr error // nil when the request authenticates
+	}{
+		{name: "valid", req: signed(1, now, nil)},
+		{name: "slight clock skew", req: signed(1, now.Add(-4*time.Minute), nil)},
+		{name: "non-numeric key id", req: signed(1, now, func(r *SignedRequest) { r.KeyID = "abc" }), wantErr: ErrInvalidSignature},
+		{name: "bad timestamp", req: signed(1, now, func(r *SignedRequest) { r.Timestamp = "yesterday" }), wantErr: ErrInvalidSignature},
+		{name: "stale timestamp", req: signed(1, now.Add(-6*time.Minute), nil), wantErr: ErrInvalidSignature},
+		{name: "future timestamp", req: signed(1, now.Add(6*time.Minute), nil), wantErr: ErrInvalidSignature},
+		{name: "short nonce", req: signed(1, now, func(r *SignedRequest) { r.Nonce = "short" }), wantErr: ErrInvalidSignature},
+		{name: "missing version", req: signed(1, now, func(r *SignedRequest) { r.Signature = r.Signature[3:] }), wantErr: ErrInvalidSignature},
+		{name: "non-hex signature", req: signed(1, now, func(r *SignedRequest) { r.Signature = "v1=zz" }), wantErr: ErrInvalidSignature},
+		{name: "tampered body", req: signed(1, now, func(r *SignedRequest) { r.Body = []byte(`{"amount":1}`) }), wantErr: ErrInvalidSignature},
+		{name: "tampered path", req: signed(1, now, func(r *SignedRequest) { r.Path = "/checkout/session" }), wantErr: ErrInvalidSignature},
+		{name: "unknown key", req: signed(99, now, nil), wantErr: ErrInvalidAPIKey},
+		{name: "bearer key", req: signed(2, now, nil), wantErr: ErrInvalidAPIKey},
+		{name: "revoked key", req: signed(3, now, nil), wantErr: ErrInvalidAPIKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := svc.AuthenticateSigned(context.Background(), tt.req)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("err = %v, want nil", err)
+				}
+				if k.ID != 1 {
+					t.Fatalf("authenticated key %d, want 1", k.ID)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("replayed nonce", func(t *testing.T) {
+		r := signed(1, now, nil)
+		if _, err := svc.AuthenticateSigned(context.Background(), r); err != nil {
+			t.Fatalf("first use: err = %v", err)
+		}
+		if _, err := svc.AuthenticateSigned(context.Background(), r); !errors.Is(err, ErrInvalidSignature) {
+			t.Fatalf("replay: err = %v, want %v", err, ErrInvalidSignature)
+		}
+	})
+
+	t.Run("forged request does not burn the nonce", func(t *testing.T) {
+		r := signed(1, now, nil)
+		forged := r
+		forged.Body = []byte(`{"amount":1}`)
+		if _, err := svc.AuthenticateSigned(context.Background(), forged); !errors.Is(err, ErrInvalidSignature) {
+			t.Fatalf("forged: err = %v, want %v", err, ErrInvalidSignature)
+		}
+		if _, err := svc.AuthenticateSigned(context.Background(), r); err != nil {
+			t.Fatalf("genuine request after forgery: err = %v", err)
+		}
+	})
+}
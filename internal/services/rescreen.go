@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/kodra-pay/checkout-service/internal/clients"
+	"github.com/kodra-pay/checkout-service/internal/dto"
+	"github.com/kodra-pay/checkout-service/internal/models"
+	"github.com/kodra-pay/checkout-service/internal/repositories"
+)
+
+const (
+	rescreenBatchSize   = 50
+	rescreenMaxAttempts = 20
+)
+
+// failPolicyReviewRetry marks unscreened payments that were screened and flagged, but whose
+// review row could not be written when they were paid
+const failPolicyReviewRetry = "review_retry"
+
+// Rescreener replays unscreened payments against fraud-service once it is reachable again.
+// Payments fraud-service would have flagged or denied are sent to the review queue.
+type Rescreener struct {
+	repo        *repositories.UnscreenedPaymentRepository
+	fraudClient clients.FraudClient
+	reviewQueue ReviewQueue
+}
+
+func NewRescreener(repo *repositories.UnscreenedPaymentRepository, fraudClient clients.FraudClient, reviewQueue ReviewQueue) *Rescreener {
+	return &Rescreener{repo: repo, fraudClient: fraudClient, reviewQueue: reviewQueue}
+}
+
+// Run re-screens pending payments every interval until ctx is cancelled
+func (r *Rescreener) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.runOnce(ctx)
+		}
+	}
+}
+
+func (r *Rescreener) runOnce(ctx context.Context) {
+	pending, err := r.repo.ListPending(ctx, rescreenBatchSize)
+	if err != nil {
+		fmt.Printf("Warning: failed to list unscreened payments: %v\n", err)
+		return
+	}
+	for _, up := range pending {
+		// These were already screened and flagged; they only need the review row written
+		if up.FailPolicy == failPolicyReviewRetry {
+			if err := r.enqueueFlagged(ctx, up); err != nil {
+				fmt.Printf("Error: failed to enqueue flagged transaction %s for review: %v\n", up.TransactionReference, err)
+				r.recordFailure(ctx, up, err)
+				continue
+			}
+			if err := r.repo.MarkScreened(ctx, up.ID, up.LocalDecision, up.FraudScore); err != nil {
+				fmt.Printf("Warning: failed to mark %s as screened: %v\n", up.TransactionReference, err)
+			}
+			continue
+		}
+
+		decision, err := r.fraudClient.CheckTransaction(ctx, dto.FraudCheckRequest{
+			TransactionReference: up.TransactionReference,
+			Amount:               up.Amount,
+			Currency:             up.Currency,
+			CustomerID:           strconv.Itoa(up.CustomerID),
+			MerchantID:           strconv.Itoa(up.MerchantID),
+			Origin:               up.Origin,
+			Country:              up.Country,
+			PaymentMethod:        up.PaymentMethod,
+			CustomData:           up.FraudSignals,
+		})
+		if err != nil {
+			// One failing payment backs off on its own and doesn't hold up the rest of the batch
+			fmt.Printf("Warning: failed to re-screen %s: %v\n", up.TransactionReference, err)
+			r.recordFailure(ctx, up, err)
+			continue
+		}
+
+		// A held credit has to be in the review queue whatever the verdict; enqueuing one that is
+		// already there from its local decision is a no-op
+		if decision.Decision == "flag" || decision.Decision == "deny" || up.WalletCredit == walletCreditHeld {
+			if err := r.enqueue(ctx, up, decision); err != nil {
+				fmt.Printf("Error: failed to enqueue re-screened transaction %s for review: %v\n", up.TransactionReference, err)
+				r.recordFailure(ctx, up, err)
+				continue
+			}
+		}
+		if err := r.repo.MarkScreened(ctx, up.ID, decision.Decision, decision.OverallScore); err != nil {
+			fmt.Printf("Warning: failed to mark %s as screened: %v\n", up.TransactionReference, err)
+		}
+	}
+}
+
+func (r *Rescreener) recordFailure(ctx context.Context, up *models.UnscreenedPayment, cause error) {
+	if err := r.repo.RecordFailure(ctx, up.ID, cause.Error(), rescreenMaxAttempts); err != nil {
+		fmt.Printf("Warning: failed to record rescreen failure for %s: %v\n", up.TransactionReference, err)
+	}
+	// A held credit is retried for as long as it takes, but someone needs to know it is stuck
+	if up.WalletCredit == walletCreditHeld && up.Attempts+1 >= rescreenMaxAttempts {
+		fmt.Printf("Error: held credit of transaction %s is still outside the review queue after %d attempts and needs manual attention: %v\n",
+			up.TransactionReference, up.Attempts+1, cause)
+	}
+}
+
+// enqueueFlagged writes the review row a flagged payment missed, with the reasons it was flagged for
+func (r *Rescreener) enqueueFlagged(ctx context.Context, up *models.UnscreenedPayment) error {
+	reasons := append([]string{"review queue was unavailable when the payment was flagged"}, up.FraudReasons...)
+	return r.reviewQueue.Enqueue(ctx, rescreenReview(up, up.FraudScore, reasons))
+}
+
+func (r *Rescreener) enqueue(ctx context.Context, up *models.UnscreenedPayment, decision dto.FraudDecision) error {
+	reasons := []string{fmt.Sprintf("re-screened after %s fail policy: fraud service decided %s", up.FailPolicy, decision.Decision)}
+	reasons = append(reasons, decision.Reasons...)
+	// Keep what the local rules found at payment time alongside the late verdict
+	for _, reason := range up.FraudReasons {
+		reasons = append(reasons, "at payment time: "+reason)
+	}
+	return r.reviewQueue.Enqueue(ctx, rescreenReview(up, decision.OverallScore, reasons))
+}
+
+func rescreenReview(up *models.UnscreenedPayment, score float64, reasons []string) *models.PaymentReview {
+	return &models.PaymentReview{
+		TransactionReference: up.TransactionReference,
+		MerchantID:           up.MerchantID,
+		CustomerID:           up.CustomerID,
+		CustomerEmail:        up.CustomerEmail,
+		Amount:               up.Amount,
+		FeeAmount:            up.FeeAmount,
+		NetAmount:            up.NetAmount,
+		Currency:             up.Currency,
+		PaymentMethod:        up.PaymentMethod,
+		FraudScore:           score,
+		FraudReasons:         reasons,
+		Status:               models.ReviewStatusPending,
+		WalletCredit:         up.WalletCredit,
+	}
+}
@@ -0,0 +1,268 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/kodra-pay/checkout-service/internal/clients"
+	"github.com/kodra-pay/checkout-service/internal/dto"
+	"github.com/kodra-pay/checkout-service/internal/models"
+	"github.com/kodra-pay/checkout-service/internal/repositories"
+)
+
+var (
+	ErrReviewNotFound   = errors.New("review not found")
+	ErrReviewNotPending = repositories.ErrReviewNotPending
+	ErrReviewerRequired = errors.New("reviewer is required")
+)
+
+// ReviewService lets the risk team work the queue of fraud-flagged payments
+type ReviewService struct {
+	repo               *repositories.PaymentReviewRepository
+	transactionClient  clients.TransactionClient
+	walletLedgerClient clients.WalletLedgerClient
+	payments           CheckoutStore
+	links              PaymentLinkRepository
+	invoices           InvoicePayer
+	events             EventPublisher
+}
+
+func NewReviewService(repo *repositories.PaymentReviewRepository, txClient clients.TransactionClient, wlClient clients.WalletLedgerClient, payments CheckoutStore, links PaymentLinkRepository, invoices InvoicePayer, events EventPublisher) *ReviewService {
+	return &ReviewService{
+		repo:               repo,
+		transactionClient:  txClient,
+		walletLedgerClient: wlClient,
+		payments:           payments,
+		links:              links,
+		invoices:           invoices,
+		events:             events,
+	}
+}
+
+func (s *ReviewService) List(ctx context.Context, status string, limit int) (dto.PaymentReviewListResponse, error) {
+	reviews, err := s.repo.List(ctx, status, limit)
+	if err != nil {
+		return dto.PaymentReviewListResponse{}, fmt.Errorf("failed to list reviews: %w", err)
+	}
+	resp := dto.PaymentReviewListResponse{Reviews: []dto.PaymentReviewResponse{}}
+	for _, pr := range reviews {
+		resp.Reviews = append(resp.Reviews, toPaymentReviewResponse(pr, nil))
+	}
+	return resp, nil
+}
+
+// Get returns a review together with its audit trail
+func (s *ReviewService) Get(ctx context.Context, id int) (*dto.PaymentReviewResponse, error) {
+	pr, err := s.repo.GetByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrReviewNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get review: %w", err)
+	}
+	events, err := s.repo.ListEvents(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list review events: %w", err)
+	}
+	resp := toPaymentReviewResponse(pr, events)
+	return &resp, nil
+}
+
+// Approve marks the transaction successful and releases the held credit to the customer's wallet
+func (s *ReviewService) Approve(ctx context.Context, id int, req dto.ReviewDecisionRequest) (*dto.PaymentReviewResponse, error) {
+	pr, err := s.resolve(ctx, id, models.ReviewStatusApproved, req)
+	if err != nil {
+		return nil, err
+	}
+
+	s.updateTransactionStatus(ctx, pr, "successful", "approved after manual review")
+
+	// Only a held credit is released; payments re-screened after the fact were already settled
+	if pr.WalletCredit == walletCreditHeld {
+		outcome := walletCreditSkipped
+		if pr.CustomerID != 0 {
+			outcome = creditWallet(ctx, s.walletLedgerClient, pr.CustomerID, pr.Currency, pr.NetAmount, pr.FeeAmount, pr.TransactionReference)
+		}
+		s.setWalletCredit(pr, outcome)
+	}
+
+	payment := s.settlePayment(ctx, pr, "paid")
+	if payment.PaymentLinkID != nil {
+		if err := s.invoices.MarkPaidByPaymentLink(ctx, *payment.PaymentLinkID, pr.TransactionReference); err != nil {
+			fmt.Printf("Warning: failed to mark invoice paid for payment link %d: %v\n", *payment.PaymentLinkID, err)
+		}
+	}
+	s.events.Publish(ctx, pr.MerchantID, models.EventPaymentSucceeded, dto.PaymentEvent{CheckoutPaymentResponse: payment})
+
+	return s.Get(ctx, pr.ID)
+}
+
+// Reject refunds the transaction, cancels the held credit and gives the payment link its use back.
+// An invoice behind the link stays open, or is reopened if this payment had already settled it.
+func (s *ReviewService) Reject(ctx context.Context, id int, req dto.ReviewDecisionRequest) (*dto.PaymentReviewResponse, error) {
+	pr, err := s.resolve(ctx, id, models.ReviewStatusRejected, req)
+	if err != nil {
+		return nil, err
+	}
+
+	reason := "rejected after manual review"
+	if req.Notes != "" {
+		reason = req.Notes
+	}
+	s.updateTransactionStatus(ctx, pr, "refunded", reason)
+
+	switch pr.WalletCredit {
+	case walletCreditHeld:
+		s.setWalletCredit(pr, walletCreditCancelled)
+	case walletCreditCredited:
+		// Re-screened payments may already have been credited; claw the credit back
+		s.setWalletCredit(pr, reverseWalletCredit(ctx, s.walletLedgerClient, pr))
+	}
+
+	payment := s.settlePayment(ctx, pr, "refunded")
+	if payment.PaymentLinkID != nil {
+		if err := s.links.ReleaseUse(ctx, *payment.PaymentLinkID); err != nil {
+			fmt.Printf("Warning: failed to release use of payment link %d for rejected payment %s: %v\n", *payment.PaymentLinkID, pr.TransactionReference, err)
+		}
+		if err := s.invoices.ReopenByPaymentLink(ctx, *payment.PaymentLinkID, pr.TransactionReference); err != nil {
+			fmt.Printf("Warning: failed to reopen invoice for payment link %d after rejecting %s: %v\n", *payment.PaymentLinkID, pr.TransactionReference, err)
+		}
+	}
+	s.events.Publish(ctx, pr.MerchantID, models.EventRefundCreated, dto.RefundEvent{
+		TransactionReference: pr.TransactionReference,
+		Amount:               pr.Amount,
+		Currency:             pr.Currency,
+		Reason:               reason,
+		Payment:              payment,
+	})
+
+	return s.Get(ctx, pr.ID)
+}
+
+// settlePayment moves the checkout payment behind a review to its final status and returns it
+// for the webhook event, falling back to the review's copy of the details if it can't be read
+func (s *ReviewService) settlePayment(ctx context.Context, pr *models.PaymentReview, status string) dto.CheckoutPaymentResponse {
+	if err := s.payments.UpdatePaymentStatus(ctx, pr.TransactionReference, status); err != nil {
+		fmt.Printf("Warning: failed to set payment %s to %s: %v\n", pr.TransactionReference, status, err)
+	}
+	p, err := s.payments.GetPaymentByReference(ctx, pr.TransactionReference)
+	if err == nil {
+		p.Status = status
+		return toCheckoutPaymentResponse(p)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		fmt.Printf("Warning: failed to load payment %s: %v\n", pr.TransactionReference, err)
+	}
+	return dto.CheckoutPaymentResponse{
+		TransactionReference: pr.TransactionReference,
+		Status:               status,
+		MerchantID:           pr.MerchantID,
+		CustomerID:           pr.CustomerID,
+		CustomerEmail:        pr.CustomerEmail,
+		Amount:               pr.Amount,
+		FeeAmount:            pr.FeeAmount,
+		NetAmount:            pr.NetAmount,
+		Currency:             pr.Currency,
+		PaymentMethod:        pr.PaymentMethod,
+		CreatedAt:            pr.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func (s *ReviewService) setWalletCredit(pr *models.PaymentReview, outcome string) {
+	// Use a fresh context so the outcome is recorded even if the request was cancelled mid-way
+	if err := s.repo.SetWalletCredit(context.Background(), pr.ID, outcome, "wallet_"+outcome, "system", ""); err != nil {
+		fmt.Printf("Warning: failed to record wallet credit outcome for review %d: %v\n", pr.ID, err)
+	}
+}
+
+// reverseWalletCredit debits a credit that was applied before the payment was rejected
+func reverseWalletCredit(ctx context.Context, wl clients.WalletLedgerClient, pr *models.PaymentReview) string {
+	wallet, err := wl.GetWalletByUserIDAndCurrency(ctx, pr.CustomerID, pr.Currency)
+	if err != nil {
+		fmt.Printf("Warning: failed to get wallet to reverse credit for %s: %v\n", pr.TransactionReference, err)
+		return walletCreditFailed
+	}
+	_, err = wl.UpdateWalletBalance(ctx, wallet.ID, dto.UpdateBalanceRequest{
+		Amount:      int64(math.Round(pr.NetAmount * 100)),
+		Reference:   pr.TransactionReference + "_REVERSAL",
+		Description: fmt.Sprintf("Reversal of credit for rejected transaction %s", pr.TransactionReference),
+		Type:        "debit",
+	})
+	if err != nil {
+		fmt.Printf("Warning: failed to reverse wallet credit for %s: %v\n", pr.TransactionReference, err)
+		return walletCreditFailed
+	}
+	return walletCreditReversed
+}
+
+func (s *ReviewService) resolve(ctx context.Context, id int, status string, req dto.ReviewDecisionRequest) (*models.PaymentReview, error) {
+	if req.Reviewer == "" {
+		return nil, ErrReviewerRequired
+	}
+	pr, err := s.repo.Resolve(ctx, id, status, req.Reviewer, req.Notes)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrReviewNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pr, nil
+}
+
+// updateTransactionStatus propagates a review decision to transaction-service. A failure is
+// recorded in the audit trail rather than undoing the decision, so it can be retried by hand.
+func (s *ReviewService) updateTransactionStatus(ctx context.Context, pr *models.PaymentReview, status, reason string) {
+	_, err := s.transactionClient.UpdateTransactionStatus(ctx, pr.TransactionReference, dto.TransactionStatusUpdateRequest{
+		Status: status,
+		Reason: reason,
+	})
+	if err == nil {
+		return
+	}
+	fmt.Printf("Warning: failed to set transaction %s to %s: %v\n", pr.TransactionReference, status, err)
+	if err := s.repo.AddEvent(ctx, pr.ID, "transaction_update_failed", "system", err.Error()); err != nil {
+		fmt.Printf("Warning: failed to record review event for review %d: %v\n", pr.ID, err)
+	}
+}
+
+func toPaymentReviewResponse(pr *models.PaymentReview, events []*models.PaymentReviewEvent) dto.PaymentReviewResponse {
+	resp := dto.PaymentReviewResponse{
+		ID:                   pr.ID,
+		TransactionReference: pr.TransactionReference,
+		MerchantID:           pr.MerchantID,
+		CustomerID:           pr.CustomerID,
+		CustomerEmail:        pr.CustomerEmail,
+		Amount:               pr.Amount,
+		FeeAmount:            pr.FeeAmount,
+		NetAmount:            pr.NetAmount,
+		Currency:             pr.Currency,
+		PaymentMethod:        pr.PaymentMethod,
+		FraudScore:           pr.FraudScore,
+		FraudReasons:         pr.FraudReasons,
+		Status:               pr.Status,
+		WalletCredit:         pr.WalletCredit,
+		CreatedAt:            pr.CreatedAt.Format(time.RFC3339),
+	}
+	if pr.ReviewedBy != nil {
+		resp.ReviewedBy = *pr.ReviewedBy
+	}
+	if pr.ReviewNotes != nil {
+		resp.ReviewNotes = *pr.ReviewNotes
+	}
+	if pr.ReviewedAt != nil {
+		resp.ReviewedAt = pr.ReviewedAt.Format(time.RFC3339)
+	}
+	for _, ev := range events {
+		resp.Events = append(resp.Events, dto.PaymentReviewEventResponse{
+			Action:    ev.Action,
+			Actor:     ev.Actor,
+			Notes:     ev.Notes,
+			CreatedAt: ev.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	return resp
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// ErrNoEncryptionKey is returned when a secret must be stored encrypted but no key is configured
+var ErrNoEncryptionKey = errors.New("API_KEY_ENCRYPTION_KEY is not configured, so signing secrets cannot be stored")
+
+// SecretBox encrypts secrets the service must be able to read back, such as HMAC signing
+// secrets, with AES-256-GCM. Ciphertexts are nonce || sealed data.
+type SecretBox struct {
+	aead cipher.AEAD // nil when no key is configured
+}
+
+// NewSecretBox takes a base64-encoded 32-byte key; an empty key gives a box that refuses to
+// seal or open anything
+func NewSecretBox(encodedKey string) (*SecretBox, error) {
+	if encodedKey == "" {
+		return &SecretBox{}, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil || len(key) != 32 {
+		return nil, errors.New("encryption key must be 32 bytes, base64-encoded")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &SecretBox{aead: aead}, nil
+}
+
+func (b *SecretBox) Seal(plaintext []byte) ([]byte, error) {
+	if b.aead == nil {
+		return nil, ErrNoEncryptionKey
+	}
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return b.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func (b *SecretBox) Open(ciphertext []byte) ([]byte, error) {
+	if b.aead == nil {
+		return nil, ErrNoEncryptionKey
+	}
+	if len(ciphertext) < b.aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, sealed := ciphertext[:b.aead.NonceSize()], ciphertext[b.aead.NonceSize():]
+	return b.aead.Open(nil, nonce, sealed, nil)
+}
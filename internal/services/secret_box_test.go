@@ -0,0 +1,100 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func testSecretBox(t *testing.T, seed byte) *SecretBox {
+	t.Helper()
+	box, err := NewSecretBox(base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{seed}, 32)))
+	if err != nil {
+		t.Fatalf("NewSecretBox: %v", err)
+	}
+	return box
+}
+
+func TestNewSecretBoxKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{name: "no key", key: ""},
+		{name: "32 bytes", key: base64.StdEncoding.EncodeToString(make([]byte, 32))},
+		{name: "16 bytes", key: base64.StdEncoding.EncodeToString(make([]byte, 16)), wantErr: true},
+		{name: "not base64", key: "not*base64", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewSecretBox(tt.key); (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSecretBoxRoundTrip(t *testing.T) {
+	box := testSecretBox(t, 1)
+	for _, plaintext := range [][]byte{[]byte("kp_sk_0123456789abcdef"), {}, bytes.Repeat([]byte{0xff}, 1024)} {
+		sealed, err := box.Seal(plaintext)
+		if err != nil {
+			t.Fatalf("Seal: %v", err)
+		}
+		if len(plaintext) > 0 && bytes.Contains(sealed, plaintext) {
+			t.Fatalf("ciphertext contains the plaintext")
+		}
+		opened, err := box.Open(sealed)
+		if err != nil {
+			t.Fatalf("Open: %v", err)
+		}
+		if !bytes.Equal(opened, plaintext) {
+			t.Fatalf("Open = %q, want %q", opened, plaintext)
+		}
+	}
+
+	// A fresh nonce per seal means the same secret never encrypts the same way twice
+	a, _ := box.Seal([]byte("secret"))
+	b, _ := box.Seal([]byte("secret"))
+	if bytes.Equal(a, b) {
+		t.Errorf("two seals of the same plaintext are identical")
+	}
+}
+
+func TestSecretBoxOpenRejects(t *testing.T) {
+	box := testSecretBox(t, 1)
+	sealed, err := box.Seal([]byte("secret"))
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	tampered := bytes.Clone(sealed)
+	tampered[len(tampered)-1] ^= 1
+
+	tests := []struct {
+		name       string
+		box        *SecretBox
+		ciphertext []byte
+		wantErr    error // nil accepts any error
+	}{
+		{name: "tampered", box: box, ciphertext: tampered},
+		{name: "truncated", box: box, ciphertext: sealed[:4]},
+		{name: "other key", box: testSecretBox(t, 2), ciphertext: sealed},
+		{name: "no key", box: &SecretBox{}, ciphertext: sealed, wantErr: ErrNoEncryptionKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.box.Open(tt.ciphertext)
+			if err == nil {
+				t.Fatalf("Open succeeded")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+	if _, err := (&SecretBox{}).Seal([]byte("secret")); !errors.Is(err, ErrNoEncryptionKey) {
+		t.Errorf("Seal without a key: err = %v, want %v", err, ErrNoEncryptionKey)
+	}
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Velocity dimensions a rule can count payments by
+const (
+	velocityByCustomer = "customer"
+	velocityByEmail    = "email"
+	velocityByIP       = "ip"
+	velocityByLink     = "link"
+)
+
+// DefaultVelocityRules apply when VELOCITY_RULES is not set
+const DefaultVelocityRules = `[
+	{"name": "ip_burst", "dimension": "ip", "window": "1m", "max_count": 10, "action": "deny"},
+	{"name": "customer_hourly", "dimension": "customer", "window": "1h", "max_count": 20, "max_amount": 1000000, "action": "flag"},
+	{"name": "email_hourly", "dimension": "email", "window": "1h", "max_count": 20, "max_amount": 1000000, "action": "flag"},
+	{"name": "link_burst", "dimension": "link", "window": "1m", "max_count": 60, "action": "flag"}
+]`
+
+// VelocityStore counts payments per key over a sliding window
+type VelocityStore interface {
+	Add(ctx context.Context, key string, at time.Time, amount float64, window time.Duration) (count int, total float64, err error)
+}
+
+// VelocityRule limits how many payments, or how much value, one customer/email/IP/link may push through in a window
+type VelocityRule struct {
+	Name      string        `json:"name"`
+	Dimension string        `json:"dimension"` // customer, email, ip, link
+	Window    time.Duration `json:"-"`
+	MaxCount  int           `json:"max_count"`  // 0 disables the count limit
+	MaxAmount float64       `json:"max_amount"` // currency units, summed per currency; 0 disables the amount limit
+	Action    string        `json:"action"`     // deny or flag
+}
+
+// ParseVelocityRules reads rules from JSON, with windows given as Go durations (e.g. "10m")
+func ParseVelocityRules(raw string) ([]VelocityRule, error) {
+	if strings.TrimSpace(raw) == "" {
+		raw = DefaultVelocityRules
+	}
+	var specs []struct {
+		VelocityRule
+		Window string `json:"window"`
+	}
+	if err := json.Unmarshal([]byte(raw), &specs); err != nil {
+		return nil, fmt.Errorf("parse velocity rules: %w", err)
+	}
+
+	rules := make([]VelocityRule, 0, len(specs))
+	for _, spec := range specs {
+		rule := spec.VelocityRule
+		window, err := time.ParseDuration(spec.Window)
+		if err != nil || window <= 0 {
+			return nil, fmt.Errorf("velocity rule %q: invalid window %q", rule.Name, spec.Window)
+		}
+		rule.Window = window
+		switch rule.Dimension {
+		case velocityByCustomer, velocityByEmail, velocityByIP, velocityByLink:
+		default:
+			return nil, fmt.Errorf("velocity rule %q: unknown dimension %q", rule.Name, rule.Dimension)
+		}
+		if rule.Action != "deny" && rule.Action != "flag" {
+			return nil, fmt.Errorf("velocity rule %q: action must be deny or flag", rule.Name)
+		}
+		if rule.MaxCount <= 0 && rule.MaxAmount <= 0 {
+			return nil, fmt.Errorf("velocity rule %q: max_count or max_amount is required", rule.Name)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// velocitySubject identifies the payment being counted
+type velocitySubject struct {
+	CustomerID    int
+	Email         string
+	Origin        string
+	PaymentLinkID int
+	Amount        float64
+	Currency      string
+}
+
+// velocityResult is the strongest action triggered by any breached rule ("" when none)
+type velocityResult struct {
+	Action  string
+	Reasons []string
+}
+
+// VelocityChecker enforces velocity rules locally, ahead of the remote fraud check
+type VelocityChecker struct {
+	store VelocityStore
+	rules []VelocityRule
+}
+
+func NewVelocityChecker(store VelocityStore, rules []VelocityRule) *VelocityChecker {
+	return &VelocityChecker{store: store, rules: rules}
+}
+
+// Check records the payment attempt against every applicable rule and reports breaches.
+// Store errors are logged and the rule skipped, so a Redis outage never blocks checkout.
+func (v *VelocityChecker) Check(ctx context.Context, subject velocitySubject) velocityResult {
+	var result velocityResult
+	now := time.Now()
+	for _, rule := range v.rules {
+		value := subject.value(rule.Dimension)
+		if value == "" {
+			continue
+		}
+		key := rule.Name + ":" + rule.Dimension + ":" + value
+		// Payments are counted across currencies, but amounts only add up within one
+		var count int
+		var total float64
+		var err error
+		if rule.MaxCount > 0 {
+			count, _, err = v.store.Add(ctx, key, now, subject.Amount, rule.Window)
+		}
+		if err == nil && rule.MaxAmount > 0 {
+			_, total, err = v.store.Add(ctx, key+":"+strings.ToUpper(subject.Currency), now, subject.Amount, rule.Window)
+		}
+		if err != nil {
+			fmt.Printf("Warning: velocity rule %s skipped: %v\n", rule.Name, err)
+			continue
+		}
+
+		var reason string
+		switch {
+		case rule.MaxCount > 0 && count > rule.MaxCount:
+			reason = fmt.Sprintf("velocity %s: %d payments by %s in %s exceeds %d", rule.Name, count, rule.Dimension, rule.Window, rule.MaxCount)
+		case rule.MaxAmount > 0 && total > rule.MaxAmount:
+			reason = fmt.Sprintf("velocity %s: %.2f %s paid by %s in %s exceeds %.2f", rule.Name, total, strings.ToUpper(subject.Currency), rule.Dimension, rule.Window, rule.MaxAmount)
+		default:
+			continue
+		}
+		result.Reasons = append(result.Reasons, reason)
+		if rule.Action == "deny" || result.Action == "" {
+			result.Action = rule.Action
+		}
+	}
+	return result
+}
+
+func (s velocitySubject) value(dimension string) string {
+	switch dimension {
+	case velocityByCustomer:
+		if s.CustomerID != 0 {
+			return strconv.Itoa(s.CustomerID)
+		}
+	case velocityByEmail:
+		return strings.ToLower(strings.TrimSpace(s.Email))
+	case velocityByIP:
+		return s.Origin
+	case velocityByLink:
+		if s.PaymentLinkID != 0 {
+			return strconv.Itoa(s.PaymentLinkID)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kodra-pay/checkout-service/internal/repositories"
+)
+
+func TestParseVelocityRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    int
+		wantErr bool
+	}{
+		{name: "empty uses defaults", raw: "", want: 4},
+		{name: "valid rule", raw: `[{"name":"r","dimension":"ip","window":"10m","max_count":3,"action":"deny"}]`, want: 1},
+		{name: "bad window", raw: `[{"name":"r","dimension":"ip","window":"soon","max_count":3,"action":"deny"}]`, wantErr: true},
+		{name: "zero window", raw: `[{"name":"r","dimension":"ip","window":"0s","max_count":3,"action":"deny"}]`, wantErr: true},
+		{name: "unknown dimension", raw: `[{"name":"r","dimension":"card","window":"1m","max_count":3,"action":"deny"}]`, wantErr: true},
+		{name: "unknown action", raw: `[{"name":"r","dimension":"ip","window":"1m","max_count":3,"action":"block"}]`, wantErr: true},
+		{name: "no limit", raw: `[{"name":"r","dimension":"ip","window":"1m","action":"flag"}]`, wantErr: true},
+		{name: "not json", raw: `{`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := ParseVelocityRules(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && len(rules) != tt.want {
+				t.Errorf("got %d rules, want %d", len(rules), tt.want)
+			}
+		})
+	}
+}
+
+func TestVelocityCheckerCheck(t *testing.T) {
+	rules := []VelocityRule{
+		{Name: "ip_burst", Dimension: velocityByIP, Window: time.Minute, MaxCount: 2, Action: "deny"},
+		{Name: "customer_amount", Dimension: velocityByCustomer, Window: time.Hour, MaxAmount: 1000, Action: "flag"},
+		{Name: "email_hourly", Dimension: velocityByEmail, Window: time.Hour, MaxCount: 2, MaxAmount: 1000, Action: "flag"},
+	}
+	tests := []struct {
+		name       string
+		payments   []velocitySubject // earlier payments, then the one checked last
+		wantAction string
+		wantReason int
+	}{
+		{
+			name:     "under every limit",
+			payments: []velocitySubject{{Origin: "1.1.1.1", CustomerID: 7, Amount: 100}},
+		},
+		{
+			name: "count breach denies",
+			payments: []velocitySubject{
+				{Origin: "1.1.1.1", Amount: 1}, {Origin: "1.1.1.1", Amount: 1}, {Origin: "1.1.1.1", Amount: 1},
+			},
+			wantAction: "deny",
+			wantReason: 1,
+		},
+		{
+			name: "amount breach flags",
+			payments: []velocitySubject{
+				{CustomerID: 7, Amount: 600, Currency: "NGN"}, {CustomerID: 7, Amount: 600, Currency: "ngn"},
+			},
+			wantAction: "flag",
+			wantReason: 1,
+		},
+		{
+			name: "amounts in different currencies are not added up",
+			payments: []velocitySubject{
+				{CustomerID: 7, Amount: 600, Currency: "NGN"}, {CustomerID: 7, Amount: 600, Currency: "USD"},
+			},
+		},
+		{
+			name: "count spans currencies",
+			payments: []velocitySubject{
+				{Email: "a@example.com", Amount: 1, Currency: "NGN"},
+				{Email: "a@example.com", Amount: 1, Currency: "USD"},
+				{Email: "a@example.com", Amount: 1, Currency: "GHS"},
+			},
+			wantAction: "flag",
+			wantReason: 1,
+		},
+		{
+			name: "deny wins over flag",
+			payments: []velocitySubject{
+				{Origin: "2.2.2.2", CustomerID: 8, Amount: 600},
+				{Origin: "2.2.2.2", CustomerID: 8, Amount: 600},
+				{Origin: "2.2.2.2", CustomerID: 8, Amount: 1},
+			},
+			wantAction: "deny",
+			wantReason: 2,
+		},
+		{
+			name:     "missing dimension is skipped",
+			payments: []velocitySubject{{Amount: 5000}, {Amount: 5000}, {Amount: 5000}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewVelocityChecker(repositories.NewMemoryVelocityStore(), rules)
+			var got velocityResult
+			for _, p := range tt.payments {
+				got = v.Check(context.Background(), p)
+			}
+			if got.Action != tt.wantAction || len(got.Reasons) != tt.wantReason {
+				t.Errorf("got action %q with %d reasons %v, want %q with %d", got.Action, len(got.Reasons), got.Reasons, tt.wantAction, tt.wantReason)
+			}
+		})
+	}
+}
@@ -0,0 +1,496 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kodra-pay/checkout-service/internal/dto"
+	"github.com/kodra-pay/checkout-service/internal/models"
+	"github.com/kodra-pay/checkout-service/internal/repositories"
+)
+
+var (
+	ErrWebhookEndpointNotFound = errors.New("webhook endpoint not found")
+	ErrWebhookDeliveryNotFound = errors.New("dead webhook delivery not found")
+	ErrInvalidWebhookEndpoint  = errors.New("invalid webhook endpoint")
+
+	// errWebhookAddressNotAllowed is returned when dialling an endpoint that resolves to an internal address
+	errWebhookAddressNotAllowed = errors.New("endpoint address is not allowed")
+)
+
+// Headers sent with every webhook delivery
+const (
+	HeaderWebhookID        = "X-Kodra-Webhook-Id" // the event id, unchanged across retries
+	HeaderWebhookEvent     = "X-Kodra-Webhook-Event"
+	HeaderWebhookTimestamp = "X-Kodra-Webhook-Timestamp" // Unix seconds of this attempt
+	HeaderWebhookSignature = "X-Kodra-Webhook-Signature" // v1=<hex HMAC-SHA256 of WebhookSigningString, keyed with the endpoint secret>
+)
+
+const (
+	webhookSecretPrefix          = "whsec_"
+	webhookSecretRandomByteCount = 24
+	webhookSecretDisplayLength   = 12 // "whsec_" plus 6 hex characters
+	webhookBatchSize             = 50
+	webhookDrainLimit            = 4096 // bytes of a response read, and discarded, so the connection can be reused
+)
+
+// blockedWebhookNets are special-purpose ranges webhooks must not reach that the net.IP
+// predicates don't cover: "this network", carrier-grade NAT, IETF protocol assignments,
+// benchmarking, reserved (including broadcast) and NAT64, which can embed any IPv4 address
+var blockedWebhookNets = func() []*net.IPNet {
+	var nets []*net.IPNet
+	for _, cidr := range []string{"0.0.0.0/8", "100.64.0.0/10", "192.0.0.0/24", "198.18.0.0/15", "240.0.0.0/4", "64:ff9b::/96"} {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}()
+
+// webhookIPAllowed reports whether ip is a public unicast address. Loopback, private,
+// link-local (which includes cloud metadata services) and the like are refused so merchants
+// can't point webhooks at the service's own network.
+func webhookIPAllowed(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsMulticast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+	for _, n := range blockedWebhookNets {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// webhookDialControl checks the address actually being dialled, after DNS resolution, so a
+// hostname that resolved to a public address when the endpoint was created can't later be
+// rebound to an internal one
+func webhookDialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !webhookIPAllowed(ip) {
+		return errWebhookAddressNotAllowed
+	}
+	return nil
+}
+
+// WebhookRetryPolicy decides how often and how long a failing delivery is retried before it is
+// dead-lettered. The wait doubles after each failed attempt, starting at InitialBackoff.
+type WebhookRetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// backoff is the wait after the attempt-th failed attempt
+func (p WebhookRetryPolicy) backoff(attempt int) time.Duration {
+	d := p.InitialBackoff
+	for i := 1; i < attempt && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	return d
+}
+
+// WebhookService manages merchants' webhook endpoints, queues events for them and delivers
+// the queue with retries
+type WebhookService struct {
+	repo     *repositories.WebhookRepository
+	box      *SecretBox // encrypts endpoint signing secrets
+	client   *http.Client
+	retry    WebhookRetryPolicy
+	lookupIP func(ctx context.Context, host string) ([]net.IPAddr, error) // resolves endpoint hosts when they are registered
+}
+
+func NewWebhookService(repo *repositories.WebhookRepository, box *SecretBox, timeout time.Duration, retry WebhookRetryPolicy) *WebhookService {
+	dialer := &net.Dialer{Timeout: timeout, Control: webhookDialControl}
+	return &WebhookService{
+		repo: repo,
+		box:  box,
+		client: &http.Client{
+			Timeout: timeout,
+			// No proxy, so every connection goes through the dialer's address check
+			Transport: &http.Transport{
+				Proxy:               nil,
+				DialContext:         dialer.DialContext,
+				ForceAttemptHTTP2:   true,
+				TLSHandshakeTimeout: timeout,
+				MaxIdleConns:        100,
+				IdleConnTimeout:     90 * time.Second,
+			},
+			// A redirect is reported as the endpoint's response rather than followed
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		retry:    retry,
+		lookupIP: net.DefaultResolver.LookupIPAddr,
+	}
+}
+
+// WebhookSigningString is the message webhook signatures cover: TIMESTAMP.BODY
+func WebhookSigningString(timestamp string, body []byte) []byte {
+	return append([]byte(timestamp+"."), body...)
+}
+
+// SignWebhook returns the hex HMAC-SHA256 of the signing string, keyed with the endpoint secret
+func SignWebhook(secret []byte, timestamp string, body []byte) string {
+	h := hmac.New(sha256.New, secret)
+	h.Write(WebhookSigningString(timestamp, body))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// CreateEndpoint registers an endpoint and returns its signing secret, which is shown only once
+func (s *WebhookService) CreateEndpoint(ctx context.Context, merchantID int, req dto.WebhookEndpointCreateRequest) (dto.WebhookEndpointResponse, error) {
+	u, err := s.endpointURL(ctx, req.URL)
+	if err != nil {
+		return dto.WebhookEndpointResponse{}, err
+	}
+	var events []string
+	for _, ev := range req.Events {
+		if !containsString(models.WebhookEventTypes, ev) {
+			return dto.WebhookEndpointResponse{}, fmt.Errorf("%w: unknown event %q; expected one of %s",
+				ErrInvalidWebhookEndpoint, ev, strings.Join(models.WebhookEventTypes, ", "))
+		}
+		if !containsString(events, ev) {
+			events = append(events, ev)
+		}
+	}
+
+	buf := make([]byte, webhookSecretRandomByteCount)
+	if _, err := rand.Read(buf); err != nil {
+		return dto.WebhookEndpointResponse{}, fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	secret := webhookSecretPrefix + hex.EncodeToString(buf)
+	cipher, err := s.box.Seal([]byte(secret))
+	if err != nil {
+		return dto.WebhookEndpointResponse{}, fmt.Errorf("failed to encrypt webhook secret: %w", err)
+	}
+
+	e := &models.WebhookEndpoint{
+		MerchantID:   merchantID,
+		URL:          u.String(),
+		Description:  req.Description,
+		Events:       events,
+		SecretPrefix: secret[:webhookSecretDisplayLength],
+		SecretCipher: cipher,
+		Status:       models.WebhookEndpointActive,
+	}
+	if err := s.repo.CreateEndpoint(ctx, e); err != nil {
+		return dto.WebhookEndpointResponse{}, fmt.Errorf("failed to create webhook endpoint: %w", err)
+	}
+	resp := toWebhookEndpointResponse(e)
+	resp.Secret = secret
+	return resp, nil
+}
+
+func (s *WebhookService) ListEndpoints(ctx context.Context, merchantID int) (dto.WebhookEndpointListResponse, error) {
+	endpoints, err := s.repo.ListEndpoints(ctx, merchantID)
+	if err != nil {
+		return dto.WebhookEndpointListResponse{}, fmt.Errorf("failed to list webhook endpoints: %w", err)
+	}
+	resp := dto.WebhookEndpointListResponse{Endpoints: []dto.WebhookEndpointResponse{}}
+	for _, e := range endpoints {
+		resp.Endpoints = append(resp.Endpoints, toWebhookEndpointResponse(e))
+	}
+	return resp, nil
+}
+
+// DisableEndpoint stops deliveries to the endpoint; deliveries still queued for it are dead-lettered
+func (s *WebhookService) DisableEndpoint(ctx context.Context, merchantID, id int) (dto.WebhookEndpointResponse, error) {
+	e, err := s.repo.DisableEndpoint(ctx, merchantID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return dto.WebhookEndpointResponse{}, ErrWebhookEndpointNotFound
+	}
+	if err != nil {
+		return dto.WebhookEndpointResponse{}, fmt.Errorf("failed to disable webhook endpoint: %w", err)
+	}
+	return toWebhookEndpointResponse(e), nil
+}
+
+func (s *WebhookService) ListDeliveries(ctx context.Context, merchantID, endpointID int, status string, limit int) (dto.WebhookDeliveryListResponse, error) {
+	deliveries, err := s.repo.ListDeliveries(ctx, merchantID, endpointID, status, limit)
+	if err != nil {
+		return dto.WebhookDeliveryListResponse{}, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	resp := dto.WebhookDeliveryListResponse{Deliveries: []dto.WebhookDeliveryResponse{}}
+	for _, d := range deliveries {
+		resp.Deliveries = append(resp.Deliveries, toWebhookDeliveryResponse(d))
+	}
+	return resp, nil
+}
+
+// RetryDelivery puts a dead-lettered delivery back in the queue with a fresh set of attempts
+func (s *WebhookService) RetryDelivery(ctx context.Context, merchantID, id int) (dto.WebhookDeliveryResponse, error) {
+	d, err := s.repo.Requeue(ctx, merchantID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return dto.WebhookDeliveryResponse{}, ErrWebhookDeliveryNotFound
+	}
+	if err != nil {
+		return dto.WebhookDeliveryResponse{}, fmt.Errorf("failed to requeue webhook delivery: %w", err)
+	}
+	return toWebhookDeliveryResponse(d), nil
+}
+
+// Publish queues an event for each of the merchant's endpoints subscribed to it. Failures are
+// logged and never affect the operation that raised the event.
+func (s *WebhookService) Publish(ctx context.Context, merchantID int, eventType string, data interface{}) {
+	ctx = context.WithoutCancel(ctx)
+	endpoints, err := s.repo.ListSubscribed(ctx, merchantID, eventType)
+	if err != nil {
+		fmt.Printf("Warning: failed to look up webhook endpoints of merchant %d for %s: %v\n", merchantID, eventType, err)
+		return
+	}
+	if len(endpoints) == 0 {
+		return
+	}
+
+	event := dto.WebhookEvent{
+		ID:         "evt_" + strings.ReplaceAll(uuid.New().String(), "-", ""),
+		Type:       eventType,
+		MerchantID: merchantID,
+		CreatedAt:  time.Now().UTC().Format(time.RFC3339),
+		Data:       data,
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		fmt.Printf("Warning: failed to encode %s event for merchant %d: %v\n", eventType, merchantID, err)
+		return
+	}
+	for _, e := range endpoints {
+		err := s.repo.Enqueue(ctx, &models.WebhookDelivery{
+			EndpointID: e.ID,
+			MerchantID: merchantID,
+			EventID:    event.ID,
+			EventType:  eventType,
+			Payload:    payload,
+		})
+		if err != nil {
+			fmt.Printf("Warning: failed to queue %s event %s for webhook endpoint %d: %v\n", eventType, event.ID, e.ID, err)
+		}
+	}
+}
+
+// RunDelivery delivers due webhooks every interval until ctx is cancelled
+func (s *WebhookService) RunDelivery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.deliverDue(ctx)
+		}
+	}
+}
+
+// deliverDue works through every delivery that is due, a batch at a time. Deliveries in a
+// batch are sent concurrently so one slow endpoint doesn't hold up the others.
+func (s *WebhookService) deliverDue(ctx context.Context) {
+	// Claimed deliveries stay leased long enough for the slowest attempt to be recorded
+	lease := s.client.Timeout + time.Minute
+	for {
+		deliveries, err := s.repo.ClaimDue(ctx, webhookBatchSize, lease)
+		if err != nil {
+			fmt.Printf("Warning: failed to claim due webhook deliveries: %v\n", err)
+			return
+		}
+
+		endpoints := map[int]*models.WebhookEndpoint{}
+		var wg sync.WaitGroup
+		for _, d := range deliveries {
+			e, ok := endpoints[d.EndpointID]
+			if !ok {
+				if e, err = s.repo.GetEndpoint(ctx, d.EndpointID); err != nil {
+					fmt.Printf("Warning: failed to load webhook endpoint %d: %v\n", d.EndpointID, err)
+					continue // the lease runs out and the delivery is retried
+				}
+				endpoints[d.EndpointID] = e
+			}
+			wg.Add(1)
+			go func(d *models.WebhookDelivery, e *models.WebhookEndpoint) {
+				defer wg.Done()
+				s.deliver(ctx, d, e)
+			}(d, e)
+		}
+		wg.Wait()
+
+		if len(deliveries) < webhookBatchSize {
+			return
+		}
+	}
+}
+
+// endpointURL validates a webhook URL: it must be https and its host must resolve only to
+// public addresses. Deliveries check the address again when they connect.
+func (s *WebhookService) endpointURL(ctx context.Context, raw string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Scheme != "https" || u.Host == "" || u.User != nil {
+		return nil, fmt.Errorf("%w: url must be an absolute https URL", ErrInvalidWebhookEndpoint)
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return nil, fmt.Errorf("%w: url must point at a public host", ErrInvalidWebhookEndpoint)
+	}
+
+	var ips []net.IP
+	if ip := net.ParseIP(host); ip != nil {
+		ips = append(ips, ip)
+	} else {
+		addrs, err := s.lookupIP(ctx, host)
+		if err != nil || len(addrs) == 0 {
+			return nil, fmt.Errorf("%w: host %q does not resolve", ErrInvalidWebhookEndpoint, host)
+		}
+		for _, a := range addrs {
+			ips = append(ips, a.IP)
+		}
+	}
+	for _, ip := range ips {
+		if !webhookIPAllowed(ip) {
+			return nil, fmt.Errorf("%w: url must point at a public host", ErrInvalidWebhookEndpoint)
+		}
+	}
+	return u, nil
+}
+
+// deliver makes one attempt at a delivery and records the outcome
+func (s *WebhookService) deliver(ctx context.Context, d *models.WebhookDelivery, e *models.WebhookEndpoint) {
+	if e.Status != models.WebhookEndpointActive {
+		s.record(ctx, d, models.WebhookDeliveryDead, nil, "endpoint is disabled", time.Now())
+		return
+	}
+	secret, err := s.box.Open(e.SecretCipher)
+	if err != nil {
+		s.fail(ctx, d, nil, fmt.Sprintf("failed to decrypt endpoint secret: %v", err))
+		return
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		s.fail(ctx, d, nil, "invalid endpoint url")
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "KodraPay-Webhooks/1.0")
+	req.Header.Set(HeaderWebhookID, d.EventID)
+	req.Header.Set(HeaderWebhookEvent, d.EventType)
+	req.Header.Set(HeaderWebhookTimestamp, timestamp)
+	req.Header.Set(HeaderWebhookSignature, "v1="+SignWebhook(secret, timestamp, d.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		s.fail(ctx, d, nil, webhookTransportError(err))
+		return
+	}
+	defer resp.Body.Close()
+	// The response body is never kept: merchants read last_error, and it must not become a
+	// window onto whatever answered
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, webhookDrainLimit))
+	code := resp.StatusCode
+
+	if code >= 200 && code < 300 {
+		s.record(ctx, d, models.WebhookDeliveryDelivered, &code, "", time.Now())
+		return
+	}
+	s.fail(ctx, d, &code, fmt.Sprintf("endpoint responded %d", code))
+}
+
+// webhookTransportError reduces a failed request to a fixed message, so nothing the network
+// said about the target ends up in last_error
+func webhookTransportError(err error) string {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, errWebhookAddressNotAllowed):
+		return errWebhookAddressNotAllowed.Error()
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "request timed out"
+	default:
+		return "connection failed"
+	}
+}
+
+// fail schedules the next attempt after a backoff, or dead-letters the delivery once it has
+// used all of its attempts
+func (s *WebhookService) fail(ctx context.Context, d *models.WebhookDelivery, statusCode *int, msg string) {
+	attempts := d.Attempts + 1
+	if attempts >= s.retry.MaxAttempts {
+		fmt.Printf("Warning: giving up on %s webhook %s for endpoint %d after %d attempts: %s\n",
+			d.EventType, d.EventID, d.EndpointID, attempts, msg)
+		s.record(ctx, d, models.WebhookDeliveryDead, statusCode, msg, time.Now())
+		return
+	}
+	s.record(ctx, d, models.WebhookDeliveryPending, statusCode, msg, time.Now().Add(s.retry.backoff(attempts)))
+}
+
+func (s *WebhookService) record(ctx context.Context, d *models.WebhookDelivery, status string, statusCode *int, msg string, next time.Time) {
+	if err := s.repo.RecordAttempt(ctx, d.ID, status, statusCode, msg, next); err != nil {
+		fmt.Printf("Warning: failed to record attempt of webhook delivery %d: %v\n", d.ID, err)
+	}
+}
+
+func toWebhookEndpointResponse(e *models.WebhookEndpoint) dto.WebhookEndpointResponse {
+	events := e.Events
+	if events == nil {
+		events = models.WebhookEventTypes
+	}
+	return dto.WebhookEndpointResponse{
+		ID:           e.ID,
+		MerchantID:   e.MerchantID,
+		URL:          e.URL,
+		Description:  e.Description,
+		Events:       events,
+		SecretPrefix: e.SecretPrefix,
+		Status:       e.Status,
+		CreatedAt:    e.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func toWebhookDeliveryResponse(d *models.WebhookDelivery) dto.WebhookDeliveryResponse {
+	resp := dto.WebhookDeliveryResponse{
+		ID:             d.ID,
+		EndpointID:     d.EndpointID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt.Format(time.RFC3339),
+	}
+	if d.Status == models.WebhookDeliveryPending {
+		resp.NextAttemptAt = d.NextAttemptAt.Format(time.RFC3339)
+	}
+	if d.DeliveredAt != nil {
+		resp.DeliveredAt = d.DeliveredAt.Format(time.RFC3339)
+	}
+	return resp
+}
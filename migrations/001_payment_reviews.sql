@@ -0,0 +1,37 @@
+-- Manual review queue for fraud-flagged payments
+CREATE TABLE IF NOT EXISTS payment_reviews (
+    id                    SERIAL PRIMARY KEY,
+    transaction_reference TEXT          NOT NULL UNIQUE,
+    merchant_id           INTEGER       NOT NULL,
+    customer_id           INTEGER       NOT NULL DEFAULT 0,
+    customer_email        TEXT          NOT NULL DEFAULT '',
+    amount                NUMERIC(18,2) NOT NULL,
+    fee_amount            NUMERIC(18,2) NOT NULL DEFAULT 0,
+    net_amount            NUMERIC(18,2) NOT NULL,
+    currency              TEXT          NOT NULL,
+    payment_method        TEXT          NOT NULL DEFAULT '',
+    fraud_score           DOUBLE PRECISION NOT NULL DEFAULT 0,
+    fraud_reasons         TEXT[]        NOT NULL DEFAULT '{}',
+    status                TEXT          NOT NULL DEFAULT 'pending', -- pending, approved, rejected
+    wallet_credit         TEXT          NOT NULL DEFAULT 'held',    -- held, credited, failed, skipped, cancelled
+    reviewed_by           TEXT,
+    review_notes          TEXT,
+    reviewed_at           TIMESTAMPTZ,
+    created_at            TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    updated_at            TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_reviews_status_created ON payment_reviews (status, created_at);
+CREATE INDEX IF NOT EXISTS idx_payment_reviews_merchant ON payment_reviews (merchant_id);
+
+-- Append-only audit trail of review actions
+CREATE TABLE IF NOT EXISTS payment_review_events (
+    id         SERIAL PRIMARY KEY,
+    review_id  INTEGER     NOT NULL REFERENCES payment_reviews(id),
+    action     TEXT        NOT NULL, -- enqueued, approved, rejected, wallet_credited, wallet_credit_failed, transaction_update_failed
+    actor      TEXT        NOT NULL,
+    notes      TEXT        NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_review_events_review ON payment_review_events (review_id, created_at);
@@ -0,0 +1,35 @@
+-- Per-merchant behaviour when fraud-service is unavailable
+CREATE TABLE IF NOT EXISTS merchant_fraud_policies (
+    merchant_id INTEGER       PRIMARY KEY,
+    mode        TEXT          NOT NULL,           -- closed, open, local
+    max_amount  NUMERIC(18,2) NOT NULL DEFAULT 0, -- payments above this fail closed; 0 means no ceiling
+    updated_at  TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+-- Payments accepted without a fraud-service verdict, waiting to be re-screened
+CREATE TABLE IF NOT EXISTS unscreened_payments (
+    id                    SERIAL PRIMARY KEY,
+    transaction_reference TEXT          NOT NULL UNIQUE,
+    merchant_id           INTEGER       NOT NULL,
+    customer_id           INTEGER       NOT NULL DEFAULT 0,
+    customer_email        TEXT          NOT NULL DEFAULT '',
+    amount                NUMERIC(18,2) NOT NULL,
+    fee_amount            NUMERIC(18,2) NOT NULL DEFAULT 0,
+    net_amount            NUMERIC(18,2) NOT NULL,
+    currency              TEXT          NOT NULL,
+    payment_method        TEXT          NOT NULL DEFAULT '',
+    origin                TEXT          NOT NULL DEFAULT '',
+    fail_policy           TEXT          NOT NULL,  -- open, local
+    local_decision        TEXT          NOT NULL DEFAULT '',
+    wallet_credit         TEXT          NOT NULL DEFAULT '',
+    status                TEXT          NOT NULL DEFAULT 'pending', -- pending, screened, abandoned
+    attempts              INTEGER       NOT NULL DEFAULT 0,
+    last_error            TEXT          NOT NULL DEFAULT '',
+    screened_decision     TEXT          NOT NULL DEFAULT '',
+    screened_score        DOUBLE PRECISION NOT NULL DEFAULT 0,
+    screened_at           TIMESTAMPTZ,
+    created_at            TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    updated_at            TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_unscreened_payments_status ON unscreened_payments (status, created_at);
@@ -0,0 +1,40 @@
+-- Checkout sessions created by merchants ahead of a hosted payment
+CREATE TABLE IF NOT EXISTS checkout_sessions (
+    id             SERIAL PRIMARY KEY,
+    merchant_id    INTEGER       NOT NULL,
+    amount         NUMERIC(18,2) NOT NULL,
+    currency       TEXT          NOT NULL,
+    description    TEXT          NOT NULL DEFAULT '',
+    customer_email TEXT          NOT NULL DEFAULT '',
+    customer_id    INTEGER       NOT NULL DEFAULT 0,
+    status         TEXT          NOT NULL DEFAULT 'pending', -- pending, completed
+    created_at     TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    updated_at     TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_checkout_sessions_merchant ON checkout_sessions (merchant_id, created_at);
+
+-- Payments taken through checkout, kept locally for lookups and risk signals
+CREATE TABLE IF NOT EXISTS checkout_payments (
+    id                    SERIAL PRIMARY KEY,
+    transaction_reference TEXT          NOT NULL UNIQUE,
+    session_id            INTEGER       REFERENCES checkout_sessions(id),
+    payment_link_id       INTEGER,
+    merchant_id           INTEGER       NOT NULL,
+    customer_id           INTEGER       NOT NULL DEFAULT 0,
+    customer_email        TEXT          NOT NULL DEFAULT '',
+    amount                NUMERIC(18,2) NOT NULL,
+    fee_amount            NUMERIC(18,2) NOT NULL DEFAULT 0,
+    net_amount            NUMERIC(18,2) NOT NULL,
+    currency              TEXT          NOT NULL,
+    payment_method        TEXT          NOT NULL DEFAULT '',
+    status                TEXT          NOT NULL, -- paid, pending_review
+    fraud_decision        TEXT          NOT NULL DEFAULT '',
+    fraud_score           DOUBLE PRECISION NOT NULL DEFAULT 0,
+    origin                TEXT          NOT NULL DEFAULT '',
+    created_at            TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_checkout_payments_customer ON checkout_payments (customer_id) WHERE customer_id <> 0;
+CREATE INDEX IF NOT EXISTS idx_checkout_payments_email ON checkout_payments (lower(customer_email)) WHERE customer_email <> '';
+CREATE INDEX IF NOT EXISTS idx_checkout_payments_merchant ON checkout_payments (merchant_id, created_at);
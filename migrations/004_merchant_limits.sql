@@ -0,0 +1,20 @@
+-- Merchant risk limits; merchants without a row get the configured onboarding defaults
+CREATE TABLE IF NOT EXISTS merchant_limits (
+    merchant_id    INTEGER       PRIMARY KEY,
+    min_amount     NUMERIC(18,2) NOT NULL DEFAULT 0, -- 0 means no limit
+    max_amount     NUMERIC(18,2) NOT NULL DEFAULT 0,
+    daily_volume   NUMERIC(18,2) NOT NULL DEFAULT 0,
+    monthly_volume NUMERIC(18,2) NOT NULL DEFAULT 0,
+    updated_at     TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+-- Per-currency caps; non-zero values override the merchant-wide limit for that currency
+CREATE TABLE IF NOT EXISTS merchant_currency_limits (
+    merchant_id    INTEGER       NOT NULL REFERENCES merchant_limits(merchant_id) ON DELETE CASCADE,
+    currency       TEXT          NOT NULL,
+    min_amount     NUMERIC(18,2) NOT NULL DEFAULT 0,
+    max_amount     NUMERIC(18,2) NOT NULL DEFAULT 0,
+    daily_volume   NUMERIC(18,2) NOT NULL DEFAULT 0,
+    monthly_volume NUMERIC(18,2) NOT NULL DEFAULT 0,
+    PRIMARY KEY (merchant_id, currency)
+);
@@ -0,0 +1,17 @@
+-- Admin-managed block and allow lists; merchant_id NULL means the entry applies to every merchant
+CREATE TABLE IF NOT EXISTS list_entries (
+    id          SERIAL PRIMARY KEY,
+    merchant_id INTEGER,
+    list_type   TEXT        NOT NULL, -- block, allow
+    kind        TEXT        NOT NULL, -- email, ip, customer, card_bin
+    value       TEXT        NOT NULL,
+    reason      TEXT        NOT NULL DEFAULT '',
+    created_by  TEXT        NOT NULL DEFAULT '',
+    expires_at  TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_list_entries_unique
+    ON list_entries (COALESCE(merchant_id, 0), list_type, kind, value);
+CREATE INDEX IF NOT EXISTS idx_list_entries_lookup ON list_entries (kind, value);
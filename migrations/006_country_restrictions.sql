@@ -0,0 +1,10 @@
+-- Per-merchant country restrictions resolved from the client IP
+CREATE TABLE IF NOT EXISTS merchant_country_rules (
+    merchant_id       INTEGER     PRIMARY KEY,
+    allowed_countries TEXT[]      NOT NULL DEFAULT '{}', -- ISO 3166-1 alpha-2; empty allows all
+    blocked_countries TEXT[]      NOT NULL DEFAULT '{}',
+    block_unknown     BOOLEAN     NOT NULL DEFAULT FALSE, -- deny when the IP cannot be resolved
+    updated_at        TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE checkout_payments ADD COLUMN IF NOT EXISTS country TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- Soft delete for payment links; deleted links are hidden from lookups and cannot be paid
+ALTER TABLE payment_links ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
@@ -0,0 +1,4 @@
+-- Supports the background job that flips active links past expires_at to expired
+CREATE INDEX IF NOT EXISTS idx_payment_links_active_expiry
+    ON payment_links (expires_at)
+    WHERE status = 'active' AND expires_at IS NOT NULL AND deleted_at IS NULL;
@@ -0,0 +1,3 @@
+-- Usage limits for payment links; use_count includes payments still in flight
+ALTER TABLE payment_links ADD COLUMN IF NOT EXISTS max_uses INT CHECK (max_uses IS NULL OR max_uses > 0);
+ALTER TABLE payment_links ADD COLUMN IF NOT EXISTS use_count INT NOT NULL DEFAULT 0;
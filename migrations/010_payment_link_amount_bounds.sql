@@ -0,0 +1,4 @@
+-- Payer amount bounds and preset amounts for open payment links
+ALTER TABLE payment_links ADD COLUMN IF NOT EXISTS min_amount BIGINT;
+ALTER TABLE payment_links ADD COLUMN IF NOT EXISTS max_amount BIGINT;
+ALTER TABLE payment_links ADD COLUMN IF NOT EXISTS suggested_amounts BIGINT[];
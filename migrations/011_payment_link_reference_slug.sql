@@ -0,0 +1,9 @@
+-- Merchant references (unique per merchant among live links) and globally unique vanity slugs.
+-- Slugs stay reserved after a soft delete so a shared URL never points at another merchant's link.
+ALTER TABLE payment_links ADD COLUMN IF NOT EXISTS reference TEXT NOT NULL DEFAULT '';
+ALTER TABLE payment_links ADD COLUMN IF NOT EXISTS slug TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payment_links_merchant_reference
+    ON payment_links (merchant_id, reference)
+    WHERE reference <> '' AND deleted_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payment_links_slug ON payment_links (slug) WHERE slug IS NOT NULL;
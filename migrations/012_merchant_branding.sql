@@ -0,0 +1,8 @@
+-- Merchant branding used on hosted pages, QR codes and documents
+CREATE TABLE IF NOT EXISTS merchant_branding (
+    merchant_id  INTEGER     PRIMARY KEY,
+    display_name TEXT        NOT NULL DEFAULT '',
+    logo         BYTEA,                          -- PNG or JPEG; NULL when no logo is set
+    logo_type    TEXT        NOT NULL DEFAULT '', -- image/png or image/jpeg
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
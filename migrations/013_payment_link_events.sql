@@ -0,0 +1,16 @@
+-- Per-link analytics: an event log for daily buckets plus running counters on the link
+CREATE TABLE IF NOT EXISTS payment_link_events (
+    id              BIGSERIAL     PRIMARY KEY,
+    payment_link_id INTEGER       NOT NULL,
+    event_type      TEXT          NOT NULL, -- view, attempt, success or fraud_denied
+    amount          NUMERIC(18,2) NOT NULL DEFAULT 0, -- collected amount on success events
+    created_at      TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_link_events_link_time ON payment_link_events (payment_link_id, created_at);
+
+ALTER TABLE payment_links ADD COLUMN IF NOT EXISTS view_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE payment_links ADD COLUMN IF NOT EXISTS attempt_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE payment_links ADD COLUMN IF NOT EXISTS success_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE payment_links ADD COLUMN IF NOT EXISTS fraud_denial_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE payment_links ADD COLUMN IF NOT EXISTS collected_amount NUMERIC(18,2) NOT NULL DEFAULT 0;
@@ -0,0 +1,4 @@
+-- Keyset pagination over a merchant's live links in the default sort order
+CREATE INDEX IF NOT EXISTS idx_payment_links_merchant_created
+    ON payment_links (merchant_id, created_at DESC, id DESC)
+    WHERE deleted_at IS NULL;
@@ -0,0 +1,3 @@
+-- Merchant-defined payer fields on payment links and the values captured with each payment
+ALTER TABLE payment_links ADD COLUMN IF NOT EXISTS custom_fields JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE checkout_payments ADD COLUMN IF NOT EXISTS custom_fields JSONB NOT NULL DEFAULT '{}';
@@ -0,0 +1,35 @@
+-- Invoices, each paid through a single-use payment link created when it is finalized.
+-- Amounts are whole currency units, like payment link amounts.
+CREATE TABLE IF NOT EXISTS invoice_sequences (
+    merchant_id INTEGER PRIMARY KEY,
+    last_number INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS invoices (
+    id                    SERIAL PRIMARY KEY,
+    merchant_id           INTEGER     NOT NULL,
+    number                INTEGER,                     -- per-merchant sequence, assigned on finalize
+    customer_name         TEXT        NOT NULL,
+    customer_email        TEXT        NOT NULL DEFAULT '',
+    currency              TEXT        NOT NULL,
+    line_items            JSONB       NOT NULL DEFAULT '[]',
+    tax_lines             JSONB       NOT NULL DEFAULT '[]',
+    subtotal              BIGINT      NOT NULL,
+    tax_total             BIGINT      NOT NULL DEFAULT 0,
+    total                 BIGINT      NOT NULL,
+    memo                  TEXT        NOT NULL DEFAULT '',
+    due_date              DATE        NOT NULL,
+    status                TEXT        NOT NULL DEFAULT 'draft', -- draft, sent, paid, overdue, void
+    payment_link_id       INTEGER     REFERENCES payment_links(id),
+    transaction_reference TEXT        NOT NULL DEFAULT '',
+    finalized_at          TIMESTAMPTZ,
+    paid_at               TIMESTAMPTZ,
+    voided_at             TIMESTAMPTZ,
+    created_at            TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at            TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (merchant_id, number)
+);
+
+CREATE INDEX IF NOT EXISTS idx_invoices_merchant ON invoices (merchant_id, created_at DESC);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_invoices_payment_link ON invoices (payment_link_id) WHERE payment_link_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_invoices_open_due ON invoices (due_date) WHERE status = 'sent';
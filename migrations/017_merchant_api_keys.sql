@@ -0,0 +1,15 @@
+-- Secret API keys merchants authenticate with. Only a SHA-256 of each key is stored; the key
+-- itself is shown once, when it is created.
+CREATE TABLE IF NOT EXISTS merchant_api_keys (
+    id           SERIAL PRIMARY KEY,
+    merchant_id  INTEGER     NOT NULL,
+    name         TEXT        NOT NULL DEFAULT '',
+    prefix       TEXT        NOT NULL,              -- first characters of the key, for display
+    key_hash     TEXT        NOT NULL UNIQUE,       -- hex SHA-256 of the full key
+    expires_at   TIMESTAMPTZ,                       -- set when the key is rotated out with a grace period
+    revoked_at   TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_merchant_api_keys_merchant ON merchant_api_keys (merchant_id, created_at DESC);
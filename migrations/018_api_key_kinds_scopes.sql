@@ -0,0 +1,5 @@
+-- Publishable keys for browser checkout, and scope restrictions for secret keys.
+-- Existing keys are secret keys with NULL scopes, which keeps them unrestricted.
+ALTER TABLE merchant_api_keys ADD COLUMN IF NOT EXISTS kind TEXT NOT NULL DEFAULT 'secret'; -- secret or publishable
+ALTER TABLE merchant_api_keys ADD COLUMN IF NOT EXISTS scopes TEXT[];                        -- NULL means every scope
+ALTER TABLE merchant_api_keys ADD COLUMN IF NOT EXISTS allowed_origins TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,4 @@
+-- Keys can require HMAC-signed requests instead of bearer use. The signing secret has to be
+-- readable to verify signatures, so for those keys it is also stored AES-GCM encrypted.
+ALTER TABLE merchant_api_keys ADD COLUMN IF NOT EXISTS auth_method TEXT NOT NULL DEFAULT 'bearer'; -- bearer or hmac
+ALTER TABLE merchant_api_keys ADD COLUMN IF NOT EXISTS secret_ciphertext BYTEA;
@@ -0,0 +1,5 @@
+-- Checkout sessions expire if they are not paid in time. Sessions created before expiry
+-- existed have no expires_at and stay open.
+ALTER TABLE checkout_sessions ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_checkout_sessions_pending_expiry ON checkout_sessions (expires_at) WHERE status = 'pending';
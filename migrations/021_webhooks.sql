@@ -0,0 +1,39 @@
+-- Merchant webhook endpoints. The signing secret has to be readable to sign deliveries, so it
+-- is stored AES-GCM encrypted like the secrets of hmac API keys.
+CREATE TABLE IF NOT EXISTS webhook_endpoints (
+    id                SERIAL PRIMARY KEY,
+    merchant_id       INTEGER     NOT NULL,
+    url               TEXT        NOT NULL,
+    description       TEXT        NOT NULL DEFAULT '',
+    events            TEXT[],                          -- NULL subscribes to every event
+    secret_prefix     TEXT        NOT NULL,            -- first characters of the secret, for display
+    secret_ciphertext BYTEA       NOT NULL,
+    status            TEXT        NOT NULL DEFAULT 'active', -- active, disabled
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at        TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_endpoints_merchant ON webhook_endpoints (merchant_id) WHERE status = 'active';
+
+-- One row per event per endpoint; this table is the delivery queue. Pending rows are claimed
+-- by pushing next_attempt_at out by a lease, so a worker that dies mid-delivery is retried.
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id               SERIAL PRIMARY KEY,
+    endpoint_id      INTEGER     NOT NULL REFERENCES webhook_endpoints(id),
+    merchant_id      INTEGER     NOT NULL,
+    event_id         TEXT        NOT NULL,
+    event_type       TEXT        NOT NULL,
+    payload          JSONB       NOT NULL,
+    status           TEXT        NOT NULL DEFAULT 'pending', -- pending, delivered, dead
+    attempts         INTEGER     NOT NULL DEFAULT 0,
+    next_attempt_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_status_code INTEGER,
+    last_error       TEXT        NOT NULL DEFAULT '',
+    delivered_at     TIMESTAMPTZ,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (endpoint_id, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_merchant ON webhook_deliveries (merchant_id, created_at DESC);
@@ -0,0 +1,4 @@
+-- Payments that fail to re-screen back off instead of being retried on every tick
+ALTER TABLE unscreened_payments ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+CREATE INDEX IF NOT EXISTS idx_unscreened_payments_due ON unscreened_payments (next_attempt_at) WHERE status = 'pending';
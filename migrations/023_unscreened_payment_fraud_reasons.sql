@@ -0,0 +1,3 @@
+-- Flagged payments handed to the rescreener keep the score and reasons they were flagged with
+ALTER TABLE unscreened_payments ADD COLUMN IF NOT EXISTS fraud_score DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE unscreened_payments ADD COLUMN IF NOT EXISTS fraud_reasons TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,3 @@
+-- Re-screens send the same country and device, email and session signals the live check did
+ALTER TABLE unscreened_payments ADD COLUMN IF NOT EXISTS country TEXT NOT NULL DEFAULT '';
+ALTER TABLE unscreened_payments ADD COLUMN IF NOT EXISTS fraud_signals JSONB NOT NULL DEFAULT '{}';